@@ -0,0 +1,77 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrUnknownTool is returned by RunTools when the model calls a tool that has
+// no registered handler.
+var ErrUnknownTool = errors.New("no handler for tool")
+
+// ErrMaxTurnsExceeded is returned by RunTools when the model is still calling
+// tools after maxTurns calls to Generate.
+var ErrMaxTurnsExceeded = errors.New("tool loop exceeded max turns")
+
+// RunTools runs the tool-calling loop: it calls Generate, passes each tool call
+// in the response to the handler registered under the tool's name, appends the
+// results as RoleTool messages and calls Generate again, until the model answers
+// without calling a tool. A handler error is sent back to the model as the
+// tool result, so it can retry or explain the failure.
+//
+// maxTurns caps the calls to Generate. If the model is still calling tools
+// after the last one, RunTools returns that response along with an error
+// wrapping ErrMaxTurnsExceeded. The returned response's Usage is summed over
+// all turns. req is not modified.
+func RunTools(ctx context.Context, client Client, req *Request, handlers map[string]func(args json.RawMessage) (string, error), maxTurns int) (*Response, error) {
+	resp, _, err := RunToolsWithTranscript(ctx, client, req, handlers, maxTurns)
+	return resp, err
+}
+
+// RunToolsWithTranscript is like RunTools but also returns the transcript: the
+// messages added to req.Messages during the loop, in order. It holds every
+// assistant turn, including the final answer, and every tool result. On error
+// the transcript so far is returned, so it can be inspected or persisted.
+func RunToolsWithTranscript(ctx context.Context, client Client, req *Request, handlers map[string]func(args json.RawMessage) (string, error), maxTurns int) (*Response, []Message, error) {
+	if maxTurns < 1 {
+		return nil, nil, fmt.Errorf("max turns must be positive, got %d", maxTurns)
+	}
+	conv := req.Clone()
+	start := len(conv.Messages)
+	transcript := func() []Message { return slices.Clip(conv.Messages[start:]) }
+	var usage *Usage
+	for turn := 1; ; turn++ {
+		resp, err := client.Generate(ctx, conv)
+		if err != nil {
+			return nil, transcript(), err
+		}
+		usage = addUsage(usage, resp.Usage)
+		resp.Usage = usage
+		conv.Messages = append(conv.Messages, Message{Role: RoleAssistant, Content: resp.Text, ToolCalls: resp.ToolCalls})
+		if len(resp.ToolCalls) == 0 {
+			return resp, transcript(), nil
+		}
+		if turn == maxTurns {
+			return resp, transcript(), fmt.Errorf("%w: %d", ErrMaxTurnsExceeded, maxTurns)
+		}
+
+		for _, call := range resp.ToolCalls {
+			handler, ok := handlers[call.Function]
+			if !ok {
+				return nil, transcript(), fmt.Errorf("%w %q", ErrUnknownTool, call.Function)
+			}
+			args := json.RawMessage(call.Arguments)
+			if len(args) == 0 {
+				args = json.RawMessage("{}")
+			}
+			result, err := handler(args)
+			if err != nil {
+				result = "error: " + err.Error()
+			}
+			conv.Messages = append(conv.Messages, Message{Role: RoleTool, ToolCallID: call.ID, Content: result})
+		}
+	}
+}
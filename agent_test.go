@@ -0,0 +1,154 @@
+package ai_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/liuzl/ai"
+)
+
+func TestRunTools(t *testing.T) {
+	const toolCallReply = `{"choices":[{"message":{"role":"assistant","tool_calls":[{"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Paris\"}"}}]}}],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`
+	const answerReply = `{"choices":[{"message":{"role":"assistant","content":"It is sunny in Paris."},"finish_reason":"stop"}],"usage":{"prompt_tokens":20,"completion_tokens":7,"total_tokens":27}}`
+
+	newClient := func(t *testing.T, replies ...string) (ai.Client, *[]map[string]any) {
+		t.Helper()
+		var bodies []map[string]any
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var body map[string]any
+			json.NewDecoder(r.Body).Decode(&body)
+			bodies = append(bodies, body)
+			fmt.Fprint(w, replies[min(len(bodies), len(replies))-1])
+		}))
+		t.Cleanup(server.Close)
+		client, err := ai.NewClient(ai.WithProvider(ai.ProviderOpenAI), ai.WithAPIKey("test-key"), ai.WithBaseURL(server.URL))
+		if err != nil {
+			t.Fatalf("failed to create client: %v", err)
+		}
+		return client, &bodies
+	}
+	req := &ai.Request{Messages: []ai.Message{{Role: ai.RoleUser, Content: "Weather in Paris?"}}}
+
+	t.Run("tool then answer", func(t *testing.T) {
+		client, bodies := newClient(t, toolCallReply, answerReply)
+		var gotArgs string
+		handlers := map[string]func(json.RawMessage) (string, error){
+			"get_weather": func(args json.RawMessage) (string, error) {
+				gotArgs = string(args)
+				return "sunny", nil
+			},
+		}
+		resp, err := ai.RunTools(context.Background(), client, req, handlers, 5)
+		if err != nil {
+			t.Fatalf("RunTools failed: %v", err)
+		}
+		if resp.Text != "It is sunny in Paris." {
+			t.Errorf("expected the final answer, got %q", resp.Text)
+		}
+		if gotArgs != `{"city":"Paris"}` {
+			t.Errorf("expected the tool call arguments, got %q", gotArgs)
+		}
+		if resp.Usage == nil || resp.Usage.TotalTokens != 42 {
+			t.Errorf("expected usage summed over both turns, got %+v", resp.Usage)
+		}
+		if len(*bodies) != 2 {
+			t.Fatalf("expected 2 calls, got %d", len(*bodies))
+		}
+		msgs := (*bodies)[1]["messages"].([]any)
+		if len(msgs) != 3 {
+			t.Fatalf("expected user, assistant and tool messages, got %v", msgs)
+		}
+		toolMsg := msgs[2].(map[string]any)
+		if toolMsg["role"] != "tool" || toolMsg["tool_call_id"] != "call_1" || toolMsg["content"] != "sunny" {
+			t.Errorf("expected the tool result message, got %v", toolMsg)
+		}
+		if len(req.Messages) != 1 {
+			t.Errorf("expected the caller's request to be unchanged, got %d messages", len(req.Messages))
+		}
+	})
+
+	t.Run("handler error is sent to the model", func(t *testing.T) {
+		client, bodies := newClient(t, toolCallReply, answerReply)
+		handlers := map[string]func(json.RawMessage) (string, error){
+			"get_weather": func(json.RawMessage) (string, error) { return "", errors.New("service down") },
+		}
+		if _, err := ai.RunTools(context.Background(), client, req, handlers, 5); err != nil {
+			t.Fatalf("RunTools failed: %v", err)
+		}
+		toolMsg := (*bodies)[1]["messages"].([]any)[2].(map[string]any)
+		if toolMsg["content"] != "error: service down" {
+			t.Errorf("expected the handler error as the tool result, got %v", toolMsg["content"])
+		}
+	})
+
+	t.Run("unknown tool", func(t *testing.T) {
+		client, _ := newClient(t, toolCallReply)
+		_, err := ai.RunTools(context.Background(), client, req, nil, 5)
+		if !errors.Is(err, ai.ErrUnknownTool) {
+			t.Errorf("expected ErrUnknownTool, got %v", err)
+		}
+	})
+
+	t.Run("max turns", func(t *testing.T) {
+		client, bodies := newClient(t, toolCallReply)
+		handlers := map[string]func(json.RawMessage) (string, error){
+			"get_weather": func(json.RawMessage) (string, error) { return "sunny", nil },
+		}
+		resp, err := ai.RunTools(context.Background(), client, req, handlers, 3)
+		if !errors.Is(err, ai.ErrMaxTurnsExceeded) {
+			t.Fatalf("expected ErrMaxTurnsExceeded, got %v", err)
+		}
+		if resp == nil || len(resp.ToolCalls) != 1 {
+			t.Errorf("expected the last response with its pending tool call, got %+v", resp)
+		}
+		if len(*bodies) != 3 {
+			t.Errorf("expected 3 calls, got %d", len(*bodies))
+		}
+	})
+
+	t.Run("transcript", func(t *testing.T) {
+		const secondToolCallReply = `{"choices":[{"message":{"role":"assistant","content":"Checking the forecast too.","tool_calls":[{"id":"call_2","type":"function","function":{"name":"get_forecast","arguments":"{}"}}]}}]}`
+		client, _ := newClient(t, toolCallReply, secondToolCallReply, answerReply)
+		handlers := map[string]func(json.RawMessage) (string, error){
+			"get_weather":  func(json.RawMessage) (string, error) { return "sunny", nil },
+			"get_forecast": func(json.RawMessage) (string, error) { return "rain tomorrow", nil },
+		}
+		resp, transcript, err := ai.RunToolsWithTranscript(context.Background(), client, req, handlers, 5)
+		if err != nil {
+			t.Fatalf("RunToolsWithTranscript failed: %v", err)
+		}
+		if resp.Text != "It is sunny in Paris." {
+			t.Errorf("expected the final answer, got %q", resp.Text)
+		}
+
+		want := []struct {
+			role    ai.Role
+			content string
+			callID  string
+		}{
+			{ai.RoleAssistant, "", "call_1"},
+			{ai.RoleTool, "sunny", "call_1"},
+			{ai.RoleAssistant, "Checking the forecast too.", "call_2"},
+			{ai.RoleTool, "rain tomorrow", "call_2"},
+			{ai.RoleAssistant, "It is sunny in Paris.", ""},
+		}
+		if len(transcript) != len(want) {
+			t.Fatalf("expected %d transcript messages, got %d: %+v", len(want), len(transcript), transcript)
+		}
+		for i, w := range want {
+			msg := transcript[i]
+			callID := msg.ToolCallID
+			if msg.Role == ai.RoleAssistant && len(msg.ToolCalls) > 0 {
+				callID = msg.ToolCalls[0].ID
+			}
+			if msg.Role != w.role || msg.Content != w.content || callID != w.callID {
+				t.Errorf("transcript[%d]: expected %s %q (%q), got %+v", i, w.role, w.content, w.callID, msg)
+			}
+		}
+	})
+}
@@ -3,9 +3,14 @@ package ai
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
+	"net/http"
 	"net/url"
 	"os"
+	"slices"
 	"strings"
 	"time"
 )
@@ -19,19 +24,126 @@ const (
 	ProviderOpenAI    Provider = "openai"
 	ProviderGemini    Provider = "gemini"
 	ProviderAnthropic Provider = "anthropic"
+	ProviderMistral   Provider = "mistral"
 )
 
 // Client is the unified interface for different AI providers.
+//
+// Clients returned by NewClient also implement io.Closer, releasing their idle
+// connections; see CloseClient.
 type Client interface {
+	// Generate sends req to the provider. It never modifies req, so one
+	// request may be reused across calls and clients.
 	Generate(ctx context.Context, req *Request) (*Response, error)
 }
 
+// CloseClient closes client if it implements io.Closer and does nothing
+// otherwise. Services that create and discard clients should close them so
+// their idle connections are not left open.
+func CloseClient(client Client) error {
+	if closer, ok := client.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
 // Request is a universal request structure for content generation.
 type Request struct {
-	Model        string
+	Model string
+	// SystemPrompt sets the system instruction. When empty, a leading RoleSystem
+	// message in Messages is used instead.
 	SystemPrompt string
 	Messages     []Message
 	Tools        []Tool
+	// StopSequences are strings that cause the model to stop generating when produced.
+	StopSequences []string
+	// Temperature controls sampling randomness; nil uses the provider default.
+	Temperature *float64
+	// TopP limits sampling to the most likely tokens whose probabilities sum to
+	// TopP (nucleus sampling); nil uses the provider default.
+	TopP *float64
+	// TopK limits sampling to the TopK most likely tokens; nil uses the
+	// provider default. Gemini and Anthropic support it; OpenAI does not.
+	TopK *int
+	// MaxTokens caps the tokens generated in the response. Zero uses the
+	// model's OutputTokenLimit when known (see WithModelInfo), or else the
+	// provider default: 4096 for Anthropic, which requires a limit, 8192 for
+	// Gemini, and no limit for OpenAI.
+	MaxTokens int
+	// LogitBias maps token IDs to a bias added to their logits. Only OpenAI's
+	// non-reasoning models support it; see WithParameterCompatibility.
+	LogitBias map[string]int
+	// Thinking enables extended thinking (reasoning before the answer) on
+	// providers that support it; the reasoning is returned in Response.Reasoning.
+	Thinking *ThinkingConfig
+	// ReasoningEffort ("low", "medium" or "high") controls how long OpenAI
+	// reasoning models think before answering; empty uses the provider default.
+	ReasoningEffort string
+	// Logprobs requests the log probability of each generated token, returned in
+	// Response.Logprobs. Only OpenAI supports it; see WithParameterCompatibility.
+	Logprobs bool
+	// ParallelToolCalls, when set to false, limits OpenAI models to one tool call
+	// per turn; nil uses the provider default. Other providers ignore it.
+	ParallelToolCalls *bool
+	// ProviderExtra holds extra request body fields keyed by provider name
+	// ("openai", "gemini", "anthropic" or "mistral"). Each value is a JSON object whose
+	// fields are merged into that provider's request body as sent, overriding
+	// any the library sets, for parameters this library does not model yet.
+	ProviderExtra map[string]json.RawMessage
+}
+
+// ThinkingConfig configures extended thinking.
+type ThinkingConfig struct {
+	// BudgetTokens caps the tokens spent on thinking. Anthropic requires at least 1024.
+	BudgetTokens int
+}
+
+// Clone returns a deep copy of the request that can be modified without
+// affecting r. Image readers are shared, since a reader cannot be copied.
+func (r *Request) Clone() *Request {
+	c := *r
+	if r.Messages != nil {
+		c.Messages = make([]Message, len(r.Messages))
+		for i, msg := range r.Messages {
+			c.Messages[i] = msg.clone()
+		}
+	}
+	if r.Tools != nil {
+		c.Tools = make([]Tool, len(r.Tools))
+		for i, tool := range r.Tools {
+			tool.Function.Parameters = slices.Clone(tool.Function.Parameters)
+			c.Tools[i] = tool
+		}
+	}
+	c.StopSequences = slices.Clone(r.StopSequences)
+	if r.Temperature != nil {
+		t := *r.Temperature
+		c.Temperature = &t
+	}
+	if r.TopP != nil {
+		p := *r.TopP
+		c.TopP = &p
+	}
+	if r.TopK != nil {
+		k := *r.TopK
+		c.TopK = &k
+	}
+	c.LogitBias = maps.Clone(r.LogitBias)
+	if r.ParallelToolCalls != nil {
+		p := *r.ParallelToolCalls
+		c.ParallelToolCalls = &p
+	}
+	if r.ProviderExtra != nil {
+		c.ProviderExtra = make(map[string]json.RawMessage, len(r.ProviderExtra))
+		for provider, extra := range r.ProviderExtra {
+			c.ProviderExtra[provider] = slices.Clone(extra)
+		}
+	}
+	if r.Thinking != nil {
+		t := *r.Thinking
+		c.Thinking = &t
+	}
+	return &c
 }
 
 // Validate checks if the request is valid and returns an error if not.
@@ -144,9 +256,130 @@ func (r *Request) Validate() error {
 		return fmt.Errorf("model cannot be whitespace only")
 	}
 
+	if r.Thinking != nil && r.Thinking.BudgetTokens <= 0 {
+		return fmt.Errorf("thinking budget must be positive, got %d", r.Thinking.BudgetTokens)
+	}
+
+	switch r.ReasoningEffort {
+	case "", "low", "medium", "high":
+	default:
+		return fmt.Errorf("reasoning effort must be low, medium or high, got %q", r.ReasoningEffort)
+	}
+
+	if r.Temperature != nil && *r.Temperature < 0 {
+		return fmt.Errorf("temperature cannot be negative, got %g", *r.Temperature)
+	}
+
+	if r.TopP != nil && (*r.TopP < 0 || *r.TopP > 1) {
+		return fmt.Errorf("top_p must be between 0 and 1, got %g", *r.TopP)
+	}
+
+	if r.TopK != nil && *r.TopK < 0 {
+		return fmt.Errorf("top_k cannot be negative, got %d", *r.TopK)
+	}
+
+	if r.MaxTokens < 0 {
+		return fmt.Errorf("max tokens cannot be negative, got %d", r.MaxTokens)
+	}
+
+	for provider, extra := range r.ProviderExtra {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(extra, &fields); err != nil {
+			return fmt.Errorf("provider_extra[%s]: must be a JSON object: %w", provider, err)
+		}
+	}
+
+	return nil
+}
+
+// ValidateToolResults checks that every tool call issued by an assistant message is
+// answered, in the tool messages that immediately follow it, by exactly one tool
+// result carrying the matching ToolCallID, and that no tool result refers to an
+// unknown call. Providers such as OpenAI reject conversations that break this pairing.
+func (r *Request) ValidateToolResults() error {
+	for i := 0; i < len(r.Messages); i++ {
+		msg := r.Messages[i]
+		if msg.Role == RoleTool {
+			return fmt.Errorf("message[%d]: tool result %q does not follow an assistant message with tool calls", i, msg.ToolCallID)
+		}
+		if msg.Role != RoleAssistant || len(msg.ToolCalls) == 0 {
+			continue
+		}
+
+		pending := make(map[string]bool, len(msg.ToolCalls))
+		for j, tc := range msg.ToolCalls {
+			if tc.ID == "" {
+				return fmt.Errorf("message[%d].tool_calls[%d]: tool call must have an ID", i, j)
+			}
+			if pending[tc.ID] {
+				return fmt.Errorf("message[%d].tool_calls[%d]: duplicate tool call ID %q", i, j, tc.ID)
+			}
+			pending[tc.ID] = true
+		}
+
+		for i+1 < len(r.Messages) && r.Messages[i+1].Role == RoleTool {
+			i++
+			id := r.Messages[i].ToolCallID
+			if !pending[id] {
+				return fmt.Errorf("message[%d]: tool result %q does not match any pending tool call", i, id)
+			}
+			delete(pending, id)
+		}
+
+		for _, tc := range msg.ToolCalls {
+			if pending[tc.ID] {
+				return fmt.Errorf("message[%d]: tool call %q (%s) has no tool result", i, tc.ID, tc.Function)
+			}
+		}
+	}
 	return nil
 }
 
+// AssistantPrefill returns the text of a trailing assistant message, if any.
+// A trailing assistant message "prefills" the start of the model's reply, which is
+// useful for forcing JSON or a specific format. Anthropic and Mistral support
+// this natively; the OpenAI and Gemini adapters emulate it by asking the model
+// to continue the message, so the reply is likely, not certain, to follow it.
+func (r *Request) AssistantPrefill() (string, bool) {
+	if len(r.Messages) == 0 {
+		return "", false
+	}
+	last := r.Messages[len(r.Messages)-1]
+	if last.Role != RoleAssistant || len(last.ToolCalls) > 0 {
+		return "", false
+	}
+	text := last.Content
+	for _, part := range last.ContentParts {
+		if part.Type == ContentTypeText {
+			text += part.Text
+		}
+	}
+	if text == "" {
+		return "", false
+	}
+	return text, true
+}
+
+// hoistSystemMessage returns r with a leading RoleSystem message moved into
+// SystemPrompt, for callers used to OpenAI's system-message convention. r is
+// returned unchanged when SystemPrompt is already set or there is no such message.
+func (r *Request) hoistSystemMessage() *Request {
+	if r.SystemPrompt != "" || len(r.Messages) == 0 || r.Messages[0].Role != RoleSystem {
+		return r
+	}
+	first := r.Messages[0]
+	text := first.Content
+	for _, part := range first.ContentParts {
+		if part.Type == ContentTypeText {
+			text += part.Text
+		}
+	}
+	hoisted := *r
+	hoisted.SystemPrompt = text
+	hoisted.Messages = r.Messages[1:]
+	return &hoisted
+}
+
 // validateImageSource validates an image source
 func validateImageSource(src *ImageSource, msgIdx, partIdx int) error {
 	switch src.Type {
@@ -158,6 +391,11 @@ func validateImageSource(src *ImageSource, msgIdx, partIdx int) error {
 		if strings.TrimSpace(src.Data) == "" {
 			return fmt.Errorf("message[%d].content_parts[%d]: image data cannot be empty", msgIdx, partIdx)
 		}
+	case ImageSourceTypeReader:
+		// Emptiness can only be checked once the reader is consumed at send time.
+		if src.Reader == nil {
+			return fmt.Errorf("message[%d].content_parts[%d]: image reader cannot be nil", msgIdx, partIdx)
+		}
 	default:
 		return fmt.Errorf("message[%d].content_parts[%d]: invalid image source type %q", msgIdx, partIdx, src.Type)
 	}
@@ -182,6 +420,151 @@ func validateMediaSource(isURL bool, url, data, mediaType string, msgIdx, partId
 type Response struct {
 	Text      string
 	ToolCalls []ToolCall
+	// Reasoning is the model's thinking, when requested with Request.Thinking
+	// and returned by the provider. It is not part of Text.
+	Reasoning string
+	// FinishReason is why the model stopped, normalized across providers.
+	FinishReason FinishReason
+	// Usage is the provider-reported token usage, or nil if none was reported.
+	Usage *Usage
+	// Citations are the sources the model cited, e.g. with web search or grounding enabled.
+	Citations []Citation
+	// Warnings are non-fatal issues with the response, reported by the provider
+	// or detected by this package, such as truncation at the output token limit.
+	Warnings []string
+	// Logprobs are the per-token log probabilities, when requested with Request.Logprobs.
+	Logprobs []TokenLogprob
+	// Media is the non-text output, such as images from Gemini image
+	// generation models. It is empty for text-only responses.
+	Media []MediaOutput
+	// Raw is the provider's response body, untouched, when enabled with
+	// WithRawResponse. With WithAutoContinue it is the body of the last call.
+	Raw json.RawMessage
+}
+
+// MediaOutput is a media item generated by the model.
+type MediaOutput struct {
+	// MimeType is the media type, e.g. "image/png".
+	MimeType string
+	// Data is the base64-encoded content.
+	Data string
+}
+
+// TokenLogprob is the log probability of one generated token.
+type TokenLogprob struct {
+	Token   string
+	Logprob float64
+}
+
+// Citation is a source cited by the model. Fields a provider does not report are zero.
+type Citation struct {
+	URL   string
+	Title string
+	// Text is the cited passage or the response segment supported by the source.
+	Text string
+	// StartIndex and EndIndex delimit the supported span of the response text.
+	StartIndex int
+	EndIndex   int
+}
+
+// appendCitations appends the citations in add that are not already in list.
+func appendCitations(list []Citation, add ...Citation) []Citation {
+	for _, c := range add {
+		if !slices.Contains(list, c) {
+			list = append(list, c)
+		}
+	}
+	return list
+}
+
+// Usage reports the tokens consumed by a call, as counted by the provider.
+type Usage struct {
+	InputTokens  int
+	OutputTokens int
+	TotalTokens  int
+	// ReasoningTokens is the part of OutputTokens spent on hidden reasoning,
+	// when the provider reports it.
+	ReasoningTokens int
+}
+
+// addUsage returns the sum of a and b; nil values count as zero.
+func addUsage(a, b *Usage) *Usage {
+	if a == nil && b == nil {
+		return nil
+	}
+	sum := &Usage{}
+	for _, u := range []*Usage{a, b} {
+		if u != nil {
+			sum.InputTokens += u.InputTokens
+			sum.OutputTokens += u.OutputTokens
+			sum.TotalTokens += u.TotalTokens
+			sum.ReasoningTokens += u.ReasoningTokens
+		}
+	}
+	return sum
+}
+
+// FinishReason is the normalized reason a model stopped generating.
+// Provider values without a normalized equivalent are passed through lowercased.
+type FinishReason string
+
+const (
+	FinishReasonStop          FinishReason = "stop"           // Natural end of turn or a stop sequence
+	FinishReasonLength        FinishReason = "length"         // Output token limit reached
+	FinishReasonToolCalls     FinishReason = "tool_calls"     // The model requested tool calls
+	FinishReasonContentFilter FinishReason = "content_filter" // Output withheld by a safety filter
+)
+
+// Merge combines r with other into a new Response, for example a streamed
+// partial with the result of a follow-up request. Text, Reasoning, Logprobs
+// and Media are concatenated (r's first) and tool calls are unioned by ID: a call from
+// other replaces one in r with the same ID, since the later response is assumed
+// to be more complete. Calls without an ID are always kept, other's
+// FinishReason wins when set, Usage is summed, and Citations and Warnings are
+// unioned. Neither input is modified; either may be nil.
+func (r *Response) Merge(other *Response) *Response {
+	if r == nil {
+		return copyResponse(other)
+	}
+	merged := copyResponse(r)
+	if other == nil {
+		return merged
+	}
+	merged.Text += other.Text
+	merged.Reasoning += other.Reasoning
+	merged.Logprobs = append(merged.Logprobs, other.Logprobs...)
+	merged.Media = append(merged.Media, other.Media...)
+	merged.Usage = addUsage(merged.Usage, other.Usage)
+	merged.Citations = appendCitations(merged.Citations, other.Citations...)
+	for _, w := range other.Warnings {
+		if !slices.Contains(merged.Warnings, w) {
+			merged.Warnings = append(merged.Warnings, w)
+		}
+	}
+	if other.FinishReason != "" {
+		merged.FinishReason = other.FinishReason
+	}
+	if other.Raw != nil {
+		merged.Raw = other.Raw
+	}
+
+	index := make(map[string]int, len(merged.ToolCalls))
+	for i, tc := range merged.ToolCalls {
+		if tc.ID != "" {
+			index[tc.ID] = i
+		}
+	}
+	for _, tc := range other.ToolCalls {
+		if i, ok := index[tc.ID]; ok && tc.ID != "" {
+			merged.ToolCalls[i] = tc
+			continue
+		}
+		if tc.ID != "" {
+			index[tc.ID] = len(merged.ToolCalls)
+		}
+		merged.ToolCalls = append(merged.ToolCalls, tc)
+	}
+	return merged
 }
 
 // Role defines the originator of a message.
@@ -215,20 +598,47 @@ type ContentPart struct {
 	DocumentSource *DocumentSource // For document parts (PDF, etc.)
 }
 
+// ErrMissingContentSource is returned when building a provider request from a
+// media ContentPart whose source field is nil.
+var ErrMissingContentSource = errors.New("content part has no source")
+
+// checkSource reports an error wrapping ErrMissingContentSource if p is a media
+// part without the source for its type, so adapters never emit an empty part.
+func (p ContentPart) checkSource() error {
+	var field string
+	switch {
+	case p.Type == ContentTypeImage && p.ImageSource == nil:
+		field = "ImageSource"
+	case p.Type == ContentTypeAudio && p.AudioSource == nil:
+		field = "AudioSource"
+	case p.Type == ContentTypeVideo && p.VideoSource == nil:
+		field = "VideoSource"
+	case p.Type == ContentTypeDocument && p.DocumentSource == nil:
+		field = "DocumentSource"
+	default:
+		return nil
+	}
+	return fmt.Errorf("%w: %s part requires %s", ErrMissingContentSource, p.Type, field)
+}
+
 // ImageSourceType defines how an image is provided.
 type ImageSourceType string
 
 const (
 	ImageSourceTypeURL    ImageSourceType = "url"
 	ImageSourceTypeBase64 ImageSourceType = "base64"
+	// ImageSourceTypeReader images are read from Reader and base64-encoded when the
+	// request is sent, so large images need not be encoded up front.
+	ImageSourceTypeReader ImageSourceType = "reader"
 )
 
 // ImageSource represents an image input for vision-enabled models.
 type ImageSource struct {
-	Type   ImageSourceType // "url" or "base64"
+	Type   ImageSourceType // "url", "base64" or "reader"
 	URL    string          // HTTP(S) URL to the image
 	Data   string          // Base64-encoded image data (with or without data URI prefix)
 	Format string          // Image format: "png", "jpeg", "gif", "webp" (optional, can be auto-detected)
+	Reader io.Reader       `json:"-"` // Raw image bytes, read once when the request is sent
 }
 
 // MediaSourceType defines how media (audio/video/document) is provided.
@@ -245,7 +655,7 @@ type AudioSource struct {
 	Type   MediaSourceType // "url" or "base64"
 	URL    string          // HTTP(S) URL to the audio file
 	Data   string          // Base64-encoded audio data
-	Format string          // Audio format: "mp3", "wav", "aiff", "aac", "ogg", "flac"
+	Format string          // Audio format: "mp3", "wav", "aiff", "aac", "ogg", "flac", "m4a"
 }
 
 // VideoSource represents a video input for video-enabled models (primarily Gemini).
@@ -270,12 +680,47 @@ type DocumentSource struct {
 // Supports both simple text messages (Content) and multimodal messages (ContentParts).
 type Message struct {
 	Role         Role
+	Name         string        // Participant name for multi-agent chats; sent to OpenAI only
 	Content      string        // Simple text content (for backward compatibility)
 	ContentParts []ContentPart // Multimodal content (text + images, etc.)
 	ToolCalls    []ToolCall
 	ToolCallID   string
 }
 
+// clone returns a deep copy of m.
+func (m Message) clone() Message {
+	m.ToolCalls = slices.Clone(m.ToolCalls)
+	if m.ContentParts != nil {
+		parts := make([]ContentPart, len(m.ContentParts))
+		for i, part := range m.ContentParts {
+			parts[i] = part.clone()
+		}
+		m.ContentParts = parts
+	}
+	return m
+}
+
+// clone returns a copy of p with its own source values.
+func (p ContentPart) clone() ContentPart {
+	if p.ImageSource != nil {
+		src := *p.ImageSource
+		p.ImageSource = &src
+	}
+	if p.AudioSource != nil {
+		src := *p.AudioSource
+		p.AudioSource = &src
+	}
+	if p.VideoSource != nil {
+		src := *p.VideoSource
+		p.VideoSource = &src
+	}
+	if p.DocumentSource != nil {
+		src := *p.DocumentSource
+		p.DocumentSource = &src
+	}
+	return p
+}
+
 // Tool defines a tool the model can use.
 type Tool struct {
 	Type     string             `json:"type"`
@@ -305,6 +750,53 @@ type Config struct {
 	baseURL  string
 	model    string // Added model to the config
 	timeout  time.Duration
+
+	userAgent           string
+	proxyURL            string
+	forceHTTP1          bool
+	insecureSkipVerify  bool
+	coalesceRequests    bool
+	strictValidation    bool
+	tokenizer           Tokenizer
+	contextWindow       int
+	maxIdleConns        int
+	maxIdleConnsPerHost int
+	idleConnTimeout     time.Duration
+	dialTimeout         time.Duration
+	tlsTimeout          time.Duration
+	headerTimeout       time.Duration
+	maxResponseSize     int64
+	downloadTimeout     time.Duration
+	downloadBudget      float64
+	mediaFetcher        func(ctx context.Context, url string) ([]byte, string, error)
+	imageMaxDimension   int
+	streamIdleTimeout   time.Duration
+	streamTimeout       time.Duration
+	retryBaseDelay      time.Duration
+	retryMaxDelay       time.Duration
+	retryBudget         time.Duration
+	autoContinueRounds  int
+	repairToolArguments bool
+	rawResponse         bool
+	mergeMessages       bool
+	paramCompatibility  ParameterCompatibility
+	anthropicImageMode  AnthropicImageMode
+	onDroppedParameter  func(provider Provider, model, param string)
+	defaults            Defaults
+	defaultSystemPrompt string
+	modelInfo           []ModelInfo
+	responseMiddleware  []func(*Response) error
+	recorder            io.Writer
+	transport           http.RoundTripper // replaces the default transport; set by NewReplayClient
+}
+
+// Defaults are request fields a client fills in on every request that leaves
+// them unset (empty, nil or zero). Values set on the request always win.
+type Defaults struct {
+	Model        string // WithModel takes precedence when both are given
+	SystemPrompt string // WithDefaultSystemPrompt takes precedence when both are given
+	Temperature  *float64
+	MaxTokens    int
 }
 
 // Option is the function signature for Configuration options.
@@ -320,21 +812,323 @@ func WithAPIKey(apiKey string) Option {
 	return func(c *Config) { c.apiKey = apiKey }
 }
 
-// WithBaseURL sets a custom base URL for the API endpoint.
+// WithBaseURL sets a custom base URL for the API endpoint, replacing the
+// provider's default.
 func WithBaseURL(baseURL string) Option {
 	return func(c *Config) { c.baseURL = baseURL }
 }
 
+// defaultBaseURLs maps each provider to the base URL used when WithBaseURL is
+// not given.
+var defaultBaseURLs = map[Provider]string{
+	ProviderOpenAI:    "https://api.openai.com",
+	ProviderGemini:    "https://generativelanguage.googleapis.com",
+	ProviderAnthropic: "https://api.anthropic.com",
+	ProviderMistral:   "https://api.mistral.ai",
+}
+
+// resolveBaseURL returns the configured base URL, or the provider's default.
+func (c *Config) resolveBaseURL() string {
+	if c.baseURL != "" {
+		return c.baseURL
+	}
+	return defaultBaseURLs[c.provider]
+}
+
 // WithModel sets the model name to use for the client.
 func WithModel(model string) Option {
 	return func(c *Config) { c.model = model }
 }
 
-// WithTimeout sets the HTTP client timeout.
+// WithDefaultSystemPrompt sets the system prompt used by requests that have
+// none, neither in SystemPrompt nor as a leading RoleSystem message. A
+// request's own system prompt always wins.
+func WithDefaultSystemPrompt(prompt string) Option {
+	return func(c *Config) { c.defaultSystemPrompt = prompt }
+}
+
+// WithDefaults sets app-wide defaults merged into each request where the
+// request leaves the field unset, so they need not be repeated on every call.
+func WithDefaults(d Defaults) Option {
+	return func(c *Config) { c.defaults = d }
+}
+
+// WithModelInfo registers model metadata, typically from ListModels, so a
+// model's OutputTokenLimit is used as its default Request.MaxTokens and, with
+// WithTokenizer, its ContextWindow bounds the local pre-check. A client's own
+// ListModels results are registered automatically.
+func WithModelInfo(models ...ModelInfo) Option {
+	return func(c *Config) { c.modelInfo = append(c.modelInfo, models...) }
+}
+
+// WithTimeout sets the HTTP client timeout for unary calls such as Generate.
+// Streams are bounded by WithStreamTimeout instead.
 func WithTimeout(timeout time.Duration) Option {
 	return func(c *Config) { c.timeout = timeout }
 }
 
+// WithUserAgent sets the User-Agent header sent with every provider request.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Config) { c.userAgent = userAgent }
+}
+
+// WithProxyURL routes all provider requests through the given HTTP(S) or
+// SOCKS5 proxy, e.g. "http://proxy.corp.example:3128".
+func WithProxyURL(proxyURL string) Option {
+	return func(c *Config) { c.proxyURL = proxyURL }
+}
+
+// WithForceHTTP1 pins the client to HTTP/1.1, disabling the automatic HTTP/2 upgrade.
+// This is an escape hatch for proxies or middleboxes that misbehave on HTTP/2.
+func WithForceHTTP1() Option {
+	return func(c *Config) { c.forceHTTP1 = true }
+}
+
+// WithInsecureSkipVerify disables TLS certificate verification, for testing
+// against self-hosted endpoints with self-signed certificates. It logs a
+// warning when the client is created. Never enable it in production.
+func WithInsecureSkipVerify(skip bool) Option {
+	return func(c *Config) { c.insecureSkipVerify = skip }
+}
+
+// WithMaxIdleConns sets the maximum number of idle (keep-alive) connections
+// kept across all hosts.
+func WithMaxIdleConns(n int) Option {
+	return func(c *Config) { c.maxIdleConns = n }
+}
+
+// WithMaxIdleConnsPerHost sets the maximum number of idle (keep-alive) connections
+// kept per host. Raise this for high-throughput workloads against a single provider.
+func WithMaxIdleConnsPerHost(n int) Option {
+	return func(c *Config) { c.maxIdleConnsPerHost = n }
+}
+
+// WithIdleConnTimeout sets how long an idle connection stays in the pool before being closed.
+func WithIdleConnTimeout(timeout time.Duration) Option {
+	return func(c *Config) { c.idleConnTimeout = timeout }
+}
+
+// WithDialTimeout bounds establishing a TCP connection. Unlike WithTimeout it
+// does not limit reading the response, so it suits streaming. A dial that
+// times out returns a *TimeoutError of kind TimeoutKindConnect.
+func WithDialTimeout(timeout time.Duration) Option {
+	return func(c *Config) { c.dialTimeout = timeout }
+}
+
+// WithTLSHandshakeTimeout bounds the TLS handshake of a new connection. A
+// handshake that times out returns a *TimeoutError of kind TimeoutKindConnect.
+func WithTLSHandshakeTimeout(timeout time.Duration) Option {
+	return func(c *Config) { c.tlsTimeout = timeout }
+}
+
+// WithResponseHeaderTimeout bounds the wait for the response headers after the
+// request is sent. The body, such as a long stream, is not limited by it.
+func WithResponseHeaderTimeout(timeout time.Duration) Option {
+	return func(c *Config) { c.headerTimeout = timeout }
+}
+
+// WithMaxResponseSize caps the size in bytes of a provider response body.
+// Responses larger than the limit fail with ErrResponseTooLarge instead of
+// being loaded into memory. The default is 10 MB.
+func WithMaxResponseSize(bytes int64) Option {
+	return func(c *Config) { c.maxResponseSize = bytes }
+}
+
+// WithDownloadTimeout bounds each media download performed while building a
+// request: Gemini fetches image, audio, video and document URLs itself, and
+// Anthropic fetches image URLs with AnthropicImageModeBase64. Downloads are
+// also bounded by WithDownloadBudget.
+func WithDownloadTimeout(timeout time.Duration) Option {
+	return func(c *Config) { c.downloadTimeout = timeout }
+}
+
+// WithDownloadBudget sets the fraction, between 0 and 1, of the time left
+// before the request context's deadline that the media downloads of a request
+// may use together, so a slow download cannot leave the generation call
+// without time. The default is 0.5. A download cut off by its budget or by
+// WithDownloadTimeout fails with a *TimeoutError of kind TimeoutKindDownload.
+func WithDownloadBudget(fraction float64) Option {
+	return func(c *Config) { c.downloadBudget = fraction }
+}
+
+// WithImageMaxDimension downscales images whose width or height exceeds px
+// pixels to fit within px, preserving the aspect ratio, before they are sent.
+// It applies to base64 and reader images, and to image URLs that are
+// downloaded rather than passed to the provider (Gemini, and Anthropic with
+// AnthropicImageModeBase64). Resized JPEGs stay JPEG; other formats become
+// PNG. Images within the bound, and formats that cannot be decoded, are sent
+// unchanged. The default of 0 sends images as given.
+func WithImageMaxDimension(px int) Option {
+	return func(c *Config) { c.imageMaxDimension = px }
+}
+
+// WithMediaFetcher replaces the plain HTTP GET used to download media URLs
+// while building a request (Gemini fetches image, audio, video and document
+// URLs itself, and Anthropic image URLs with AnthropicImageModeBase64), e.g. to
+// go through a proxy or authenticate to private storage. fetch returns the
+// media bytes and their MIME type, which may be empty to keep the type given
+// in the request or detected from the URL. The context carries the limits of
+// WithDownloadTimeout and WithDownloadBudget.
+func WithMediaFetcher(fetch func(ctx context.Context, url string) (data []byte, mimeType string, err error)) Option {
+	return func(c *Config) { c.mediaFetcher = fetch }
+}
+
+// WithStreamIdleTimeout fails a stream's Recv with a *TimeoutError of kind
+// TimeoutKindIdle when the provider sends nothing for the given duration,
+// catching stalled streams without capping their total length.
+func WithStreamIdleTimeout(timeout time.Duration) Option {
+	return func(c *Config) { c.streamIdleTimeout = timeout }
+}
+
+// WithStreamTimeout bounds the total duration of a stream, from sending the
+// request to reading its last event. Streams legitimately run longer than
+// unary calls, so they do not use WithTimeout. The default is 10 minutes; use
+// WithStreamIdleTimeout to catch streams that stall.
+func WithStreamTimeout(timeout time.Duration) Option {
+	return func(c *Config) { c.streamTimeout = timeout }
+}
+
+// WithAutoContinue makes Generate automatically continue responses cut off by the
+// output token limit (FinishReason "length"). The partial reply is sent back as an
+// assistant message followed by a user turn asking the model to continue, up to
+// maxRounds extra calls, and the text of all rounds is concatenated.
+// Continuation stops at the first response with any other finish reason.
+func WithAutoContinue(maxRounds int) Option {
+	return func(c *Config) { c.autoContinueRounds = maxRounds }
+}
+
+// WithMessageMerging makes Anthropic requests merge consecutive messages with
+// the same role into one message holding all their content blocks. Anthropic
+// requires alternating user and assistant turns and rejects two user messages
+// in a row with a 400. Other providers accept such messages and are unaffected.
+func WithMessageMerging(merge bool) Option {
+	return func(c *Config) { c.mergeMessages = merge }
+}
+
+// WithBackoff sets the delay before the first retry of a 5xx response or a
+// transient network error, and the cap on any retry delay. Delays double per
+// attempt and include random jitter so many clients do not retry in lockstep.
+// The default is 1s, capped at 30s.
+func WithBackoff(base, maxDelay time.Duration) Option {
+	return func(c *Config) {
+		c.retryBaseDelay = base
+		c.retryMaxDelay = maxDelay
+	}
+}
+
+// WithRetryBudget caps the total time a request spends on attempts and retry
+// delays. Once another retry would not fit in the budget, the last error is
+// returned without waiting. An attempt already in flight is not cut short; use
+// WithTimeout or a context deadline for that. The default is no budget.
+func WithRetryBudget(total time.Duration) Option {
+	return func(c *Config) { c.retryBudget = total }
+}
+
+// WithAnthropicImageMode sets how image URLs are sent to Anthropic. The
+// default, AnthropicImageModeURL, passes them for Anthropic to fetch;
+// AnthropicImageModeBase64 downloads them first, for models and accounts that
+// reject URL image sources. Downloads honor WithDownloadTimeout,
+// WithDownloadBudget, WithMediaFetcher and WithImageMaxDimension.
+func WithAnthropicImageMode(mode AnthropicImageMode) Option {
+	return func(c *Config) { c.anthropicImageMode = mode }
+}
+
+// WithParameterCompatibility sets how request parameters the provider or model
+// does not support, such as Temperature on OpenAI reasoning models, are handled.
+// The default, ParameterCompatibilityDrop, omits them from the request.
+func WithParameterCompatibility(mode ParameterCompatibility) Option {
+	return func(c *Config) { c.paramCompatibility = mode }
+}
+
+// WithDroppedParameterHandler registers fn to be called for each parameter
+// omitted under ParameterCompatibilityDrop, e.g. to log a warning.
+func WithDroppedParameterHandler(fn func(provider Provider, model, param string)) Option {
+	return func(c *Config) { c.onDroppedParameter = fn }
+}
+
+// WithResponseMiddleware adds fn to the functions run on every response before
+// it is returned, e.g. to redact PII or append a disclaimer. fn may modify the
+// response in place; an error fails the call. Middlewares run in the order
+// they were added. For streams they run on the final Done chunk's Snapshot,
+// after the deltas have been delivered unmodified.
+func WithResponseMiddleware(fn func(*Response) error) Option {
+	return func(c *Config) { c.responseMiddleware = append(c.responseMiddleware, fn) }
+}
+
+// WithToolArgumentRepair enables a best-effort repair of malformed JSON in tool
+// call arguments (trailing commas, raw newlines in strings, unclosed brackets),
+// both in parsed responses and in tool calls sent back to the provider.
+// Valid arguments are never changed.
+func WithToolArgumentRepair(enabled bool) Option {
+	return func(c *Config) { c.repairToolArguments = enabled }
+}
+
+// WithRawResponse keeps the provider's response body in Response.Raw, for
+// debugging or reading fields this package does not parse. It is off by
+// default so the body is not retained. Streamed responses are not kept.
+func WithRawResponse(enabled bool) Option {
+	return func(c *Config) { c.rawResponse = enabled }
+}
+
+// WithRequestCoalescing makes concurrent Generate calls with identical requests
+// (as determined by HashRequest) share a single provider call. The shared call
+// keeps the values of the first caller's context but not its cancellation or
+// deadline, so it is bounded by WithTimeout; each caller stops waiting when its
+// own context is done, and the call is canceled once no caller is waiting.
+// Streaming requests are never coalesced.
+func WithRequestCoalescing() Option {
+	return func(c *Config) { c.coalesceRequests = true }
+}
+
+// WithStrictValidation enables additional request checks before anything is sent.
+// Currently this verifies with Request.ValidateToolResults that every tool call
+// has a matching tool result.
+func WithStrictValidation() Option {
+	return func(c *Config) { c.strictValidation = true }
+}
+
+// WithTokenizer sets the Tokenizer used to estimate request size for the
+// context window pre-check, and enables the check for models whose
+// ContextWindow is known (see WithModelInfo). Without it, WhitespaceTokenizer
+// is used and only WithContextWindow enables the check.
+func WithTokenizer(t Tokenizer) Option {
+	return func(c *Config) { c.tokenizer = t }
+}
+
+// WithContextWindow sets the model's context window in tokens, enabling a
+// local pre-check: requests whose estimated size exceeds it fail with
+// *ContextTooLongError without a network round-trip. The estimate covers the
+// request after the client's defaults, such as WithDefaultSystemPrompt, are
+// applied. It overrides the ContextWindow of a model registered with
+// WithModelInfo.
+func WithContextWindow(tokens int) Option {
+	return func(c *Config) { c.contextWindow = tokens }
+}
+
+// WithRecorder writes every provider call the client makes to w as one line of
+// JSON holding the request, the response status and the raw response body, for
+// replay with NewReplayClient. Headers, including the API key, are not
+// recorded. A call is written once its response body has been read; for
+// streams, once the stream is closed. Failing to write fails the call.
+func WithRecorder(w io.Writer) Option {
+	return func(c *Config) { c.recorder = w }
+}
+
+// newConfig returns the default configuration with opts applied.
+func newConfig(opts []Option) *Config {
+	cfg := &Config{
+		timeout:             30 * time.Second,
+		maxIdleConns:        defaultMaxIdleConns,
+		maxIdleConnsPerHost: defaultMaxIdleConnsPerHost,
+		idleConnTimeout:     defaultIdleConnTimeout,
+		maxResponseSize:     maxResponseSize,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
 // validateConfig validates the client configuration and returns an error if invalid.
 func validateConfig(cfg *Config) error {
 	// Validate provider
@@ -344,10 +1138,10 @@ func validateConfig(cfg *Config) error {
 
 	// Validate provider is supported
 	switch cfg.provider {
-	case ProviderOpenAI, ProviderGemini, ProviderAnthropic:
+	case ProviderOpenAI, ProviderGemini, ProviderAnthropic, ProviderMistral:
 		// Valid provider
 	default:
-		return fmt.Errorf("unsupported provider: %q (supported: openai, gemini, anthropic)", cfg.provider)
+		return fmt.Errorf("unsupported provider: %q (supported: openai, gemini, anthropic, mistral)", cfg.provider)
 	}
 
 	// Validate API key
@@ -363,6 +1157,106 @@ func validateConfig(cfg *Config) error {
 		return fmt.Errorf("timeout must be positive, got %v", cfg.timeout)
 	}
 
+	// Validate connection pool settings
+	if cfg.maxIdleConns <= 0 {
+		return fmt.Errorf("max idle connections must be positive, got %d", cfg.maxIdleConns)
+	}
+	if cfg.maxIdleConnsPerHost <= 0 {
+		return fmt.Errorf("max idle connections per host must be positive, got %d", cfg.maxIdleConnsPerHost)
+	}
+	if cfg.idleConnTimeout <= 0 {
+		return fmt.Errorf("idle connection timeout must be positive, got %v", cfg.idleConnTimeout)
+	}
+
+	// Validate context window
+	if cfg.contextWindow < 0 {
+		return fmt.Errorf("context window cannot be negative, got %d", cfg.contextWindow)
+	}
+
+	// Validate auto-continue rounds
+	if cfg.autoContinueRounds < 0 {
+		return fmt.Errorf("auto-continue rounds cannot be negative, got %d", cfg.autoContinueRounds)
+	}
+
+	// Validate retry backoff
+	if cfg.retryBaseDelay != 0 || cfg.retryMaxDelay != 0 {
+		if cfg.retryBaseDelay <= 0 {
+			return fmt.Errorf("backoff base delay must be positive, got %v", cfg.retryBaseDelay)
+		}
+		if cfg.retryMaxDelay < cfg.retryBaseDelay {
+			return fmt.Errorf("backoff max delay (%v) cannot be less than base delay (%v)", cfg.retryMaxDelay, cfg.retryBaseDelay)
+		}
+	}
+
+	// Validate retry budget
+	if cfg.retryBudget < 0 {
+		return fmt.Errorf("retry budget cannot be negative, got %v", cfg.retryBudget)
+	}
+
+	// Validate request defaults
+	if d := cfg.defaults; d.Temperature != nil && *d.Temperature < 0 {
+		return fmt.Errorf("default temperature cannot be negative, got %g", *d.Temperature)
+	}
+	if cfg.defaults.MaxTokens < 0 {
+		return fmt.Errorf("default max tokens cannot be negative, got %d", cfg.defaults.MaxTokens)
+	}
+
+	// Validate response middleware
+	for _, fn := range cfg.responseMiddleware {
+		if fn == nil {
+			return fmt.Errorf("response middleware cannot be nil")
+		}
+	}
+
+	// Validate parameter compatibility mode
+	switch cfg.paramCompatibility {
+	case "", ParameterCompatibilityDrop, ParameterCompatibilityStrict:
+	default:
+		return fmt.Errorf("unknown parameter compatibility mode %q (must be drop or strict)", cfg.paramCompatibility)
+	}
+	switch cfg.anthropicImageMode {
+	case "", AnthropicImageModeURL, AnthropicImageModeBase64:
+	default:
+		return fmt.Errorf("unknown anthropic image mode %q (must be url or base64)", cfg.anthropicImageMode)
+	}
+
+	// Validate download timeout
+	if cfg.downloadTimeout < 0 {
+		return fmt.Errorf("download timeout cannot be negative, got %v", cfg.downloadTimeout)
+	}
+	if cfg.downloadBudget < 0 || cfg.downloadBudget >= 1 {
+		return fmt.Errorf("download budget must be between 0 and 1, got %v", cfg.downloadBudget)
+	}
+	if cfg.imageMaxDimension < 0 {
+		return fmt.Errorf("image max dimension cannot be negative, got %d", cfg.imageMaxDimension)
+	}
+
+	// Validate stream timeout
+	if cfg.streamTimeout < 0 {
+		return fmt.Errorf("stream timeout cannot be negative, got %v", cfg.streamTimeout)
+	}
+
+	// Validate transport timeouts
+	if cfg.dialTimeout < 0 {
+		return fmt.Errorf("dial timeout cannot be negative, got %v", cfg.dialTimeout)
+	}
+	if cfg.tlsTimeout < 0 {
+		return fmt.Errorf("TLS handshake timeout cannot be negative, got %v", cfg.tlsTimeout)
+	}
+	if cfg.headerTimeout < 0 {
+		return fmt.Errorf("response header timeout cannot be negative, got %v", cfg.headerTimeout)
+	}
+
+	// Validate stream idle timeout
+	if cfg.streamIdleTimeout < 0 {
+		return fmt.Errorf("stream idle timeout cannot be negative, got %v", cfg.streamIdleTimeout)
+	}
+
+	// Validate response size limit
+	if cfg.maxResponseSize <= 0 {
+		return fmt.Errorf("max response size must be positive, got %d", cfg.maxResponseSize)
+	}
+
 	// Validate baseURL if provided
 	if cfg.baseURL != "" {
 		if strings.TrimSpace(cfg.baseURL) == "" {
@@ -383,20 +1277,40 @@ func validateConfig(cfg *Config) error {
 		}
 	}
 
+	// Validate proxy URL if provided
+	if cfg.proxyURL != "" {
+		parsedURL, err := url.Parse(cfg.proxyURL)
+		if err != nil {
+			return fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		switch parsedURL.Scheme {
+		case "http", "https", "socks5":
+		case "":
+			return fmt.Errorf("proxy URL must include scheme (http://, https:// or socks5://), got: %q", cfg.proxyURL)
+		default:
+			return fmt.Errorf("proxy URL scheme must be http, https or socks5, got: %q", parsedURL.Scheme)
+		}
+		if parsedURL.Host == "" {
+			return fmt.Errorf("proxy URL must include host, got: %q", cfg.proxyURL)
+		}
+	}
+
 	// Validate model if provided
 	if cfg.model != "" && strings.TrimSpace(cfg.model) == "" {
 		return fmt.Errorf("model cannot be empty or whitespace only")
 	}
 
+	// Validate user agent if provided
+	if cfg.userAgent != "" && strings.TrimSpace(cfg.userAgent) == "" {
+		return fmt.Errorf("user agent cannot be empty or whitespace only")
+	}
+
 	return nil
 }
 
 // NewClient is the single, unified factory function to create an AI client.
 func NewClient(opts ...Option) (Client, error) {
-	cfg := &Config{timeout: 30 * time.Second}
-	for _, opt := range opts {
-		opt(cfg)
-	}
+	cfg := newConfig(opts)
 
 	// Validate configuration
 	if err := validateConfig(cfg); err != nil {
@@ -410,6 +1324,8 @@ func NewClient(opts ...Option) (Client, error) {
 		return newGeminiClient(cfg), nil
 	case ProviderAnthropic:
 		return newAnthropicClient(cfg), nil
+	case ProviderMistral:
+		return newMistralClient(cfg), nil
 	default:
 		// This should never happen due to validateConfig, but keep for safety
 		return nil, fmt.Errorf("unknown provider: %q", cfg.provider)
@@ -428,34 +1344,61 @@ var providerEnvs = map[Provider]providerEnvConfig{
 	ProviderOpenAI:    {"OPENAI_API_KEY", "OPENAI_MODEL", "OPENAI_BASE_URL"},
 	ProviderGemini:    {"GEMINI_API_KEY", "GEMINI_MODEL", "GEMINI_BASE_URL"},
 	ProviderAnthropic: {"ANTHROPIC_API_KEY", "ANTHROPIC_MODEL", "ANTHROPIC_BASE_URL"},
+	ProviderMistral:   {"MISTRAL_API_KEY", "MISTRAL_MODEL", "MISTRAL_BASE_URL"},
 }
 
+// envProviderOrder lists the providers in the order they are described in error messages.
+var envProviderOrder = []Provider{ProviderOpenAI, ProviderGemini, ProviderAnthropic, ProviderMistral}
+
 // NewClientFromEnv creates a new AI client by reading configuration from
 // environment variables. It provides a convenient way to initialize the client
 // without manual configuration.
 //
 // It uses the following environment variables:
-//   - AI_PROVIDER: "openai" or "gemini" (defaults to "openai").
+//   - AI_PROVIDER: "openai", "gemini", "anthropic" or "mistral" (defaults to "openai").
 //   - OPENAI_API_KEY, OPENAI_MODEL, OPENAI_BASE_URL
 //   - GEMINI_API_KEY, GEMINI_MODEL, GEMINI_BASE_URL
+//   - ANTHROPIC_API_KEY, ANTHROPIC_MODEL, ANTHROPIC_BASE_URL
+//   - MISTRAL_API_KEY, MISTRAL_MODEL, MISTRAL_BASE_URL
+//   - AI_MODEL: model used when the provider-specific *_MODEL is unset.
+//
+// The model is resolved in order: the provider's *_MODEL, then AI_MODEL, then
+// the provider's built-in default. When AI_PROVIDER is unset, OPENAI_API_KEY
+// must be set for the default provider; otherwise the error lists the
+// variables to set.
 func NewClientFromEnv() (Client, error) {
 	providerStr := os.Getenv("AI_PROVIDER")
-	if providerStr == "" {
+	explicit := providerStr != ""
+	if !explicit {
 		providerStr = "openai" // Default to openai
 	}
 	provider := Provider(strings.ToLower(providerStr))
 
 	env, ok := providerEnvs[provider]
 	if !ok {
-		return nil, fmt.Errorf("unsupported AI_PROVIDER: %s", provider)
+		return nil, fmt.Errorf("unsupported AI_PROVIDER: %s (supported: openai, gemini, anthropic, mistral)", provider)
 	}
 
 	apiKey := os.Getenv(env.apiKey)
 	if apiKey == "" {
-		return nil, fmt.Errorf("API key for provider '%s' is not set in env var %s", provider, env.apiKey)
+		if !explicit {
+			var alternatives []string
+			for _, p := range envProviderOrder {
+				if p != provider {
+					alternatives = append(alternatives, fmt.Sprintf("AI_PROVIDER=%s with %s", p, providerEnvs[p].apiKey))
+				}
+			}
+			return nil, fmt.Errorf("AI_PROVIDER is not set and the default provider '%s' has no API key: set %s, or set %s",
+				provider, env.apiKey, strings.Join(alternatives, " or "))
+		}
+		return nil, fmt.Errorf("API key for provider '%s' is not set in env var %s (optional: %s or AI_MODEL, %s)",
+			provider, env.apiKey, env.model, env.baseURL)
 	}
 
 	model := os.Getenv(env.model)
+	if model == "" {
+		model = os.Getenv("AI_MODEL")
+	}
 	baseURL := os.Getenv(env.baseURL)
 
 	var opts []Option
@@ -484,6 +1427,25 @@ func NewTextMessage(role Role, text string) Message {
 	}
 }
 
+// Turn is a single text entry of a stored chat transcript.
+type Turn struct {
+	Role Role
+	Text string
+}
+
+// RequestFromTranscript builds a Request from a system prompt and a plain
+// text transcript, creating one text message per turn.
+func RequestFromTranscript(system string, turns []Turn) *Request {
+	req := &Request{
+		SystemPrompt: system,
+		Messages:     make([]Message, len(turns)),
+	}
+	for i, turn := range turns {
+		req.Messages[i] = NewTextMessage(turn.Role, turn.Text)
+	}
+	return req
+}
+
 // NewMultimodalMessage creates a message with multimodal content parts.
 func NewMultimodalMessage(role Role, parts []ContentPart) Message {
 	return Message{
@@ -526,8 +1488,22 @@ func NewImagePartFromBase64(data, format string) ContentPart {
 	}
 }
 
+// NewImagePartFromReader creates an image content part whose raw bytes are read
+// from r and base64-encoded only when the request is sent. The reader is consumed
+// once, so the part cannot be reused across requests.
+func NewImagePartFromReader(r io.Reader, format string) ContentPart {
+	return ContentPart{
+		Type: ContentTypeImage,
+		ImageSource: &ImageSource{
+			Type:   ImageSourceTypeReader,
+			Reader: r,
+			Format: format,
+		},
+	}
+}
+
 // NewAudioPartFromURL creates an audio content part from a URL.
-// Supported formats: mp3, wav, aiff, aac, ogg, flac
+// Supported formats: mp3, wav, aiff, aac, ogg, flac, m4a
 // Primarily supported by Gemini models.
 func NewAudioPartFromURL(url, format string) ContentPart {
 	return ContentPart{
@@ -3,10 +3,12 @@ package ai_test
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strings"
 	"testing"
 
@@ -431,3 +433,248 @@ func TestAnthropicImplementation(t *testing.T) {
 		}
 	})
 }
+
+// TestResponseMerge tests combining partial responses.
+func TestResponseMerge(t *testing.T) {
+	partial := &ai.Response{
+		Text: "The weather in Paris ",
+		ToolCalls: []ai.ToolCall{
+			{ID: "call_1", Type: "function", Function: "get_weather", Arguments: `{"city":`},
+		},
+	}
+	final := &ai.Response{
+		Text: "is sunny.",
+		ToolCalls: []ai.ToolCall{
+			{ID: "call_1", Type: "function", Function: "get_weather", Arguments: `{"city":"Paris"}`},
+			{ID: "call_2", Type: "function", Function: "get_time", Arguments: `{"tz":"CET"}`},
+		},
+	}
+
+	merged := partial.Merge(final)
+	if merged.Text != "The weather in Paris is sunny." {
+		t.Errorf("Expected concatenated text, got %q", merged.Text)
+	}
+	if len(merged.ToolCalls) != 2 {
+		t.Fatalf("Expected 2 tool calls without duplicates, got %d: %+v", len(merged.ToolCalls), merged.ToolCalls)
+	}
+	if merged.ToolCalls[0].ID != "call_1" || merged.ToolCalls[0].Arguments != `{"city":"Paris"}` {
+		t.Errorf("Expected call_1 to take the later, complete arguments, got %+v", merged.ToolCalls[0])
+	}
+	if merged.ToolCalls[1].ID != "call_2" {
+		t.Errorf("Expected call_2 second, got %+v", merged.ToolCalls[1])
+	}
+
+	// Inputs are left untouched.
+	if partial.Text != "The weather in Paris " || partial.ToolCalls[0].Arguments != `{"city":` || len(partial.ToolCalls) != 1 {
+		t.Errorf("Expected Merge not to modify its receiver, got %+v", partial)
+	}
+
+	// Nil on either side yields a copy of the other.
+	if got := (*ai.Response)(nil).Merge(final); got == final || len(got.ToolCalls) != 2 || got.Text != final.Text {
+		t.Errorf("Expected a copy of the argument when merging into nil, got %+v", got)
+	}
+	if got := partial.Merge(nil); got == partial || got.Text != partial.Text {
+		t.Errorf("Expected a copy of the receiver when merging nil, got %+v", got)
+	}
+}
+
+// TestResponseWarnings verifies that non-fatal issues are surfaced on
+// Response.Warnings rather than as errors.
+func TestResponseWarnings(t *testing.T) {
+	t.Run("truncated by max_tokens", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"Once upon a"},"finish_reason":"length"}]}`)
+		}))
+		defer server.Close()
+
+		client, err := ai.NewClient(ai.WithProvider(ai.ProviderOpenAI), ai.WithAPIKey("test-key"), ai.WithBaseURL(server.URL))
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		resp, err := client.Generate(context.Background(), &ai.Request{
+			Messages: []ai.Message{{Role: ai.RoleUser, Content: "Tell me a story."}},
+		})
+		if err != nil {
+			t.Fatalf("Generate failed: %v", err)
+		}
+		if resp.FinishReason != ai.FinishReasonLength {
+			t.Errorf("Expected finish reason %q, got %q", ai.FinishReasonLength, resp.FinishReason)
+		}
+		if !slices.Contains(resp.Warnings, "response truncated by max_tokens") {
+			t.Errorf("Expected a truncation warning, got %q", resp.Warnings)
+		}
+	})
+
+	t.Run("gemini blocked prompt", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"promptFeedback":{"blockReason":"SAFETY"}}`)
+		}))
+		defer server.Close()
+
+		client, err := ai.NewClient(ai.WithProvider(ai.ProviderGemini), ai.WithAPIKey("test-key"), ai.WithBaseURL(server.URL))
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		_, err = client.Generate(context.Background(), &ai.Request{
+			Messages: []ai.Message{{Role: ai.RoleUser, Content: "Hello"}},
+		})
+		var filterErr *ai.ContentFilterError
+		if !errors.As(err, &filterErr) {
+			t.Fatalf("Expected a ContentFilterError, got %v", err)
+		}
+		if filterErr.Reason != "SAFETY" || filterErr.Provider() != "gemini" {
+			t.Errorf("Expected a SAFETY block from gemini, got %q from %q", filterErr.Reason, filterErr.Provider())
+		}
+	})
+
+	t.Run("gemini empty candidates", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"candidates":[]}`)
+		}))
+		defer server.Close()
+
+		client, err := ai.NewClient(ai.WithProvider(ai.ProviderGemini), ai.WithAPIKey("test-key"), ai.WithBaseURL(server.URL))
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		_, err = client.Generate(context.Background(), &ai.Request{
+			Messages: []ai.Message{{Role: ai.RoleUser, Content: "Hello"}},
+		})
+		if err == nil || !strings.Contains(err.Error(), "no candidates") {
+			t.Errorf("Expected a no candidates error, got %v", err)
+		}
+	})
+}
+
+func TestRequestClone(t *testing.T) {
+	temp := 0.5
+	req := &ai.Request{
+		Messages: []ai.Message{{
+			Role:         ai.RoleUser,
+			ContentParts: []ai.ContentPart{ai.NewImagePartFromURL("https://example.com/cat.png")},
+			ToolCalls:    []ai.ToolCall{{ID: "call_1", Arguments: "{}"}},
+		}},
+		Tools:         []ai.Tool{{Type: "function", Function: ai.FunctionDefinition{Name: "f", Parameters: json.RawMessage(`{}`)}}},
+		StopSequences: []string{"END"},
+		Temperature:   &temp,
+		LogitBias:     map[string]int{"1": 2},
+		Thinking:      &ai.ThinkingConfig{BudgetTokens: 1024},
+	}
+	clone := req.Clone()
+
+	clone.Messages[0].ContentParts[0].ImageSource.URL = "changed"
+	clone.Messages[0].ToolCalls[0].Arguments = "changed"
+	clone.Tools[0].Function.Parameters[0] = '['
+	clone.StopSequences[0] = "changed"
+	*clone.Temperature = 1
+	clone.LogitBias["1"] = 0
+	clone.Thinking.BudgetTokens = 0
+
+	if req.Messages[0].ContentParts[0].ImageSource.URL != "https://example.com/cat.png" ||
+		req.Messages[0].ToolCalls[0].Arguments != "{}" ||
+		string(req.Tools[0].Function.Parameters) != "{}" ||
+		req.StopSequences[0] != "END" ||
+		*req.Temperature != 0.5 ||
+		req.LogitBias["1"] != 2 ||
+		req.Thinking.BudgetTokens != 1024 {
+		t.Errorf("modifying the clone changed the original: %+v", req)
+	}
+}
+
+// TestContentPartLiterals tests that every media part can be built from the
+// exported types alone, without the New*Part helpers
+func TestContentPartLiterals(t *testing.T) {
+	var parts []any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Contents []struct {
+				Parts []any `json:"parts"`
+			} `json:"contents"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		parts = body.Contents[0].Parts
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"candidates":[{"content":{"role":"model","parts":[{"text":"ok"}]},"finishReason":"STOP"}]}`)
+	}))
+	defer server.Close()
+
+	client, err := ai.NewClient(ai.WithProvider(ai.ProviderGemini), ai.WithAPIKey("test-key"), ai.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	_, err = client.Generate(context.Background(), &ai.Request{Messages: []ai.Message{{
+		Role: ai.RoleUser,
+		ContentParts: []ai.ContentPart{
+			{Type: ai.ContentTypeText, Text: "Describe these."},
+			{Type: ai.ContentTypeImage, ImageSource: &ai.ImageSource{Type: ai.ImageSourceTypeBase64, Data: "aW1hZ2U=", Format: "png"}},
+			{Type: ai.ContentTypeAudio, AudioSource: &ai.AudioSource{Type: ai.MediaSourceTypeBase64, Data: "YXVkaW8=", Format: "mp3"}},
+			{Type: ai.ContentTypeVideo, VideoSource: &ai.VideoSource{Type: ai.MediaSourceTypeBase64, Data: "dmlkZW8=", Format: "mp4"}},
+			{Type: ai.ContentTypeDocument, DocumentSource: &ai.DocumentSource{Type: ai.MediaSourceTypeBase64, Data: "cGRm", MimeType: "application/pdf"}},
+		},
+	}}})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	want := []struct{ mimeType, data string }{
+		{"image/png", "aW1hZ2U="},
+		{"audio/mpeg", "YXVkaW8="},
+		{"video/mp4", "dmlkZW8="},
+		{"application/pdf", "cGRm"},
+	}
+	if len(parts) != len(want)+1 {
+		t.Fatalf("Expected %d parts, got %d: %v", len(want)+1, len(parts), parts)
+	}
+	for i, w := range want {
+		inline, _ := parts[i+1].(map[string]any)["inlineData"].(map[string]any)
+		if inline["mimeType"] != w.mimeType || inline["data"] != w.data {
+			t.Errorf("part %d: expected inline %s data %q, got %v", i+1, w.mimeType, w.data, parts[i+1])
+		}
+	}
+}
+
+// TestClientClose tests that closing a client is idempotent and leaves it usable
+func TestClientClose(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`)
+	}))
+	defer server.Close()
+
+	client, err := ai.NewClient(ai.WithProvider(ai.ProviderOpenAI), ai.WithAPIKey("test-key"), ai.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if _, ok := client.(io.Closer); !ok {
+		t.Fatal("Expected the client to implement io.Closer")
+	}
+	req := &ai.Request{Messages: []ai.Message{{Role: ai.RoleUser, Content: "hi"}}}
+	if _, err := client.Generate(context.Background(), req); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	for range 2 {
+		if err := ai.CloseClient(client); err != nil {
+			t.Fatalf("CloseClient failed: %v", err)
+		}
+	}
+	if _, err := client.Generate(context.Background(), req); err != nil {
+		t.Errorf("Expected the client to stay usable after Close, got %v", err)
+	}
+
+	breaker := ai.NewCircuitBreakerClient(client, ai.CircuitBreakerOptions{})
+	if err := ai.CloseClient(breaker); err != nil {
+		t.Errorf("Expected the circuit breaker to close its inner client, got %v", err)
+	}
+	if err := ai.CloseClient(nonCloser{}); err != nil {
+		t.Errorf("Expected CloseClient to ignore clients without Close, got %v", err)
+	}
+}
+
+type nonCloser struct{}
+
+func (nonCloser) Generate(context.Context, *ai.Request) (*ai.Response, error) {
+	return &ai.Response{}, nil
+}
@@ -2,14 +2,95 @@ package ai
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"strings"
+	"time"
+)
+
+// anthropicDefaultMaxTokens is sent as max_tokens, which Anthropic requires,
+// when neither the request nor the model's known output limit sets one.
+const anthropicDefaultMaxTokens = 4096
+
+// AnthropicImageMode selects how image URLs are sent to Anthropic.
+type AnthropicImageMode string
+
+const (
+	// AnthropicImageModeURL sends image URLs as URL sources, fetched by Anthropic.
+	AnthropicImageModeURL AnthropicImageMode = "url"
+	// AnthropicImageModeBase64 downloads image URLs and sends them as base64 sources.
+	AnthropicImageModeBase64 AnthropicImageMode = "base64"
 )
 
 // anthropicAdapter implements the providerAdapter interface for Anthropic.
-type anthropicAdapter struct{}
+type anthropicAdapter struct {
+	params paramPolicy
+	// mergeMessages joins consecutive same-role messages; see WithMessageMerging.
+	mergeMessages bool
+	// imageMode, with the download settings below, is set by WithAnthropicImageMode.
+	imageMode       AnthropicImageMode
+	downloadTimeout time.Duration
+	downloadBudget  float64
+	fetchMedia      func(ctx context.Context, url string) ([]byte, string, error)
+	imageMaxDim     int
+}
+
+// downloadImage fetches an image URL for AnthropicImageModeBase64 and returns
+// it base64-encoded with its MIME type, downscaled per WithImageMaxDimension.
+// budget is the context shared by the request's downloads and parent the
+// caller's; a download cut off by its timeout or budget rather than the caller
+// fails with a *TimeoutError of kind TimeoutKindDownload.
+func (a *anthropicAdapter) downloadImage(parent, budget context.Context, url string) (string, string, error) {
+	ctx, cancel := downloadContext(budget, a.downloadTimeout)
+	defer cancel()
+	start := time.Now()
+
+	data, mimeType, err := a.fetchImage(ctx, url)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded && parent.Err() == nil {
+			err = newTimeoutError(string(ProviderAnthropic), TimeoutKindDownload, time.Since(start).Round(time.Millisecond), err)
+		}
+		return "", "", err
+	}
+	if a.imageMaxDim > 0 {
+		resized, format, err := resizeBase64Image(data, a.imageMaxDim)
+		if err != nil {
+			return "", "", err
+		}
+		if format != "" {
+			data, mimeType = resized, imageMIMEType(format)
+		}
+	}
+	return data, mimeType, nil
+}
+
+// fetchImage downloads url with the media fetcher, or else a plain HTTP GET.
+func (a *anthropicAdapter) fetchImage(ctx context.Context, url string) (string, string, error) {
+	var data, mimeType string
+	if a.fetchMedia != nil {
+		raw, fetchedType, err := a.fetchMedia(ctx, url)
+		if err != nil {
+			return "", "", err
+		}
+		if len(raw) > maxImageSize {
+			return "", "", fmt.Errorf("image exceeds maximum size of %d bytes", maxImageSize)
+		}
+		data, mimeType = base64.StdEncoding.EncodeToString(raw), fetchedType
+	} else {
+		var format string
+		var err error
+		if data, format, err = downloadImageToBase64(ctx, url); err != nil {
+			return "", "", err
+		}
+		mimeType = imageMIMEType(format)
+	}
+	if mimeType == "" {
+		mimeType = imageMIMEType(detectImageFormat("", url))
+	}
+	return data, mimeType, nil
+}
 
 func (a *anthropicAdapter) getModel(req *Request) string {
 	if req.Model == "" {
@@ -24,12 +105,59 @@ func (a *anthropicAdapter) getEndpoint(model string) string {
 }
 
 func (a *anthropicAdapter) buildRequestPayload(ctx context.Context, req *Request) (any, error) {
+	req = req.hoistSystemMessage()
 	anthropicReq := &anthropicMessagesRequest{
-		Model:     a.getModel(req),
-		System:    req.SystemPrompt,
-		Messages:  make([]anthropicMessage, 0, len(req.Messages)),
-		MaxTokens: 4096, // A required parameter for Anthropic.
+		Model:         a.getModel(req),
+		System:        req.SystemPrompt,
+		Messages:      make([]anthropicMessage, 0, len(req.Messages)),
+		MaxTokens:     anthropicDefaultMaxTokens, // A required parameter for Anthropic.
+		StopSequences: req.StopSequences,
+		Temperature:   req.Temperature,
+		TopP:          req.TopP,
+		TopK:          req.TopK,
 	}
+	if req.MaxTokens > 0 {
+		anthropicReq.MaxTokens = req.MaxTokens
+	}
+	if req.Thinking != nil {
+		anthropicReq.Thinking = &anthropicThinking{Type: "enabled", BudgetTokens: req.Thinking.BudgetTokens}
+		// max_tokens includes the thinking budget and must exceed it.
+		if anthropicReq.MaxTokens <= req.Thinking.BudgetTokens {
+			anthropicReq.MaxTokens = req.Thinking.BudgetTokens + anthropicDefaultMaxTokens
+		}
+		// Thinking is incompatible with a modified temperature or top_k.
+		if req.Temperature != nil {
+			if err := a.params.unsupported(ProviderAnthropic, anthropicReq.Model, "Temperature"); err != nil {
+				return nil, err
+			}
+			anthropicReq.Temperature = nil
+		}
+		if req.TopK != nil {
+			if err := a.params.unsupported(ProviderAnthropic, anthropicReq.Model, "TopK"); err != nil {
+				return nil, err
+			}
+			anthropicReq.TopK = nil
+		}
+	}
+	if len(req.LogitBias) > 0 {
+		if err := a.params.unsupported(ProviderAnthropic, anthropicReq.Model, "LogitBias"); err != nil {
+			return nil, err
+		}
+	}
+	if req.ReasoningEffort != "" {
+		if err := a.params.unsupported(ProviderAnthropic, anthropicReq.Model, "ReasoningEffort"); err != nil {
+			return nil, err
+		}
+	}
+	if req.Logprobs {
+		if err := a.params.unsupported(ProviderAnthropic, anthropicReq.Model, "Logprobs"); err != nil {
+			return nil, err
+		}
+	}
+
+	// Downloads of image URLs share the budget of WithDownloadBudget.
+	downloads, cancelDownloads := downloadBudgetContext(ctx, a.downloadBudget)
+	defer cancelDownloads()
 
 	for _, msg := range req.Messages {
 		var role string
@@ -49,6 +177,9 @@ func (a *anthropicAdapter) buildRequestPayload(ctx context.Context, req *Request
 			} else if len(msg.ContentParts) > 0 {
 				// Multimodal content
 				for _, part := range msg.ContentParts {
+					if err := part.checkSource(); err != nil {
+						return nil, err
+					}
 					switch part.Type {
 					case ContentTypeText:
 						contentBlocks = append(contentBlocks, anthropicContentBlock{
@@ -56,75 +187,65 @@ func (a *anthropicAdapter) buildRequestPayload(ctx context.Context, req *Request
 							Text: part.Text,
 						})
 					case ContentTypeImage:
-						if part.ImageSource != nil {
-							// Determine media type
-							mediaType := "image/png" // default
-							if part.ImageSource.Format != "" {
-								mediaType = "image/" + part.ImageSource.Format
-								if part.ImageSource.Format == "jpg" {
-									mediaType = "image/jpeg"
-								}
+						// Determine media type
+						mediaType := "image/png" // default
+						if part.ImageSource.Format != "" {
+							mediaType = "image/" + part.ImageSource.Format
+							if part.ImageSource.Format == "jpg" {
+								mediaType = "image/jpeg"
 							}
+						}
 
-							source := &anthropicImageSource{MediaType: mediaType}
-							switch part.ImageSource.Type {
-							case ImageSourceTypeURL:
-								source.Type = "url"
-								source.URL = part.ImageSource.URL
-							case ImageSourceTypeBase64:
-								source.Type = "base64"
-								data := part.ImageSource.Data
-								// Remove data URI prefix if present
-								if strings.HasPrefix(data, "data:") {
-									if idx := strings.Index(data, ","); idx != -1 {
-										data = data[idx+1:]
-									}
+						source := &anthropicImageSource{MediaType: mediaType}
+						switch part.ImageSource.Type {
+						case ImageSourceTypeURL:
+							if a.imageMode == AnthropicImageModeBase64 {
+								data, downloadedType, err := a.downloadImage(ctx, downloads, part.ImageSource.URL)
+								if err != nil {
+									return nil, fmt.Errorf("failed to download image %s: %w", part.ImageSource.URL, err)
 								}
+								source.Type = "base64"
 								source.Data = data
+								if downloadedType != "" {
+									source.MediaType = downloadedType
+								}
+								break
 							}
-
-							contentBlocks = append(contentBlocks, anthropicContentBlock{
-								Type:   "image",
-								Source: source,
-							})
+							source.Type = "url"
+							source.URL = part.ImageSource.URL
+						case ImageSourceTypeBase64:
+							source.Type = "base64"
+							source.Data = cleanBase64(part.ImageSource.Data)
 						}
-					case ContentTypeDocument:
-						if part.DocumentSource != nil {
-							// Anthropic supports PDF documents
-							mediaType := part.DocumentSource.MimeType
-							if mediaType == "" {
-								mediaType = "application/pdf"
-							}
 
-							source := &anthropicImageSource{MediaType: mediaType}
-							switch part.DocumentSource.Type {
-							case MediaSourceTypeURL:
-								source.Type = "url"
-								source.URL = part.DocumentSource.URL
-							case MediaSourceTypeBase64:
-								source.Type = "base64"
-								data := part.DocumentSource.Data
-								// Remove data URI prefix if present
-								if strings.HasPrefix(data, "data:") {
-									if idx := strings.Index(data, ","); idx != -1 {
-										data = data[idx+1:]
-									}
-								}
-								source.Data = data
-							}
+						contentBlocks = append(contentBlocks, anthropicContentBlock{
+							Type:   "image",
+							Source: source,
+						})
+					case ContentTypeDocument:
+						// Anthropic supports PDF documents
+						mediaType := part.DocumentSource.MimeType
+						if mediaType == "" {
+							mediaType = "application/pdf"
+						}
 
-							// Anthropic uses "document" type for PDFs
-							contentBlocks = append(contentBlocks, anthropicContentBlock{
-								Type:   "document",
-								Source: source,
-							})
+						source := &anthropicImageSource{MediaType: mediaType}
+						switch part.DocumentSource.Type {
+						case MediaSourceTypeURL:
+							source.Type = "url"
+							source.URL = part.DocumentSource.URL
+						case MediaSourceTypeBase64:
+							source.Type = "base64"
+							source.Data = cleanBase64(part.DocumentSource.Data)
 						}
-					case ContentTypeAudio:
-						return nil, fmt.Errorf("anthropic provider does not support audio input (content type: audio). Supported providers: Gemini")
-					case ContentTypeVideo:
-						return nil, fmt.Errorf("anthropic provider does not support video input (content type: video). Supported providers: Gemini")
+
+						// Anthropic uses "document" type for PDFs
+						contentBlocks = append(contentBlocks, anthropicContentBlock{
+							Type:   "document",
+							Source: source,
+						})
 					default:
-						return nil, fmt.Errorf("anthropic provider does not support content type: %s", part.Type)
+						return nil, NewUnsupportedContentError(ProviderAnthropic, part.Type)
 					}
 				}
 			} else if msg.Content != "" {
@@ -137,7 +258,8 @@ func (a *anthropicAdapter) buildRequestPayload(ctx context.Context, req *Request
 			if len(msg.ContentParts) > 0 {
 				// Handle multimodal content (typically just text for assistant)
 				for _, part := range msg.ContentParts {
-					if part.Type == ContentTypeText {
+					// Anthropic rejects empty text blocks, e.g. beside tool calls.
+					if part.Type == ContentTypeText && part.Text != "" {
 						contentBlocks = append(contentBlocks, anthropicContentBlock{
 							Type: "text",
 							Text: part.Text,
@@ -149,8 +271,8 @@ func (a *anthropicAdapter) buildRequestPayload(ctx context.Context, req *Request
 				contentBlocks = append(contentBlocks, anthropicContentBlock{Type: "text", Text: msg.Content})
 			}
 			for _, tc := range msg.ToolCalls {
-				var args map[string]any
-				if err := json.Unmarshal([]byte(tc.Arguments), &args); err != nil {
+				args, err := decodeArguments(tc.Arguments)
+				if err != nil {
 					return nil, fmt.Errorf("failed to unmarshal tool call arguments for anthropic: %w", err)
 				}
 				contentBlocks = append(contentBlocks, anthropicContentBlock{
@@ -166,6 +288,10 @@ func (a *anthropicAdapter) buildRequestPayload(ctx context.Context, req *Request
 		}
 
 		if len(contentBlocks) > 0 {
+			if n := len(anthropicReq.Messages); a.mergeMessages && n > 0 && anthropicReq.Messages[n-1].Role == role {
+				anthropicReq.Messages[n-1].Content = append(anthropicReq.Messages[n-1].Content, contentBlocks...)
+				continue
+			}
 			anthropicReq.Messages = append(anthropicReq.Messages, anthropicMessage{
 				Role:    role,
 				Content: contentBlocks,
@@ -173,6 +299,20 @@ func (a *anthropicAdapter) buildRequestPayload(ctx context.Context, req *Request
 		}
 	}
 
+	// A trailing assistant message prefills the reply. Anthropic rejects a final
+	// assistant turn that ends with whitespace, so trim it from the last text block.
+	if _, ok := req.AssistantPrefill(); ok && len(anthropicReq.Messages) > 0 {
+		last := anthropicReq.Messages[len(anthropicReq.Messages)-1]
+		if last.Role == "assistant" {
+			for i := len(last.Content) - 1; i >= 0; i-- {
+				if last.Content[i].Type == "text" {
+					last.Content[i].Text = strings.TrimRight(last.Content[i].Text, " \t\r\n")
+					break
+				}
+			}
+		}
+	}
+
 	// Translate our universal Tool definition to Anthropic's format.
 	if len(req.Tools) > 0 {
 		anthropicReq.Tools = make([]anthropicTool, len(req.Tools))
@@ -190,16 +330,24 @@ func (a *anthropicAdapter) buildRequestPayload(ctx context.Context, req *Request
 
 func (a *anthropicAdapter) parseResponse(providerResp []byte) (*Response, error) {
 	var anthropicResp anthropicMessagesResponse
-	if err := json.Unmarshal(providerResp, &anthropicResp); err != nil {
+	if err := decodeFirstJSON(providerResp, &anthropicResp); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal anthropic response: %w", err)
 	}
 
-	universalResp := &Response{}
+	universalResp := &Response{FinishReason: anthropicFinishReason(anthropicResp.StopReason)}
+	if u := anthropicResp.Usage; u != nil {
+		universalResp.Usage = &Usage{InputTokens: u.InputTokens, OutputTokens: u.OutputTokens, TotalTokens: u.InputTokens + u.OutputTokens}
+	}
 
 	for _, block := range anthropicResp.Content {
 		switch block.Type {
 		case "text":
 			universalResp.Text += block.Text
+			for _, c := range block.Citations {
+				universalResp.Citations = append(universalResp.Citations, c.toCitation())
+			}
+		case "thinking":
+			universalResp.Reasoning += block.Thinking
 		case "tool_use":
 			args, err := json.Marshal(block.Input)
 			if err != nil {
@@ -218,6 +366,22 @@ func (a *anthropicAdapter) parseResponse(providerResp []byte) (*Response, error)
 	return universalResp, nil
 }
 
+// anthropicFinishReason normalizes an Anthropic stop_reason.
+func anthropicFinishReason(reason string) FinishReason {
+	switch reason {
+	case "end_turn", "stop_sequence":
+		return FinishReasonStop
+	case "max_tokens":
+		return FinishReasonLength
+	case "tool_use":
+		return FinishReasonToolCalls
+	case "refusal":
+		return FinishReasonContentFilter
+	default:
+		return FinishReason(strings.ToLower(reason))
+	}
+}
+
 func (a *anthropicAdapter) enableStreaming(payload any) {
 	if req, ok := payload.(*anthropicMessagesRequest); ok {
 		req.Stream = true
@@ -255,6 +419,8 @@ func (a *anthropicAdapter) parseStreamEvent(event *sseEvent, acc *streamAccumula
 		switch payload.ContentBlock.Type {
 		case "text":
 			acc.anthropicBlocks[payload.Index] = &anthropicBlockState{kind: "text"}
+		case "thinking":
+			acc.anthropicBlocks[payload.Index] = &anthropicBlockState{kind: "thinking"}
 		case "tool_use":
 			acc.anthropicBlocks[payload.Index] = &anthropicBlockState{
 				kind:     "tool",
@@ -267,9 +433,11 @@ func (a *anthropicAdapter) parseStreamEvent(event *sseEvent, acc *streamAccumula
 		var payload struct {
 			Index int `json:"index"`
 			Delta struct {
-				Type        string `json:"type"`
-				Text        string `json:"text,omitempty"`
-				PartialJSON string `json:"partial_json,omitempty"`
+				Type        string             `json:"type"`
+				Text        string             `json:"text,omitempty"`
+				PartialJSON string             `json:"partial_json,omitempty"`
+				Thinking    string             `json:"thinking,omitempty"`
+				Citation    *anthropicCitation `json:"citation,omitempty"`
 			} `json:"delta"`
 		}
 		if err := json.Unmarshal(event.Data, &payload); err != nil {
@@ -283,6 +451,12 @@ func (a *anthropicAdapter) parseStreamEvent(event *sseEvent, acc *streamAccumula
 		if block.kind == "text" && payload.Delta.Text != "" {
 			chunk.TextDelta = payload.Delta.Text
 		}
+		if block.kind == "thinking" && payload.Delta.Type == "thinking_delta" {
+			chunk.ReasoningDelta = payload.Delta.Thinking
+		}
+		if payload.Delta.Type == "citations_delta" && payload.Delta.Citation != nil {
+			chunk.Citations = []Citation{payload.Delta.Citation.toCitation()}
+		}
 		if block.kind == "tool" && (payload.Delta.PartialJSON != "" || payload.Delta.Text != "") {
 			argDelta := payload.Delta.PartialJSON
 			if argDelta == "" {
@@ -295,7 +469,7 @@ func (a *anthropicAdapter) parseStreamEvent(event *sseEvent, acc *streamAccumula
 				ArgumentsDelta: argDelta,
 			})
 		}
-		if chunk.TextDelta == "" && len(chunk.ToolCallDeltas) == 0 {
+		if chunk.TextDelta == "" && chunk.ReasoningDelta == "" && len(chunk.ToolCallDeltas) == 0 && len(chunk.Citations) == 0 {
 			return nil, false, nil
 		}
 		return chunk, false, nil
@@ -334,7 +508,7 @@ func (a *anthropicAdapter) parseStreamEvent(event *sseEvent, acc *streamAccumula
 			return nil, false, err
 		}
 		if payload.Delta.StopReason != "" {
-			return &StreamChunk{Done: true}, true, nil
+			return &StreamChunk{Done: true, FinishReason: anthropicFinishReason(payload.Delta.StopReason)}, true, nil
 		}
 		return nil, false, nil
 	default:
@@ -360,12 +534,22 @@ type anthropicTool struct {
 }
 
 type anthropicMessagesRequest struct {
-	Model     string             `json:"model"`
-	System    string             `json:"system,omitempty"`
-	Messages  []anthropicMessage `json:"messages"`
-	MaxTokens int                `json:"max_tokens"`
-	Tools     []anthropicTool    `json:"tools,omitempty"`
-	Stream    bool               `json:"stream,omitempty"`
+	Model         string             `json:"model"`
+	System        string             `json:"system,omitempty"`
+	Messages      []anthropicMessage `json:"messages"`
+	MaxTokens     int                `json:"max_tokens"`
+	Tools         []anthropicTool    `json:"tools,omitempty"`
+	Stream        bool               `json:"stream,omitempty"`
+	StopSequences []string           `json:"stop_sequences,omitempty"`
+	Temperature   *float64           `json:"temperature,omitempty"`
+	TopP          *float64           `json:"top_p,omitempty"`
+	TopK          *int               `json:"top_k,omitempty"`
+	Thinking      *anthropicThinking `json:"thinking,omitempty"`
+}
+
+type anthropicThinking struct {
+	Type         string `json:"type"` // "enabled"
+	BudgetTokens int    `json:"budget_tokens"`
 }
 
 type anthropicMessage struct {
@@ -376,6 +560,7 @@ type anthropicMessage struct {
 type anthropicMessagesResponse struct {
 	Content    []anthropicContentBlock `json:"content"`
 	StopReason string                  `json:"stop_reason"`
+	Usage      *anthropicUsage         `json:"usage,omitempty"`
 }
 
 type anthropicContentBlock struct {
@@ -384,12 +569,36 @@ type anthropicContentBlock struct {
 	// For image content
 	Source *anthropicImageSource `json:"source,omitempty"`
 	// For tool use request from model
-	ID    string         `json:"id,omitempty"`
-	Name  string         `json:"name,omitempty"`
-	Input map[string]any `json:"input,omitempty"`
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+	// Input holds a map[string]any. It is typed any so that a tool call
+	// without arguments still sends the "input": {} Anthropic requires.
+	Input any `json:"input,omitempty"`
 	// For tool result response from user
 	ToolUseID string `json:"tool_use_id,omitempty"`
 	Content   string `json:"content,omitempty"`
+	// For cited text in responses
+	Citations []anthropicCitation `json:"citations,omitempty"`
+	// For thinking blocks in responses
+	Thinking  string `json:"thinking,omitempty"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// anthropicCitation covers the web search and document citation location types.
+type anthropicCitation struct {
+	Type          string `json:"type"`
+	URL           string `json:"url,omitempty"`
+	Title         string `json:"title,omitempty"`
+	DocumentTitle string `json:"document_title,omitempty"`
+	CitedText     string `json:"cited_text,omitempty"`
+}
+
+func (c anthropicCitation) toCitation() Citation {
+	title := c.Title
+	if title == "" {
+		title = c.DocumentTitle
+	}
+	return Citation{URL: c.URL, Title: title, Text: c.CitedText}
 }
 
 type anthropicImageSource struct {
@@ -19,7 +19,9 @@ func NewAnthropicFormatConverter() *AnthropicFormatConverter {
 // DecodeRequest decodes the request body into the Anthropic request struct.
 func (c *AnthropicFormatConverter) DecodeRequest(r *http.Request) (any, error) {
 	var req AnthropicIncomingRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	dec := json.NewDecoder(r.Body)
+	dec.UseNumber() // Keep numbers in tool inputs and schemas exact
+	if err := dec.Decode(&req); err != nil {
 		return nil, fmt.Errorf("failed to decode Anthropic request: %w", err)
 	}
 	return &req, nil
@@ -66,6 +68,32 @@ func (c *AnthropicFormatConverter) ConvertResponseToFormat(universalResp *Respon
 	return c.ConvertResponseToAnthropic(universalResp, originalModel)
 }
 
+// ConvertErrorToFormat converts an error to Anthropic's error format.
+func (c *AnthropicFormatConverter) ConvertErrorToFormat(statusCode int, message string) any {
+	errType := "invalid_request_error"
+	switch statusCode {
+	case http.StatusUnauthorized:
+		errType = "authentication_error"
+	case http.StatusForbidden:
+		errType = "permission_error"
+	case http.StatusNotFound:
+		errType = "not_found_error"
+	case http.StatusRequestEntityTooLarge:
+		errType = "request_too_large"
+	case http.StatusTooManyRequests:
+		errType = "rate_limit_error"
+	case http.StatusGatewayTimeout:
+		errType = "timeout_error"
+	case 529:
+		errType = "overloaded_error"
+	default:
+		if statusCode >= http.StatusInternalServerError {
+			errType = "api_error"
+		}
+	}
+	return &AnthropicErrorResponse{Type: "error", Error: AnthropicError{Type: errType, Message: message}}
+}
+
 // ConvertRequestToUniversal converts an Anthropic request to Universal format.
 func (c *AnthropicFormatConverter) ConvertRequestToUniversal(anthropicReq *AnthropicIncomingRequest) (*Request, error) {
 	if anthropicReq == nil {
@@ -73,8 +101,13 @@ func (c *AnthropicFormatConverter) ConvertRequestToUniversal(anthropicReq *Anthr
 	}
 
 	universalReq := &Request{
-		Model:    anthropicReq.Model,
-		Messages: make([]Message, 0, len(anthropicReq.Messages)),
+		Model:         anthropicReq.Model,
+		Messages:      make([]Message, 0, len(anthropicReq.Messages)),
+		StopSequences: anthropicReq.StopSequences,
+		Temperature:   anthropicReq.Temperature,
+		TopP:          anthropicReq.TopP,
+		TopK:          anthropicReq.TopK,
+		MaxTokens:     anthropicReq.MaxTokens,
 	}
 
 	// Handle system prompt (can be string or array of content blocks)
@@ -128,7 +161,7 @@ func (c *AnthropicFormatConverter) ConvertRequestToUniversal(anthropicReq *Anthr
 				return nil, fmt.Errorf("failed to marshal content array in message[%d]: %w", i, err)
 			}
 			var blocks []anthropicContentBlock
-			if err := json.Unmarshal(contentBytes, &blocks); err != nil {
+			if err := decodeFirstJSON(contentBytes, &blocks); err != nil {
 				return nil, fmt.Errorf("failed to unmarshal content blocks in message[%d]: %w", i, err)
 			}
 
@@ -251,8 +284,8 @@ func (c *AnthropicFormatConverter) ConvertResponseToAnthropic(universalResp *Res
 
 	// Add tool calls if present
 	for _, tc := range universalResp.ToolCalls {
-		var input map[string]any
-		if err := json.Unmarshal([]byte(tc.Arguments), &input); err != nil {
+		input, err := decodeArguments(tc.Arguments)
+		if err != nil {
 			return nil, fmt.Errorf("failed to unmarshal tool call arguments: %w", err)
 		}
 		anthropicResp.Content = append(anthropicResp.Content, anthropicContentBlock{
@@ -263,11 +296,10 @@ func (c *AnthropicFormatConverter) ConvertResponseToAnthropic(universalResp *Res
 		})
 	}
 
-	// Set stop reason
-	if len(universalResp.ToolCalls) > 0 {
-		anthropicResp.StopReason = "tool_use"
-	} else {
-		anthropicResp.StopReason = "end_turn"
+	anthropicResp.StopReason = anthropicStopReasonFor(universalResp)
+
+	if u := universalResp.Usage; u != nil {
+		anthropicResp.Usage = &anthropicUsage{InputTokens: u.InputTokens, OutputTokens: u.OutputTokens}
 	}
 
 	return anthropicResp, nil
@@ -371,9 +403,13 @@ func (h *AnthropicStreamHandler) OnChunk(w http.ResponseWriter, flusher http.Flu
 				"index": *h.TextIndex,
 			})
 		}
+		stopReason := "end_turn"
+		if chunk.Snapshot != nil {
+			stopReason = anthropicStopReasonFor(chunk.Snapshot)
+		}
 		sendAnthropicEvent(w, flusher, "message_delta", map[string]any{
 			"type":  "message_delta",
-			"delta": map[string]any{"stop_reason": "end_turn"},
+			"delta": map[string]any{"stop_reason": stopReason},
 		})
 		sendAnthropicEvent(w, flusher, "message_stop", map[string]any{"type": "message_stop"})
 		h.SentStop = true
@@ -381,6 +417,21 @@ func (h *AnthropicStreamHandler) OnChunk(w http.ResponseWriter, flusher http.Flu
 	return nil
 }
 
+// anthropicStopReasonFor maps a response's finish reason back to Anthropic's
+// stop_reason; any tool call makes it tool_use.
+func anthropicStopReasonFor(resp *Response) string {
+	switch {
+	case len(resp.ToolCalls) > 0:
+		return "tool_use"
+	case resp.FinishReason == FinishReasonLength:
+		return "max_tokens"
+	case resp.FinishReason == FinishReasonContentFilter:
+		return "refusal"
+	default:
+		return "end_turn"
+	}
+}
+
 func (h *AnthropicStreamHandler) OnEnd(w http.ResponseWriter, flusher http.Flusher) {
 	if !h.MessageStarted || h.SentStop {
 		return
@@ -420,14 +471,30 @@ func generateAnthropicMessageID() string {
 
 // --- Anthropic Specific Types (Exported for format conversion) ---
 
+// AnthropicErrorResponse is the body of an Anthropic API error.
+type AnthropicErrorResponse struct {
+	Type  string         `json:"type"` // Always "error"
+	Error AnthropicError `json:"error"`
+}
+
+// AnthropicError describes an Anthropic API error.
+type AnthropicError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
 // AnthropicIncomingRequest represents an Anthropic messages request.
 type AnthropicIncomingRequest struct {
-	Model     string                     `json:"model"`
-	System    any                        `json:"system,omitempty"` // Can be string or []anthropicContentBlock
-	Messages  []anthropicIncomingMessage `json:"messages"`
-	MaxTokens int                        `json:"max_tokens"`
-	Tools     []anthropicTool            `json:"tools,omitempty"`
-	Stream    bool                       `json:"stream,omitempty"`
+	Model         string                     `json:"model"`
+	System        any                        `json:"system,omitempty"` // Can be string or []anthropicContentBlock
+	Messages      []anthropicIncomingMessage `json:"messages"`
+	MaxTokens     int                        `json:"max_tokens"`
+	Tools         []anthropicTool            `json:"tools,omitempty"`
+	Stream        bool                       `json:"stream,omitempty"`
+	StopSequences []string                   `json:"stop_sequences,omitempty"`
+	Temperature   *float64                   `json:"temperature,omitempty"`
+	TopP          *float64                   `json:"top_p,omitempty"`
+	TopK          *int                       `json:"top_k,omitempty"`
 }
 
 type anthropicIncomingMessage struct {
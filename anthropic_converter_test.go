@@ -1,7 +1,10 @@
 package ai
 
 import (
+	"context"
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -79,3 +82,70 @@ func TestAnthropicConverter_ConvertRequestFromFormat_ToolUse(t *testing.T) {
 		t.Errorf("Request validation failed: %v", err)
 	}
 }
+
+func TestAnthropicStopSequences_DirectCall(t *testing.T) {
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body anthropicMessagesRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		got = body.StopSequences
+		w.Write([]byte(`{"content":[{"type":"text","text":"ok"}],"stop_reason":"stop_sequence"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithProvider(ProviderAnthropic), WithAPIKey("test-key"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	_, err = client.Generate(context.Background(), &Request{
+		Messages:      []Message{{Role: RoleUser, Content: "count to ten"}},
+		StopSequences: []string{"5", "END"},
+	})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if len(got) != 2 || got[0] != "5" || got[1] != "END" {
+		t.Fatalf("expected stop_sequences [5 END], got %v", got)
+	}
+}
+
+func TestAnthropicStopSequences_ProxyRoundTrip(t *testing.T) {
+	requestJSON := `{
+		"model": "claude-haiku-4-5",
+		"max_tokens": 100,
+		"stop_sequences": ["\n\nHuman:", "STOP"],
+		"messages": [{"role": "user", "content": "hi"}]
+	}`
+
+	var providerReq AnthropicIncomingRequest
+	if err := json.Unmarshal([]byte(requestJSON), &providerReq); err != nil {
+		t.Fatalf("Failed to unmarshal request: %v", err)
+	}
+	req, err := NewAnthropicFormatConverter().ConvertRequestFromFormat(&providerReq)
+	if err != nil {
+		t.Fatalf("ConvertRequestFromFormat failed: %v", err)
+	}
+	if len(req.StopSequences) != 2 || req.StopSequences[1] != "STOP" {
+		t.Fatalf("expected stop sequences to be preserved, got %v", req.StopSequences)
+	}
+
+	payload, err := (&anthropicAdapter{}).buildRequestPayload(context.Background(), req)
+	if err != nil {
+		t.Fatalf("buildRequestPayload failed: %v", err)
+	}
+	out, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("failed to marshal payload: %v", err)
+	}
+	var sent struct {
+		StopSequences []string `json:"stop_sequences"`
+	}
+	if err := json.Unmarshal(out, &sent); err != nil {
+		t.Fatalf("failed to unmarshal payload: %v", err)
+	}
+	if len(sent.StopSequences) != 2 || sent.StopSequences[0] != "\n\nHuman:" {
+		t.Fatalf("expected stop_sequences to reach Anthropic, got %v", sent.StopSequences)
+	}
+}
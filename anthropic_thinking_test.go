@@ -0,0 +1,118 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAnthropicThinking(t *testing.T) {
+	var payload map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"content":[
+			{"type":"thinking","thinking":"The user wants 2+2. ","signature":"sig-1"},
+			{"type":"thinking","thinking":"That is 4.","signature":"sig-2"},
+			{"type":"text","text":"4"}],"stop_reason":"end_turn"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithProvider(ProviderAnthropic), WithAPIKey("test-key"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	resp, err := client.Generate(context.Background(), &Request{
+		Messages: []Message{{Role: RoleUser, Content: "What is 2+2?"}},
+		Thinking: &ThinkingConfig{BudgetTokens: 8000},
+	})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	if resp.Text != "4" {
+		t.Errorf("expected answer text %q, got %q", "4", resp.Text)
+	}
+	if resp.Reasoning != "The user wants 2+2. That is 4." {
+		t.Errorf("unexpected reasoning: %q", resp.Reasoning)
+	}
+
+	thinking, _ := payload["thinking"].(map[string]any)
+	if thinking["type"] != "enabled" || thinking["budget_tokens"] != float64(8000) {
+		t.Errorf("unexpected thinking payload: %v", payload["thinking"])
+	}
+	if maxTokens, _ := payload["max_tokens"].(float64); maxTokens <= 8000 {
+		t.Errorf("expected max_tokens above the thinking budget, got %v", payload["max_tokens"])
+	}
+}
+
+func TestAnthropicThinkingStreaming(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		events := []string{
+			`{"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"Adding "}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"two and two."}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"signature_delta","signature":"sig"}}`,
+			`{"type":"content_block_stop","index":0}`,
+			`{"type":"content_block_start","index":1,"content_block":{"type":"text","text":""}}`,
+			`{"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"4"}}`,
+			`{"type":"content_block_stop","index":1}`,
+			`{"type":"message_stop"}`,
+		}
+		for _, e := range events {
+			fmt.Fprintf(w, "data: %s\n\n", e)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithProvider(ProviderAnthropic), WithAPIKey("test-key"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	reader, err := Stream(context.Background(), client, &Request{
+		Messages: []Message{{Role: RoleUser, Content: "What is 2+2?"}},
+		Thinking: &ThinkingConfig{BudgetTokens: 2048},
+	})
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	defer reader.Close()
+
+	resp, err := AccumulateStream(reader)
+	if err != nil {
+		t.Fatalf("AccumulateStream failed: %v", err)
+	}
+	if resp.Reasoning != "Adding two and two." {
+		t.Errorf("unexpected streamed reasoning: %q", resp.Reasoning)
+	}
+	if resp.Text != "4" {
+		t.Errorf("expected reasoning to stay out of the answer text, got %q", resp.Text)
+	}
+}
+
+func TestAnthropicThinkingDropsTemperature(t *testing.T) {
+	temp := 0.5
+	req := &Request{
+		Messages:    []Message{{Role: RoleUser, Content: "hi"}},
+		Thinking:    &ThinkingConfig{BudgetTokens: 1024},
+		Temperature: &temp,
+	}
+
+	payload, err := (&anthropicAdapter{}).buildRequestPayload(context.Background(), req)
+	if err != nil {
+		t.Fatalf("buildRequestPayload failed: %v", err)
+	}
+	if p := payload.(*anthropicMessagesRequest); p.Temperature != nil {
+		t.Errorf("expected temperature to be dropped with thinking enabled, got %v", *p.Temperature)
+	}
+
+	if _, err := (&anthropicAdapter{params: paramPolicy{strict: true}}).buildRequestPayload(context.Background(), req); err == nil {
+		t.Error("expected strict mode to reject temperature with thinking")
+	}
+}
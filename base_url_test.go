@@ -0,0 +1,67 @@
+package ai
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// hostRecorder is a transport that records the host of each request and
+// answers with a canned body.
+type hostRecorder struct {
+	hosts []string
+	body  string
+}
+
+func (t *hostRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.hosts = append(t.hosts, req.URL.Host)
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(t.body)),
+		Request:    req,
+	}, nil
+}
+
+// TestBaseURLs tests that each provider calls its default host unless
+// WithBaseURL overrides it
+func TestBaseURLs(t *testing.T) {
+	openaiBody := `{"choices":[{"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`
+	tests := []struct {
+		provider Provider
+		host     string
+		body     string
+	}{
+		{ProviderOpenAI, "api.openai.com", openaiBody},
+		{ProviderGemini, "generativelanguage.googleapis.com", `{"candidates":[{"content":{"role":"model","parts":[{"text":"ok"}]},"finishReason":"STOP"}]}`},
+		{ProviderAnthropic, "api.anthropic.com", `{"content":[{"type":"text","text":"ok"}],"stop_reason":"end_turn"}`},
+		{ProviderMistral, "api.mistral.ai", openaiBody},
+	}
+	if len(tests) != len(defaultBaseURLs) {
+		t.Fatalf("expected a case for each of the %d providers with a default base URL", len(defaultBaseURLs))
+	}
+
+	for _, tt := range tests {
+		for _, override := range []bool{false, true} {
+			transport := &hostRecorder{body: tt.body}
+			opts := []Option{WithProvider(tt.provider), WithAPIKey("test-key"), func(c *Config) { c.transport = transport }}
+			want := tt.host
+			if override {
+				opts = append(opts, WithBaseURL("https://proxy.example.com"))
+				want = "proxy.example.com"
+			}
+			client, err := NewClient(opts...)
+			if err != nil {
+				t.Fatalf("%s: failed to create client: %v", tt.provider, err)
+			}
+			if _, err := client.Generate(context.Background(), &Request{Messages: []Message{{Role: RoleUser, Content: "Hi"}}}); err != nil {
+				t.Fatalf("%s: Generate failed: %v", tt.provider, err)
+			}
+			if len(transport.hosts) != 1 || transport.hosts[0] != want {
+				t.Errorf("%s (override %v): expected a request to %s, got %v", tt.provider, override, want, transport.hosts)
+			}
+		}
+	}
+}
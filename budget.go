@@ -0,0 +1,113 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrBudgetExceeded is matched (via errors.Is) by *BudgetExceededError.
+var ErrBudgetExceeded = errors.New("token budget exceeded")
+
+// BudgetExceededError is returned by TokenBudget before a call whose estimated
+// input, added to the tokens already used, would exceed the budget.
+type BudgetExceededError struct {
+	Used      int // Tokens consumed by earlier calls
+	Estimated int // Estimated input tokens of the pending request
+	Limit     int // Configured budget
+}
+
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("%s: used %d tokens, request needs an estimated %d more, limit %d", ErrBudgetExceeded, e.Used, e.Estimated, e.Limit)
+}
+
+func (e *BudgetExceededError) Unwrap() error {
+	return ErrBudgetExceeded
+}
+
+// TokenBudget tracks cumulative token usage across calls, for example over a
+// long chat session, and optionally enforces a ceiling on it. It is safe for
+// concurrent use.
+type TokenBudget struct {
+	mu        sync.Mutex
+	limit     int
+	tokenizer Tokenizer
+	used      Usage
+}
+
+// NewTokenBudget returns a budget allowing limit total tokens. A non-positive
+// limit only tracks usage. tokenizer estimates pending requests and stands in
+// for providers that report no usage; nil means WhitespaceTokenizer.
+func NewTokenBudget(limit int, tokenizer Tokenizer) *TokenBudget {
+	if tokenizer == nil {
+		tokenizer = WhitespaceTokenizer{}
+	}
+	return &TokenBudget{limit: limit, tokenizer: tokenizer}
+}
+
+// Check returns a *BudgetExceededError if sending req would exceed the budget.
+func (b *TokenBudget) Check(req *Request) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.limit <= 0 {
+		return nil
+	}
+	if n := EstimateTokens(b.tokenizer, req); b.used.TotalTokens+n > b.limit {
+		return &BudgetExceededError{Used: b.used.TotalTokens, Estimated: n, Limit: b.limit}
+	}
+	return nil
+}
+
+// Record adds u to the tokens used so far. A nil u is ignored.
+func (b *TokenBudget) Record(u *Usage) {
+	if u == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.used.InputTokens += u.InputTokens
+	b.used.OutputTokens += u.OutputTokens
+	b.used.TotalTokens += u.TotalTokens
+}
+
+// Used returns the tokens consumed so far.
+func (b *TokenBudget) Used() Usage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.used
+}
+
+// Remaining returns the tokens left in the budget, or -1 if it has no limit.
+func (b *TokenBudget) Remaining() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.limit <= 0 {
+		return -1
+	}
+	return max(b.limit-b.used.TotalTokens, 0)
+}
+
+// Generate checks req against the budget, calls c, and records the usage of
+// the response. When the provider reports no usage, it is estimated with the
+// budget's tokenizer.
+func (b *TokenBudget) Generate(ctx context.Context, c Client, req *Request) (*Response, error) {
+	if err := b.Check(req); err != nil {
+		return nil, err
+	}
+	resp, err := c.Generate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	usage := resp.Usage
+	if usage == nil {
+		in := EstimateTokens(b.tokenizer, req)
+		out := b.tokenizer.Count(resp.Text)
+		for _, tc := range resp.ToolCalls {
+			out += b.tokenizer.Count(tc.Function) + b.tokenizer.Count(tc.Arguments)
+		}
+		usage = &Usage{InputTokens: in, OutputTokens: out, TotalTokens: in + out}
+	}
+	b.Record(usage)
+	return resp, nil
+}
@@ -0,0 +1,78 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// usageClient returns a fixed response with the given usage and counts calls.
+type usageClient struct {
+	usage *Usage
+	calls int
+}
+
+func (c *usageClient) Generate(ctx context.Context, req *Request) (*Response, error) {
+	c.calls++
+	return &Response{Text: "four word reply here", Usage: c.usage}, nil
+}
+
+func TestTokenBudget(t *testing.T) {
+	client := &usageClient{usage: &Usage{InputTokens: 30, OutputTokens: 10, TotalTokens: 40}}
+	budget := NewTokenBudget(100, nil)
+	req := &Request{Messages: []Message{{Role: RoleUser, Content: "hello there"}}}
+
+	// Two calls use 80 tokens; a third (40 more) is still allowed to start
+	// because only its 2-token input is estimated up front.
+	for i := 0; i < 3; i++ {
+		if _, err := budget.Generate(context.Background(), client, req); err != nil {
+			t.Fatalf("call %d: unexpected error: %v", i+1, err)
+		}
+	}
+	if got := budget.Used(); got.TotalTokens != 120 || got.InputTokens != 90 || got.OutputTokens != 30 {
+		t.Errorf("unexpected usage after three calls: %+v", got)
+	}
+	if got := budget.Remaining(); got != 0 {
+		t.Errorf("expected 0 tokens remaining, got %d", got)
+	}
+
+	_, err := budget.Generate(context.Background(), client, req)
+	var budgetErr *BudgetExceededError
+	if !errors.As(err, &budgetErr) || !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("expected BudgetExceededError, got %v", err)
+	}
+	if budgetErr.Used != 120 || budgetErr.Estimated != 2 || budgetErr.Limit != 100 {
+		t.Errorf("unexpected error fields: %+v", budgetErr)
+	}
+	if client.calls != 3 {
+		t.Errorf("expected the over-budget call to be rejected locally, got %d calls", client.calls)
+	}
+}
+
+func TestTokenBudgetPendingRequestTooLarge(t *testing.T) {
+	budget := NewTokenBudget(10, charTokenizer{})
+	budget.Record(&Usage{InputTokens: 3, OutputTokens: 2, TotalTokens: 5})
+
+	if err := budget.Check(&Request{Messages: []Message{{Role: RoleUser, Content: "12345"}}}); err != nil {
+		t.Errorf("expected request that exactly fills the budget to pass, got %v", err)
+	}
+	if err := budget.Check(&Request{Messages: []Message{{Role: RoleUser, Content: "123456"}}}); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("expected ErrBudgetExceeded, got %v", err)
+	}
+}
+
+func TestTokenBudgetEstimatesMissingUsage(t *testing.T) {
+	client := &usageClient{}
+	budget := NewTokenBudget(0, nil)
+	req := &Request{Messages: []Message{{Role: RoleUser, Content: "hello there"}}}
+
+	if _, err := budget.Generate(context.Background(), client, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := budget.Used(); got != (Usage{InputTokens: 2, OutputTokens: 4, TotalTokens: 6}) {
+		t.Errorf("unexpected estimated usage: %+v", got)
+	}
+	if got := budget.Remaining(); got != -1 {
+		t.Errorf("expected -1 remaining for an unlimited budget, got %d", got)
+	}
+}
@@ -0,0 +1,149 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by a CircuitBreakerClient while its circuit is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitBreakerOptions configures NewCircuitBreakerClient. Zero values use the defaults.
+type CircuitBreakerOptions struct {
+	// FailureThreshold is the number of consecutive retriable failures that
+	// opens the circuit. Default 5.
+	FailureThreshold int
+	// Cooldown is how long the circuit stays open before a probe request is
+	// let through. Default 30s.
+	Cooldown time.Duration
+}
+
+// CircuitState is the state of a CircuitBreakerClient.
+type CircuitState int
+
+const (
+	CircuitClosed   CircuitState = iota // Requests pass through
+	CircuitOpen                         // Requests fail fast with ErrCircuitOpen
+	CircuitHalfOpen                     // A single probe request is in flight
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitState(%d)", int(s))
+}
+
+// CircuitBreakerClient wraps a Client so that a failing provider is not called
+// repeatedly. After FailureThreshold consecutive retriable failures
+// (*ServerError, *NetworkError or *TimeoutError) it fails fast with
+// ErrCircuitOpen for Cooldown, then lets one probe through: success closes the
+// circuit, failure opens it again. Other errors mean the provider is reachable
+// and reset the failure count. It is safe for concurrent use.
+type CircuitBreakerClient struct {
+	inner Client
+	opts  CircuitBreakerOptions
+	now   func() time.Time
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+}
+
+// NewCircuitBreakerClient returns a circuit breaker around inner.
+func NewCircuitBreakerClient(inner Client, opts CircuitBreakerOptions) *CircuitBreakerClient {
+	if opts.FailureThreshold <= 0 {
+		opts.FailureThreshold = 5
+	}
+	if opts.Cooldown <= 0 {
+		opts.Cooldown = 30 * time.Second
+	}
+	return &CircuitBreakerClient{inner: inner, opts: opts, now: time.Now}
+}
+
+// State returns the current circuit state.
+func (c *CircuitBreakerClient) State() CircuitState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.state == CircuitOpen && c.now().Sub(c.openedAt) >= c.opts.Cooldown {
+		// Due for a probe; report it as the next request will see it.
+		return CircuitHalfOpen
+	}
+	return c.state
+}
+
+// Generate calls the inner client unless the circuit is open.
+func (c *CircuitBreakerClient) Generate(ctx context.Context, req *Request) (*Response, error) {
+	if err := c.allow(); err != nil {
+		return nil, err
+	}
+	resp, err := c.inner.Generate(ctx, req)
+	c.record(err)
+	return resp, err
+}
+
+// Close closes the inner client; see CloseClient.
+func (c *CircuitBreakerClient) Close() error {
+	return CloseClient(c.inner)
+}
+
+// allow reports whether a request may proceed, moving an open circuit to
+// half-open once the cooldown has passed.
+func (c *CircuitBreakerClient) allow() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch c.state {
+	case CircuitOpen:
+		remaining := c.opts.Cooldown - c.now().Sub(c.openedAt)
+		if remaining > 0 {
+			return fmt.Errorf("%w: retry in %v", ErrCircuitOpen, remaining.Round(time.Millisecond))
+		}
+		c.state = CircuitHalfOpen
+	case CircuitHalfOpen:
+		// Only one probe at a time.
+		return fmt.Errorf("%w: probe in progress", ErrCircuitOpen)
+	}
+	return nil
+}
+
+// record updates the circuit with the outcome of a request.
+func (c *CircuitBreakerClient) record(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if isCircuitFailure(err) {
+		c.failures++
+		if c.state == CircuitHalfOpen || c.failures >= c.opts.FailureThreshold {
+			c.state = CircuitOpen
+			c.openedAt = c.now()
+			c.failures = 0
+		}
+		return
+	}
+	if err != nil && errors.Is(err, context.Canceled) {
+		// The caller gave up; this says nothing about the provider.
+		if c.state == CircuitHalfOpen {
+			c.state = CircuitOpen
+		}
+		return
+	}
+	c.state = CircuitClosed
+	c.failures = 0
+}
+
+// isCircuitFailure reports whether err indicates the provider is unavailable.
+func isCircuitFailure(err error) bool {
+	var serverErr *ServerError
+	var networkErr *NetworkError
+	var timeoutErr *TimeoutError
+	return errors.As(err, &serverErr) || errors.As(err, &networkErr) || errors.As(err, &timeoutErr)
+}
@@ -0,0 +1,115 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerTripsAndRecovers(t *testing.T) {
+	var healthy atomic.Bool
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error":{"message":"overloaded"}}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`))
+	}))
+	defer server.Close()
+
+	inner, err := NewClient(
+		WithProvider(ProviderOpenAI),
+		WithAPIKey("test-key"),
+		WithBaseURL(server.URL),
+		WithBackoff(time.Millisecond, time.Millisecond),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	breaker := NewCircuitBreakerClient(inner, CircuitBreakerOptions{FailureThreshold: 2, Cooldown: time.Minute})
+	now := time.Now()
+	breaker.now = func() time.Time { return now }
+	req := &Request{Messages: []Message{{Role: RoleUser, Content: "hi"}}}
+
+	// Two consecutive 500s trip the breaker.
+	for i := range 2 {
+		var serverErr *ServerError
+		if _, err := breaker.Generate(context.Background(), req); !errors.As(err, &serverErr) {
+			t.Fatalf("call %d: expected ServerError, got %v", i+1, err)
+		}
+	}
+	if breaker.State() != CircuitOpen {
+		t.Fatalf("expected open circuit, got %v", breaker.State())
+	}
+
+	// While open, calls fail fast without reaching the provider.
+	before := calls.Load()
+	if _, err := breaker.Generate(context.Background(), req); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if calls.Load() != before {
+		t.Errorf("expected no provider call while open")
+	}
+
+	// After the cooldown a failed probe reopens the circuit.
+	now = now.Add(time.Minute)
+	if breaker.State() != CircuitHalfOpen {
+		t.Fatalf("expected half-open circuit after cooldown, got %v", breaker.State())
+	}
+	if _, err := breaker.Generate(context.Background(), req); errors.Is(err, ErrCircuitOpen) || err == nil {
+		t.Fatalf("expected the probe to reach the provider and fail, got %v", err)
+	}
+	if breaker.State() != CircuitOpen {
+		t.Fatalf("expected failed probe to reopen the circuit, got %v", breaker.State())
+	}
+
+	// Once the provider recovers, a successful probe closes the circuit.
+	healthy.Store(true)
+	now = now.Add(time.Minute)
+	resp, err := breaker.Generate(context.Background(), req)
+	if err != nil || resp.Text != "ok" {
+		t.Fatalf("expected successful probe, got %v", err)
+	}
+	if breaker.State() != CircuitClosed {
+		t.Errorf("expected closed circuit after recovery, got %v", breaker.State())
+	}
+}
+
+// errClient returns the given errors in order, then succeeds.
+type errClient struct {
+	errs []error
+}
+
+func (c *errClient) Generate(ctx context.Context, req *Request) (*Response, error) {
+	if len(c.errs) == 0 {
+		return &Response{Text: "ok"}, nil
+	}
+	err := c.errs[0]
+	c.errs = c.errs[1:]
+	return nil, err
+}
+
+func TestCircuitBreakerIgnoresNonRetriableErrors(t *testing.T) {
+	inner := &errClient{errs: []error{
+		NewServerError("test", 500, "boom", nil),
+		NewInvalidRequestError("test", "bad request", "", nil),
+		NewNetworkError("test", "refused", nil),
+		NewRateLimitError("test", "slow down", 0, nil),
+	}}
+	breaker := NewCircuitBreakerClient(inner, CircuitBreakerOptions{FailureThreshold: 2})
+
+	for range 4 {
+		breaker.Generate(context.Background(), &Request{})
+	}
+	if breaker.State() != CircuitClosed {
+		t.Errorf("expected non-retriable errors to reset the failure count, got %v", breaker.State())
+	}
+}
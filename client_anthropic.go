@@ -6,16 +6,18 @@ import (
 
 // newAnthropicClient is the internal constructor for the Anthropic client.
 func newAnthropicClient(cfg *Config) Client {
-	baseURL := "https://api.anthropic.com"
-	if cfg.baseURL != "" {
-		baseURL = cfg.baseURL
-	}
 	headers := make(http.Header)
 	headers.Set("x-api-key", cfg.apiKey)
 	headers.Set("anthropic-version", "2023-06-01") // Required header
 
-	return &genericClient{
-		b:       newBaseClient(string(ProviderAnthropic), baseURL, "v1", cfg.timeout, headers, 3),
-		adapter: &anthropicAdapter{},
-	}
+	b := newBaseClient(string(ProviderAnthropic), cfg.resolveBaseURL(), "v1", cfg.timeout, headers, 3)
+	return newGenericClient(cfg, b, &anthropicAdapter{
+		params:          newParamPolicy(cfg),
+		mergeMessages:   cfg.mergeMessages,
+		imageMode:       cfg.anthropicImageMode,
+		downloadTimeout: cfg.downloadTimeout,
+		downloadBudget:  cfg.downloadBudget,
+		fetchMedia:      cfg.mediaFetcher,
+		imageMaxDim:     cfg.imageMaxDimension,
+	})
 }
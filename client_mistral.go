@@ -0,0 +1,14 @@
+package ai
+
+import (
+	"net/http"
+)
+
+// newMistralClient is the internal constructor for the Mistral client.
+func newMistralClient(cfg *Config) Client {
+	headers := make(http.Header)
+	headers.Set("Authorization", "Bearer "+cfg.apiKey)
+
+	b := newBaseClient(string(ProviderMistral), cfg.resolveBaseURL(), "v1", cfg.timeout, headers, 3)
+	return newGenericClient(cfg, b, &mistralAdapter{openaiAdapter{params: newParamPolicy(cfg)}})
+}
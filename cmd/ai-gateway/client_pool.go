@@ -1,8 +1,10 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"slices"
 	"sync"
 	"time"
 
@@ -13,12 +15,25 @@ import (
 type ClientPool struct {
 	mu      sync.RWMutex
 	clients map[string]ai.Client // key: provider name
+	opts    []ai.Option          // extra options applied to every client
+
+	apiKeys   map[string][]APIKeyConfig // Providers with several balanced keys
+	providers map[string]ProviderConfig // Per-provider settings such as base URLs
+	metrics   *MetricsCollector         // Receives per-key metrics; may be nil
 }
 
 // NewClientPool creates a new empty client pool
-func NewClientPool() *ClientPool {
+// Providers listed in apiKeys get a client that balances across those keys;
+// the rest use their single key from the environment.
+// A base URL in providers takes precedence over the provider's environment variable.
+// The given options are applied to every client the pool creates.
+func NewClientPool(apiKeys map[string][]APIKeyConfig, providers map[string]ProviderConfig, metrics *MetricsCollector, opts ...ai.Option) *ClientPool {
 	return &ClientPool{
-		clients: make(map[string]ai.Client),
+		clients:   make(map[string]ai.Client),
+		opts:      opts,
+		apiKeys:   apiKeys,
+		providers: providers,
+		metrics:   metrics,
 	}
 }
 
@@ -46,7 +61,7 @@ func (p *ClientPool) GetClient(provider ai.Provider) (ai.Client, error) {
 	}
 
 	// Create new client from environment variables
-	client, err := createClientFromEnv(provider)
+	client, err := p.createClient(provider)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create client for %s: %w", provider, err)
 	}
@@ -57,43 +72,88 @@ func (p *ClientPool) GetClient(provider ai.Provider) (ai.Client, error) {
 }
 
 // GetStreamingClient retrieves a streaming-capable client
-func (p *ClientPool) GetStreamingClient(provider ai.Provider) (ai.StreamingClient, error) {
+func (p *ClientPool) GetStreamingClient(provider ai.Provider) (ai.Client, error) {
 	client, err := p.GetClient(provider)
 	if err != nil {
 		return nil, err
 	}
 
-	streamingClient, ok := client.(ai.StreamingClient)
-	if !ok {
-		return nil, fmt.Errorf("provider %s does not support streaming", provider)
+	if !ai.CanStream(client) {
+		return nil, &ai.StreamingUnsupportedError{Provider: string(provider)}
+	}
+
+	return client, nil
+}
+
+// Close closes every pooled client and empties the pool
+func (p *ClientPool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var errs []error
+	for key, client := range p.clients {
+		errs = append(errs, ai.CloseClient(client))
+		delete(p.clients, key)
+	}
+	return errors.Join(errs...)
+}
+
+// createClient creates the client for a provider, balancing across its
+// configured API keys when there are any
+func (p *ClientPool) createClient(provider ai.Provider) (ai.Client, error) {
+	opts := p.opts
+	if baseURL := p.providers[string(provider)].BaseURL; baseURL != "" {
+		opts = append(slices.Clip(opts), ai.WithBaseURL(baseURL))
+	}
+
+	keys := p.apiKeys[string(provider)]
+	if len(keys) == 0 {
+		return createClientFromEnv(provider, "", opts...)
 	}
 
-	return streamingClient, nil
+	keyed := make([]*keyedClient, 0, len(keys))
+	for _, key := range keys {
+		client, err := createClientFromEnv(provider, key.Env, opts...)
+		if err != nil {
+			return nil, err
+		}
+		weight := key.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		keyed = append(keyed, &keyedClient{name: key.Env, client: client, weight: weight})
+	}
+	return newBalancedClient(provider, keyed, p.metrics), nil
 }
 
-// createClientFromEnv creates an AI client from environment variables
-func createClientFromEnv(provider ai.Provider) (ai.Client, error) {
-	var apiKey, baseURL string
+// createClientFromEnv creates an AI client from environment variables.
+// keyEnv names the variable holding the API key; empty means the provider default.
+func createClientFromEnv(provider ai.Provider, keyEnv string, extraOpts ...ai.Option) (ai.Client, error) {
+	var defaultKeyEnv, baseURL string
 
 	// Get provider-specific environment variables
 	switch provider {
 	case ai.ProviderOpenAI:
-		apiKey = os.Getenv("OPENAI_API_KEY")
+		defaultKeyEnv = "OPENAI_API_KEY"
 		baseURL = os.Getenv("OPENAI_BASE_URL")
 	case ai.ProviderGemini:
-		apiKey = os.Getenv("GEMINI_API_KEY")
+		defaultKeyEnv = "GEMINI_API_KEY"
 		baseURL = os.Getenv("GEMINI_BASE_URL")
 	case ai.ProviderAnthropic:
-		apiKey = os.Getenv("ANTHROPIC_API_KEY")
+		defaultKeyEnv = "ANTHROPIC_API_KEY"
 		baseURL = os.Getenv("ANTHROPIC_BASE_URL")
 	default:
 		return nil, fmt.Errorf("unsupported provider: %s", provider)
 	}
+	if keyEnv == "" {
+		keyEnv = defaultKeyEnv
+	}
 
 	// Check if API key is set
+	apiKey := os.Getenv(keyEnv)
 	if apiKey == "" {
-		return nil, fmt.Errorf("API key not set for provider %s (set %s_API_KEY environment variable)",
-			provider, provider)
+		return nil, fmt.Errorf("API key not set for provider %s (set %s environment variable)",
+			provider, keyEnv)
 	}
 
 	// Build client options
@@ -107,6 +167,7 @@ func createClientFromEnv(provider ai.Provider) (ai.Client, error) {
 	if baseURL != "" {
 		opts = append(opts, ai.WithBaseURL(baseURL))
 	}
+	opts = append(opts, extraOpts...)
 
 	// Create and return the client
 	return ai.NewClient(opts...)
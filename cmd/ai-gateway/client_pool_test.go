@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/liuzl/ai"
+)
+
+// closingClient records whether it has been closed.
+type closingClient struct {
+	fakeClient
+	closed bool
+}
+
+func (c *closingClient) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestClientPoolClose(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "test-key")
+	pool := NewClientPool(nil, nil, nil)
+	a, b := &closingClient{}, &closingClient{}
+	pool.clients["openai"] = newBalancedClient(ai.ProviderOpenAI, []*keyedClient{
+		{name: "KEY_A", client: a, weight: 1},
+		{name: "KEY_B", client: b, weight: 1},
+	}, nil)
+
+	if err := pool.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if !a.closed || !b.closed {
+		t.Errorf("expected the client of every key to be closed, got %v and %v", a.closed, b.closed)
+	}
+	if len(pool.clients) != 0 {
+		t.Errorf("expected Close to empty the pool, got %d clients", len(pool.clients))
+	}
+
+	// The pool creates fresh clients once closed.
+	if _, err := pool.GetClient(ai.ProviderOpenAI); err != nil {
+		t.Errorf("expected GetClient to work after Close, got %v", err)
+	}
+	if err := pool.Close(); err != nil {
+		t.Errorf("expected a second Close to succeed, got %v", err)
+	}
+}
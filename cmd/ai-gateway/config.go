@@ -2,8 +2,11 @@ package main
 
 import (
 	"fmt"
+	"math/rand/v2"
+	"net/url"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/liuzl/ai"
 	"gopkg.in/yaml.v3"
@@ -11,18 +14,59 @@ import (
 
 // ProxyConfig represents the YAML configuration structure
 type ProxyConfig struct {
-	Version         string        `yaml:"version"`
-	Models          []ModelConfig `yaml:"models"`
-	DefaultProvider string        `yaml:"default_provider,omitempty"`
-	DefaultModel    string        `yaml:"default_model,omitempty"`
-	Timeout         string        `yaml:"timeout,omitempty"`
+	Version          string                    `yaml:"version"`
+	Models           []ModelConfig             `yaml:"models"`
+	Aliases          map[string]AliasConfig    `yaml:"aliases,omitempty"`   // Friendly model names mapped to a provider and real model
+	APIKeys          map[string][]APIKeyConfig `yaml:"api_keys,omitempty"`  // Several weighted keys per provider, balanced per request
+	Providers        map[string]ProviderConfig `yaml:"providers,omitempty"` // Per-provider client settings
+	DefaultProvider  string                    `yaml:"default_provider,omitempty"`
+	DefaultModel     string                    `yaml:"default_model,omitempty"`
+	Timeout          string                    `yaml:"timeout,omitempty"`
+	CoalesceRequests bool                      `yaml:"coalesce_requests,omitempty"`    // Share one backend call among identical concurrent requests
+	MaxRequestBytes  int64                     `yaml:"max_request_bytes,omitempty"`    // Largest accepted request body; 0 uses the default
+	StreamHeartbeat  string                    `yaml:"stream_heartbeat,omitempty"`     // Idle interval before a keep-alive comment is sent on streams, e.g. "15s"
+	SystemPrompt     *SystemPromptInjection    `yaml:"inject_system_prompt,omitempty"` // Global system prompt applied to every request
 }
 
+// SystemPromptInjection is a system prompt the gateway adds to every request
+type SystemPromptInjection struct {
+	Mode string `yaml:"mode"` // "prepend", "append" or "replace" the client's system prompt
+	Text string `yaml:"text"`
+}
+
+// defaultMaxRequestBytes bounds request bodies when max_request_bytes is not set.
+// It leaves room for base64-encoded images and documents.
+const defaultMaxRequestBytes = 32 << 20 // 32 MB
+
 // ModelConfig represents a single model configuration
 type ModelConfig struct {
-	Name        string `yaml:"name"`
-	Provider    string `yaml:"provider"` // "openai", "gemini", or "anthropic"
-	Description string `yaml:"description,omitempty"`
+	Name        string        `yaml:"name"`
+	Provider    string        `yaml:"provider"` // "openai", "gemini", or "anthropic"
+	Description string        `yaml:"description,omitempty"`
+	Split       []SplitTarget `yaml:"split,omitempty"` // Serve weighted shares of this model's requests with other models, e.g. for A/B tests
+}
+
+// SplitTarget is one arm of a model's traffic split
+type SplitTarget struct {
+	Model  string `yaml:"model"`  // A configured model or alias; may be the split model itself
+	Weight int    `yaml:"weight"` // Relative share of requests
+}
+
+// AliasConfig maps a friendly model name to a real model on a provider
+type AliasConfig struct {
+	Provider string `yaml:"provider"` // "openai", "gemini", or "anthropic"
+	Model    string `yaml:"model"`
+}
+
+// ProviderConfig holds client settings for one provider
+type ProviderConfig struct {
+	BaseURL string `yaml:"base_url,omitempty"` // Overrides the provider's *_BASE_URL environment variable
+}
+
+// APIKeyConfig names an environment variable holding one API key for a provider
+type APIKeyConfig struct {
+	Env    string `yaml:"env"`
+	Weight int    `yaml:"weight,omitempty"` // Relative share of requests; defaults to 1
 }
 
 // LoadConfig loads and parses the YAML configuration file
@@ -89,6 +133,92 @@ func ValidateConfig(cfg *ProxyConfig) error {
 		}
 	}
 
+	// Validate aliases
+	for name, alias := range cfg.Aliases {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("aliases: name cannot be empty")
+		}
+		if seen[name] {
+			return fmt.Errorf("aliases[%s]: alias shadows configured model of the same name", name)
+		}
+		if strings.TrimSpace(alias.Model) == "" {
+			return fmt.Errorf("aliases[%s]: model cannot be empty", name)
+		}
+		switch ai.Provider(alias.Provider) {
+		case ai.ProviderOpenAI, ai.ProviderGemini, ai.ProviderAnthropic:
+			// Valid provider
+		default:
+			return fmt.Errorf("aliases[%s]: unsupported provider %q (supported: openai, gemini, anthropic)", name, alias.Provider)
+		}
+	}
+
+	// Validate traffic splits
+	for _, model := range cfg.Models {
+		for i, target := range model.Split {
+			if _, isAlias := cfg.Aliases[target.Model]; !seen[target.Model] && !isAlias {
+				return fmt.Errorf("models[%s].split[%d]: model %q is not defined in models or aliases", model.Name, i, target.Model)
+			}
+			if target.Weight <= 0 {
+				return fmt.Errorf("models[%s].split[%d]: weight must be positive, got %d", model.Name, i, target.Weight)
+			}
+		}
+	}
+
+	// Validate system prompt injection
+	if sp := cfg.SystemPrompt; sp != nil {
+		switch sp.Mode {
+		case "prepend", "append", "replace":
+			// Valid mode
+		default:
+			return fmt.Errorf("inject_system_prompt: unsupported mode %q (supported: prepend, append, replace)", sp.Mode)
+		}
+		if strings.TrimSpace(sp.Text) == "" {
+			return fmt.Errorf("inject_system_prompt: text cannot be empty")
+		}
+	}
+
+	// Validate API keys
+	for provider, keys := range cfg.APIKeys {
+		switch ai.Provider(provider) {
+		case ai.ProviderOpenAI, ai.ProviderGemini, ai.ProviderAnthropic:
+			// Valid provider
+		default:
+			return fmt.Errorf("api_keys: unsupported provider %q (supported: openai, gemini, anthropic)", provider)
+		}
+		if len(keys) == 0 {
+			return fmt.Errorf("api_keys[%s]: at least one key must be configured", provider)
+		}
+		envs := make(map[string]bool)
+		for i, key := range keys {
+			if strings.TrimSpace(key.Env) == "" {
+				return fmt.Errorf("api_keys[%s][%d]: env cannot be empty", provider, i)
+			}
+			if envs[key.Env] {
+				return fmt.Errorf("api_keys[%s][%d]: duplicate env %s", provider, i, key.Env)
+			}
+			envs[key.Env] = true
+			if key.Weight < 0 {
+				return fmt.Errorf("api_keys[%s][%d]: weight cannot be negative, got %d", provider, i, key.Weight)
+			}
+		}
+	}
+
+	// Validate provider settings
+	for provider, pc := range cfg.Providers {
+		switch ai.Provider(provider) {
+		case ai.ProviderOpenAI, ai.ProviderGemini, ai.ProviderAnthropic:
+			// Valid provider
+		default:
+			return fmt.Errorf("providers: unsupported provider %q (supported: openai, gemini, anthropic)", provider)
+		}
+		if pc.BaseURL != "" {
+			u, err := url.Parse(pc.BaseURL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("providers[%s]: base_url must be an http or https URL, got %q", provider, pc.BaseURL)
+			}
+		}
+	}
+
 	// Validate default provider if specified
 	if cfg.DefaultProvider != "" {
 		provider := ai.Provider(cfg.DefaultProvider)
@@ -100,6 +230,22 @@ func ValidateConfig(cfg *ProxyConfig) error {
 		}
 	}
 
+	// Validate stream heartbeat interval
+	if cfg.StreamHeartbeat != "" {
+		interval, err := time.ParseDuration(cfg.StreamHeartbeat)
+		if err != nil {
+			return fmt.Errorf("invalid stream_heartbeat: %w", err)
+		}
+		if interval <= 0 {
+			return fmt.Errorf("stream_heartbeat must be positive, got %s", cfg.StreamHeartbeat)
+		}
+	}
+
+	// Validate request size limit
+	if cfg.MaxRequestBytes < 0 {
+		return fmt.Errorf("max_request_bytes cannot be negative, got %d", cfg.MaxRequestBytes)
+	}
+
 	// Validate default model if specified
 	if cfg.DefaultModel != "" {
 		if !seen[cfg.DefaultModel] {
@@ -127,8 +273,13 @@ func (c *ProxyConfig) GetProviderForModel(model string) (ai.Provider, error) {
 }
 
 // ResolveModel returns the resolved model name and provider.
+// Aliases are resolved first; unknown names fall through to the configured models.
 // If the requested model is unknown and a default_model is configured, the default is used.
 func (c *ProxyConfig) ResolveModel(requested string) (string, ai.Provider, error) {
+	if alias, ok := c.Aliases[requested]; ok {
+		return alias.Model, ai.Provider(alias.Provider), nil
+	}
+
 	for _, m := range c.Models {
 		if m.Name == requested {
 			return m.Name, ai.Provider(m.Provider), nil
@@ -153,6 +304,63 @@ func (c *ProxyConfig) ResolveModel(requested string) (string, ai.Provider, error
 	return "", "", fmt.Errorf("unknown model: %s", requested)
 }
 
+// SplitModel picks the model to serve a request for requested, at random by
+// weight, when requested has a traffic split. ok reports whether it has one.
+// The pick is not split again.
+func (c *ProxyConfig) SplitModel(requested string) (target string, ok bool) {
+	for _, m := range c.Models {
+		if m.Name != requested || len(m.Split) == 0 {
+			continue
+		}
+		total := 0
+		for _, t := range m.Split {
+			total += t.Weight
+		}
+		n := rand.IntN(total)
+		for _, t := range m.Split {
+			if n < t.Weight {
+				return t.Model, true
+			}
+			n -= t.Weight
+		}
+	}
+	return requested, false
+}
+
+// ApplySystemPrompt adds the configured global system prompt to req, joining it
+// to any system prompt sent by the client with a blank line
+func (c *ProxyConfig) ApplySystemPrompt(req *ai.Request) {
+	sp := c.SystemPrompt
+	if sp == nil {
+		return
+	}
+	switch {
+	case sp.Mode == "replace" || req.SystemPrompt == "":
+		req.SystemPrompt = sp.Text
+	case sp.Mode == "prepend":
+		req.SystemPrompt = sp.Text + "\n\n" + req.SystemPrompt
+	case sp.Mode == "append":
+		req.SystemPrompt = req.SystemPrompt + "\n\n" + sp.Text
+	}
+}
+
+// GetMaxRequestBytes returns the request body limit, falling back to the default
+func (c *ProxyConfig) GetMaxRequestBytes() int64 {
+	if c.MaxRequestBytes > 0 {
+		return c.MaxRequestBytes
+	}
+	return defaultMaxRequestBytes
+}
+
+// GetStreamHeartbeat returns the stream keep-alive interval, or 0 when disabled
+func (c *ProxyConfig) GetStreamHeartbeat() time.Duration {
+	interval, err := time.ParseDuration(c.StreamHeartbeat)
+	if err != nil {
+		return 0
+	}
+	return interval
+}
+
 // GetModelNames returns a list of all configured model names
 func (c *ProxyConfig) GetModelNames() []string {
 	names := make([]string, len(c.Models))
@@ -169,6 +377,9 @@ func (c *ProxyConfig) GetProviders() []ai.Provider {
 	for _, m := range c.Models {
 		providerSet[ai.Provider(m.Provider)] = true
 	}
+	for _, a := range c.Aliases {
+		providerSet[ai.Provider(a.Provider)] = true
+	}
 
 	// Add default provider if specified
 	if c.DefaultProvider != "" {
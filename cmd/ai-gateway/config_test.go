@@ -0,0 +1,175 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/liuzl/ai"
+)
+
+// loadTestConfig writes yaml to a temporary file and loads it with LoadConfig.
+func loadTestConfig(t *testing.T, yaml string) (*ProxyConfig, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(yaml), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return LoadConfig(path)
+}
+
+func TestAliasConfig(t *testing.T) {
+	cfg, err := loadTestConfig(t, `
+version: "1.0"
+models:
+  - name: gpt-test
+    provider: openai
+aliases:
+  gpt-smart:
+    provider: anthropic
+    model: claude-test
+`)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	model, provider, err := cfg.ResolveModel("gpt-smart")
+	if err != nil || model != "claude-test" || provider != ai.ProviderAnthropic {
+		t.Errorf("expected the alias to resolve to anthropic/claude-test, got %s/%s, %v", provider, model, err)
+	}
+	if model, provider, err := cfg.ResolveModel("gpt-test"); err != nil || model != "gpt-test" || provider != ai.ProviderOpenAI {
+		t.Errorf("expected a configured model to resolve to itself, got %s/%s, %v", provider, model, err)
+	}
+	if _, _, err := cfg.ResolveModel("gpt-unknown"); err == nil {
+		t.Error("expected an unknown name to fall through to the unknown model error")
+	}
+
+	for name, yaml := range map[string]string{
+		"shadows a model": "aliases: {gpt-test: {provider: openai, model: gpt-4o}}",
+		"empty model":     "aliases: {gpt-smart: {provider: openai, model: \"\"}}",
+		"bad provider":    "aliases: {gpt-smart: {provider: cohere, model: command}}",
+	} {
+		_, err := loadTestConfig(t, "version: \"1.0\"\nmodels: [{name: gpt-test, provider: openai}]\n"+yaml)
+		if err == nil || !strings.Contains(err.Error(), "aliases[") {
+			t.Errorf("%s: expected an alias validation error, got %v", name, err)
+		}
+	}
+}
+
+func TestSplitModel(t *testing.T) {
+	cfg := &ProxyConfig{Models: []ModelConfig{
+		{Name: "gpt-test", Provider: "openai", Split: []SplitTarget{{Model: "gpt-test", Weight: 3}, {Model: "gpt-b", Weight: 1}}},
+		{Name: "gpt-b", Provider: "openai"},
+	}}
+
+	counts := map[string]int{}
+	for range 4000 {
+		target, ok := cfg.SplitModel("gpt-test")
+		if !ok {
+			t.Fatal("expected gpt-test to be split")
+		}
+		counts[target]++
+	}
+	if len(counts) != 2 || counts["gpt-b"] < 800 || counts["gpt-b"] > 1200 {
+		t.Errorf("expected about a quarter of requests to go to gpt-b, got %v", counts)
+	}
+
+	if target, ok := cfg.SplitModel("gpt-b"); ok || target != "gpt-b" {
+		t.Errorf("expected a model without a split to be served as is, got %q, %v", target, ok)
+	}
+}
+
+func TestSplitValidation(t *testing.T) {
+	for name, split := range map[string]string{
+		"unknown model": "[{model: gpt-missing, weight: 1}]",
+		"zero weight":   "[{model: gpt-test, weight: 0}]",
+	} {
+		_, err := loadTestConfig(t, "version: \"1.0\"\nmodels: [{name: gpt-test, provider: openai, split: "+split+"}]\n")
+		if err == nil || !strings.Contains(err.Error(), "models[gpt-test].split[0]") {
+			t.Errorf("%s: expected a split validation error, got %v", name, err)
+		}
+	}
+
+	// Splits may point at aliases.
+	if _, err := loadTestConfig(t, `
+version: "1.0"
+models: [{name: gpt-test, provider: openai, split: [{model: gpt-smart, weight: 1}]}]
+aliases: {gpt-smart: {provider: openai, model: gpt-4o}}
+`); err != nil {
+		t.Errorf("expected a split to an alias to be valid, got %v", err)
+	}
+}
+
+func TestApplySystemPrompt(t *testing.T) {
+	tests := []struct {
+		mode, client, want string
+	}{
+		{"prepend", "Be brief.", "Gateway rules.\n\nBe brief."},
+		{"append", "Be brief.", "Be brief.\n\nGateway rules."},
+		{"replace", "Be brief.", "Gateway rules."},
+		{"prepend", "", "Gateway rules."},
+		{"append", "", "Gateway rules."},
+	}
+	for _, tt := range tests {
+		cfg := &ProxyConfig{SystemPrompt: &SystemPromptInjection{Mode: tt.mode, Text: "Gateway rules."}}
+		req := &ai.Request{SystemPrompt: tt.client}
+		cfg.ApplySystemPrompt(req)
+		if req.SystemPrompt != tt.want {
+			t.Errorf("%s with client prompt %q: got %q, want %q", tt.mode, tt.client, req.SystemPrompt, tt.want)
+		}
+	}
+
+	req := &ai.Request{SystemPrompt: "Be brief."}
+	(&ProxyConfig{}).ApplySystemPrompt(req)
+	if req.SystemPrompt != "Be brief." {
+		t.Errorf("expected the client prompt to be kept without injection, got %q", req.SystemPrompt)
+	}
+
+	for name, yaml := range map[string]string{
+		"bad mode":   "inject_system_prompt: {mode: insert, text: hi}",
+		"empty text": "inject_system_prompt: {mode: prepend, text: \" \"}",
+	} {
+		_, err := loadTestConfig(t, "version: \"1.0\"\nmodels: [{name: gpt-test, provider: openai}]\n"+yaml)
+		if err == nil || !strings.Contains(err.Error(), "inject_system_prompt") {
+			t.Errorf("%s: expected a system prompt validation error, got %v", name, err)
+		}
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	valid := func() *ProxyConfig {
+		cfg := testConfig()
+		cfg.Providers = map[string]ProviderConfig{"openai": {BaseURL: "https://llm.example.com/v1"}}
+		return cfg
+	}
+	if err := ValidateConfig(valid()); err != nil {
+		t.Fatalf("expected a valid config, got %v", err)
+	}
+
+	tests := map[string]struct {
+		modify func(*ProxyConfig)
+		want   string
+	}{
+		"no version":           {func(c *ProxyConfig) { c.Version = "" }, "version"},
+		"no models":            {func(c *ProxyConfig) { c.Models = nil }, "model"},
+		"unsupported provider": {func(c *ProxyConfig) { c.Models[0].Provider = "cohere" }, "unsupported provider"},
+		"duplicate model":      {func(c *ProxyConfig) { c.Models = append(c.Models, c.Models[0]) }, "duplicate"},
+		"base_url provider": {func(c *ProxyConfig) {
+			c.Providers["cohere"] = ProviderConfig{BaseURL: "https://llm.example.com"}
+		}, "providers: unsupported provider"},
+		"base_url scheme": {func(c *ProxyConfig) {
+			c.Providers["openai"] = ProviderConfig{BaseURL: "ftp://llm.example.com"}
+		}, "providers[openai]: base_url"},
+		"base_url host": {func(c *ProxyConfig) {
+			c.Providers["openai"] = ProviderConfig{BaseURL: "https://"}
+		}, "providers[openai]: base_url"},
+	}
+	for name, tt := range tests {
+		cfg := valid()
+		tt.modify(cfg)
+		if err := ValidateConfig(cfg); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected an error mentioning %q, got %v", name, tt.want, err)
+		}
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
@@ -28,11 +30,63 @@ func (s *ProxyServer) handleGemini(w http.ResponseWriter, r *http.Request) {
 	s.handleRequest(w, r, ai.ProviderGemini)
 }
 
+// modelListResponse is the OpenAI-compatible body of the /v1/models endpoint
+type modelListResponse struct {
+	Object string           `json:"object"`
+	Data   []modelListEntry `json:"data"`
+}
+
+// modelListEntry describes one configured model. OwnedBy and Provider both
+// carry the configured provider so generic OpenAI clients can display it.
+type modelListEntry struct {
+	ID          string `json:"id"`
+	Object      string `json:"object"`
+	Created     int64  `json:"created"`
+	OwnedBy     string `json:"owned_by"`
+	Provider    string `json:"provider"`
+	Description string `json:"description,omitempty"`
+}
+
+// handleModels lists the configured models in OpenAI's /v1/models format
+func (s *ProxyServer) handleModels(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.handleError(w, r, ai.ProviderOpenAI, "", "", fmt.Errorf("method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	config := s.current().config
+	resp := modelListResponse{Object: "list", Data: make([]modelListEntry, 0, len(config.Models))}
+	for _, m := range config.Models {
+		resp.Data = append(resp.Data, modelListEntry{
+			ID:          m.Name,
+			Object:      "model",
+			OwnedBy:     m.Provider,
+			Provider:    m.Provider,
+			Description: m.Description,
+		})
+	}
+	for _, name := range slices.Sorted(maps.Keys(config.Aliases)) {
+		alias := config.Aliases[name]
+		resp.Data = append(resp.Data, modelListEntry{
+			ID:          name,
+			Object:      "model",
+			OwnedBy:     alias.Provider,
+			Provider:    alias.Provider,
+			Description: "Alias for " + alias.Model,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
 // handleRequest is the core request handling logic
 func (s *ProxyServer) handleRequest(w http.ResponseWriter, r *http.Request, format ai.Provider) {
 	// Get request context
 	requestID := GetRequestID(r.Context())
 	startTime := time.Now()
+	// Use one configuration for the whole request, even if it is reloaded meanwhile
+	state := s.current()
 
 	// Only accept POST requests
 	if r.Method != http.MethodPost {
@@ -47,9 +101,17 @@ func (s *ProxyServer) handleRequest(w http.ResponseWriter, r *http.Request, form
 		return
 	}
 
+	// Bound the body so oversized requests cannot exhaust memory
+	r.Body = http.MaxBytesReader(w, r.Body, state.config.GetMaxRequestBytes())
+
 	// Decode provider-specific request
 	providerReq, err := converter.DecodeRequest(r)
 	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			s.handleError(w, r, format, "", "", fmt.Errorf("request body too large: limit is %d bytes", maxBytesErr.Limit), http.StatusRequestEntityTooLarge)
+			return
+		}
 		s.handleError(w, r, format, "", "", fmt.Errorf("failed to decode request: %w", err), http.StatusBadRequest)
 		return
 	}
@@ -61,21 +123,26 @@ func (s *ProxyServer) handleRequest(w http.ResponseWriter, r *http.Request, form
 		return
 	}
 
+	// Fall back to the model named in the URL when the body has none
 	requestedModel := universalReq.Model
-
-	// Special handling for Gemini: extract model from URL if not in request body
-	if format == ai.ProviderGemini && requestedModel == "" {
-		requestedModel = extractGeminiModelFromURL(r.URL.Path)
-		if requestedModel == "" {
+	if requestedModel == "" {
+		requestedModel = extractModelFromURL(r, format)
+		if requestedModel == "" && format == ai.ProviderGemini {
 			s.handleError(w, r, format, "", "", fmt.Errorf("failed to extract model from URL: %s", r.URL.Path), http.StatusBadRequest)
 			return
 		}
-		// Update the universal request with the extracted model
 		universalReq.Model = requestedModel
 	}
 
+	// Serve a weighted share of the model's traffic with another model when it has a split
+	if target, ok := state.config.SplitModel(universalReq.Model); ok {
+		s.metrics.RecordSplit(universalReq.Model, target)
+		w.Header().Set("X-Split-Target", target)
+		universalReq.Model = target
+	}
+
 	// Resolve model/provider (fallback to default model if configured)
-	model, provider, err := s.config.ResolveModel(universalReq.Model)
+	model, provider, err := state.config.ResolveModel(universalReq.Model)
 	if err != nil {
 		s.handleError(w, r, format, requestedModel, "", err, http.StatusBadRequest)
 		return
@@ -83,12 +150,15 @@ func (s *ProxyServer) handleRequest(w http.ResponseWriter, r *http.Request, form
 	// Ensure downstream uses resolved model
 	universalReq.Model = model
 
+	// Apply the gateway-wide system prompt, if configured
+	state.config.ApplySystemPrompt(universalReq)
+
 	// Increment active requests
 	s.metrics.IncActiveRequests(string(format), string(provider))
 	defer s.metrics.DecActiveRequests(string(format), string(provider))
 
 	// Get client from pool
-	client, err := s.clientPool.GetClient(provider)
+	client, err := state.clientPool.GetClient(provider)
 	if err != nil {
 		s.handleError(w, r, format, model, string(provider), err, http.StatusInternalServerError)
 		return
@@ -96,14 +166,14 @@ func (s *ProxyServer) handleRequest(w http.ResponseWriter, r *http.Request, form
 
 	// Check if streaming
 	if converter.IsStreaming(providerReq) {
-		s.handleStream(w, r, format, model, string(provider), converter, providerReq, client)
+		s.handleStream(w, r, format, model, string(provider), converter, universalReq, client, state.config.GetStreamHeartbeat())
 		return
 	}
 
 	// Call backend (non-streaming)
 	universalResp, err := client.Generate(r.Context(), universalReq)
 	if err != nil {
-		s.handleError(w, r, format, model, string(provider), err, http.StatusInternalServerError)
+		s.handleError(w, r, format, model, string(provider), err, backendErrorStatus(err))
 		return
 	}
 
@@ -150,38 +220,25 @@ func (s *ProxyServer) handleStream(
 	model string,
 	provider string,
 	converter ai.FormatConverter,
-	providerReq any,
+	universalReq *ai.Request,
 	client ai.Client,
+	interval time.Duration, // Keep-alive interval from the request's configuration; 0 disables
 ) {
 	requestID := GetRequestID(r.Context())
 	startTime := time.Now()
 
-	// Get streaming client
-	streamingClient, ok := client.(ai.StreamingClient)
-	if !ok {
+	// Check streaming support
+	if !ai.CanStream(client) {
 		s.handleError(w, r, format, model, provider,
-			fmt.Errorf("provider %s does not support streaming", provider),
+			&ai.StreamingUnsupportedError{Provider: provider},
 			http.StatusNotImplemented)
 		return
 	}
 
-	// Convert to Universal format
-	universalReq, err := converter.ConvertRequestFromFormat(providerReq)
-	if err != nil {
-		s.handleError(w, r, format, model, provider,
-			fmt.Errorf("failed to convert request: %w", err),
-			http.StatusBadRequest)
-		return
-	}
-	// Ensure resolved model is used downstream (important for default_model fallback)
-	if model != "" {
-		universalReq.Model = model
-	}
-
 	// Start streaming
-	streamReader, err := streamingClient.Stream(r.Context(), universalReq)
+	streamReader, err := ai.Stream(r.Context(), client, universalReq)
 	if err != nil {
-		s.handleError(w, r, format, model, provider, err, http.StatusInternalServerError)
+		s.handleError(w, r, format, model, provider, err, backendErrorStatus(err))
 		return
 	}
 	defer streamReader.Close()
@@ -206,9 +263,77 @@ func (s *ProxyServer) handleStream(
 	// Start streaming
 	streamHandler.OnStart(w, flusher)
 
+	// Receive in the background so idle periods can be filled with heartbeats.
+	// Only this goroutine writes to w.
+	type recvResult struct {
+		chunk *ai.StreamChunk
+		err   error
+	}
+	results := make(chan recvResult)
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	defer func() {
+		// Closing the stream unblocks a pending Recv; wait for the receiver
+		// so it never outlives the request
+		close(done)
+		streamReader.Close()
+		<-exited
+	}()
+	go func() {
+		defer close(exited)
+		for {
+			chunk, err := streamReader.Recv()
+			select {
+			case results <- recvResult{chunk, err}:
+			case <-done:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	// A nil channel never fires, which disables heartbeats
+	var heartbeat <-chan time.Time
+	var ticker *time.Ticker
+	if interval > 0 {
+		ticker = time.NewTicker(interval)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+
 	// Read and forward chunks
 	for {
-		chunk, err := streamReader.Recv()
+		var chunk *ai.StreamChunk
+		var err error
+		select {
+		case <-heartbeat:
+			// SSE comment lines are ignored by clients but keep the connection active
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+			continue
+		case res := <-results:
+			chunk, err = res.chunk, res.err
+			if ticker != nil {
+				// Heartbeats are only needed while the stream is idle
+				ticker.Reset(interval)
+			}
+		case <-r.Context().Done():
+			// The client disconnected. Returning closes streamReader, and the
+			// canceled context has already aborted the upstream request, so
+			// the provider stops generating for nobody.
+			duration := time.Since(startTime)
+			s.metrics.RecordRequest(string(format), model, provider, "canceled", duration)
+			rest.Log().Info().
+				Str("request_id", requestID).
+				Dur("duration", duration).
+				Str("format", string(format)).
+				Str("model", model).
+				Str("provider", provider).
+				Msg("client disconnected, streaming request canceled")
+			return
+		}
 		if err != nil {
 			if errors.Is(err, io.EOF) {
 				streamHandler.OnEnd(w, flusher)
@@ -285,19 +410,35 @@ func (s *ProxyServer) handleError(
 		Str("error_type", errorType).
 		Msg("request failed")
 
-	// Write error response
+	// Write the error in the requested format's native shape so its SDKs can
+	// parse it; the request ID is in the X-Request-ID header.
+	var errorResponse any
+	if converter, convErr := s.converterFactory.GetConverter(format); convErr == nil {
+		errorResponse = converter.ConvertErrorToFormat(statusCode, err.Error())
+	} else {
+		errorResponse = map[string]any{
+			"error": map[string]any{
+				"message":    err.Error(),
+				"type":       errorType,
+				"request_id": requestID,
+			},
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(errorResponse)
+}
 
-	errorResponse := map[string]any{
-		"error": map[string]any{
-			"message":    err.Error(),
-			"type":       errorType,
-			"request_id": requestID,
-		},
+// backendErrorStatus returns the status code for an error from a provider
+// client: 400 for content the provider does not accept, which the client must
+// change, and 500 otherwise
+func backendErrorStatus(err error) int {
+	var contentErr *ai.UnsupportedContentError
+	if errors.As(err, &contentErr) {
+		return http.StatusBadRequest
 	}
-
-	json.NewEncoder(w).Encode(errorResponse)
+	return http.StatusInternalServerError
 }
 
 // getErrorType maps errors to error types for metrics
@@ -306,6 +447,12 @@ func getErrorType(err error) string {
 		return "unknown"
 	}
 
+	// Adapter errors arrive wrapped, so they are matched with errors.As.
+	var contentErr *ai.UnsupportedContentError
+	if errors.As(err, &contentErr) {
+		return "unsupported_content"
+	}
+
 	switch err.(type) {
 	case *ai.AuthenticationError:
 		return "auth"
@@ -319,6 +466,10 @@ func getErrorType(err error) string {
 		return "network"
 	case *ai.ServerError:
 		return "server_error"
+	case *ai.UnexpectedResponseError:
+		return "unexpected_response"
+	case *ai.StreamingUnsupportedError:
+		return "streaming_unsupported"
 	default:
 		errStr := err.Error()
 		if strings.Contains(errStr, "unknown model") {
@@ -327,6 +478,9 @@ func getErrorType(err error) string {
 		if strings.Contains(errStr, "failed to decode") {
 			return "decode_error"
 		}
+		if strings.Contains(errStr, "request body too large") {
+			return "request_too_large"
+		}
 		if strings.Contains(errStr, "failed to convert") {
 			return "conversion_error"
 		}
@@ -334,6 +488,18 @@ func getErrorType(err error) string {
 	}
 }
 
+// extractModelFromURL returns the model named in the request URL: the Gemini
+// path segment, or else a ?model= query parameter, which some clients send
+// instead of a body field. It returns "" when the URL names no model.
+func extractModelFromURL(r *http.Request, format ai.Provider) string {
+	if format == ai.ProviderGemini {
+		if model := extractGeminiModelFromURL(r.URL.Path); model != "" {
+			return model
+		}
+	}
+	return r.URL.Query().Get("model")
+}
+
 // extractGeminiModelFromURL extracts the model name from Gemini URL path
 // Example: /gemini/v1/models/gemini-2.0-flash:generateContent → gemini-2.0-flash
 // Example: /gemini/v1beta/models/gemini-1.5-pro:streamGenerateContent → gemini-1.5-pro
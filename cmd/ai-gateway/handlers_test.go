@@ -0,0 +1,439 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/liuzl/ai"
+)
+
+// testMetrics is shared by every test server: the collector registers its
+// metrics globally, so it can only be created once per process.
+var testMetrics = NewMetricsCollector()
+
+// newTestServer returns a server for cfg whose providers all call backendURL
+// with a test API key.
+func newTestServer(t *testing.T, cfg *ProxyConfig, backendURL string) *ProxyServer {
+	t.Helper()
+	for _, p := range []string{"OPENAI", "GEMINI", "ANTHROPIC"} {
+		t.Setenv(p+"_API_KEY", "test-key")
+		t.Setenv(p+"_BASE_URL", backendURL)
+	}
+	if err := ValidateConfig(cfg); err != nil {
+		t.Fatalf("invalid test config: %v", err)
+	}
+
+	s := &ProxyServer{
+		serverCfg:        &ServerConfig{},
+		converterFactory: &ai.FormatConverterFactory{},
+		metrics:          testMetrics,
+	}
+	state, err := s.newRoutingState(cfg)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	s.state.Store(state)
+	t.Cleanup(func() { s.current().clientPool.Close() })
+	return s
+}
+
+// serve starts an HTTP server with s's routes and middleware.
+func serve(t *testing.T, s *ProxyServer) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(s.applyMiddleware(s.setupRoutes()))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// mockBackend is an OpenAI-compatible provider that answers every chat
+// completion with "ok", streamed when asked, and records the request bodies.
+type mockBackend struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	bodies []map[string]any
+}
+
+func newMockBackend(t *testing.T) *mockBackend {
+	t.Helper()
+	b := &mockBackend{}
+	b.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		b.mu.Lock()
+		b.bodies = append(b.bodies, body)
+		b.mu.Unlock()
+
+		if body["stream"] == true {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"ok\"},\"finish_reason\":\"stop\"}]}\n\n")
+			fmt.Fprint(w, "data: [DONE]\n\n")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`)
+	}))
+	t.Cleanup(b.Close)
+	return b
+}
+
+// last returns the body of the most recent request, or nil if there was none.
+func (b *mockBackend) last() map[string]any {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.bodies) == 0 {
+		return nil
+	}
+	return b.bodies[len(b.bodies)-1]
+}
+
+// post sends body to url and returns the response with its body read.
+func post(t *testing.T, url, body string) (*http.Response, []byte) {
+	t.Helper()
+	resp, err := http.Post(url, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST %s failed: %v", url, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read response: %v", err)
+	}
+	return resp, data
+}
+
+// testConfig returns a minimal valid configuration with one OpenAI model.
+func testConfig() *ProxyConfig {
+	return &ProxyConfig{
+		Version: "1.0",
+		Models:  []ModelConfig{{Name: "gpt-test", Provider: "openai", Description: "Test model"}},
+	}
+}
+
+func TestModelsEndpoint(t *testing.T) {
+	cfg := testConfig()
+	cfg.Models = append(cfg.Models, ModelConfig{Name: "claude-test", Provider: "anthropic"})
+	server := serve(t, newTestServer(t, cfg, newMockBackend(t).URL))
+
+	for _, path := range []string{"/v1/models", "/openai/v1/models"} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		var list modelListResponse
+		json.NewDecoder(resp.Body).Decode(&list)
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK || list.Object != "list" || len(list.Data) != 2 {
+			t.Fatalf("%s: expected a list of the 2 configured models, got %d %+v", path, resp.StatusCode, list)
+		}
+		if m := list.Data[0]; m.ID != "gpt-test" || m.Object != "model" || m.Provider != "openai" || m.OwnedBy != "openai" || m.Description != "Test model" {
+			t.Errorf("%s: unexpected entry for gpt-test: %+v", path, m)
+		}
+		if m := list.Data[1]; m.ID != "claude-test" || m.Provider != "anthropic" {
+			t.Errorf("%s: unexpected entry for claude-test: %+v", path, m)
+		}
+	}
+
+	resp, err := http.Post(server.URL+"/v1/models", "application/json", nil)
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for POST, got %d", resp.StatusCode)
+	}
+}
+
+func TestRequestBodyLimit(t *testing.T) {
+	cfg := testConfig()
+	cfg.MaxRequestBytes = 256
+	backend := newMockBackend(t)
+	server := serve(t, newTestServer(t, cfg, backend.URL))
+
+	large := fmt.Sprintf(`{"model":"gpt-test","messages":[{"role":"user","content":%q}]}`, strings.Repeat("x", 1024))
+	resp, body := post(t, server.URL+"/openai/v1/chat/completions", large)
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 for a body over the limit, got %d: %s", resp.StatusCode, body)
+	}
+	if backend.last() != nil {
+		t.Error("expected the oversized request not to reach the backend")
+	}
+
+	small := `{"model":"gpt-test","messages":[{"role":"user","content":"hi"}]}`
+	if resp, body := post(t, server.URL+"/openai/v1/chat/completions", small); resp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200 for a body within the limit, got %d: %s", resp.StatusCode, body)
+	}
+}
+
+func TestStreamHeartbeat(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"slow\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		time.Sleep(300 * time.Millisecond) // A provider pausing mid-stream
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\" reply\"},\"finish_reason\":\"stop\"}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer backend.Close()
+
+	cfg := testConfig()
+	cfg.StreamHeartbeat = "50ms"
+	server := serve(t, newTestServer(t, cfg, backend.URL))
+
+	resp, body := post(t, server.URL+"/openai/v1/chat/completions", `{"model":"gpt-test","stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", resp.StatusCode, body)
+	}
+	stream := string(body)
+	first, heartbeat, second := strings.Index(stream, "slow"), strings.Index(stream, ": keep-alive\n\n"), strings.Index(stream, " reply")
+	if first < 0 || second < 0 {
+		t.Fatalf("expected both chunks in the stream, got %q", stream)
+	}
+	if heartbeat < first || heartbeat > second {
+		t.Errorf("expected a keep-alive comment during the pause, got %q", stream)
+	}
+	if strings.Contains(stream[second:], ": keep-alive") {
+		t.Errorf("expected no keep-alive after the last chunk, got %q", stream)
+	}
+
+	// Without stream_heartbeat, none are sent.
+	server = serve(t, newTestServer(t, testConfig(), backend.URL))
+	_, body = post(t, server.URL+"/openai/v1/chat/completions", `{"model":"gpt-test","stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+	if strings.Contains(string(body), ": keep-alive") {
+		t.Errorf("expected no keep-alive comments when disabled, got %q", body)
+	}
+}
+
+func TestAliasRequest(t *testing.T) {
+	cfg := testConfig()
+	cfg.Aliases = map[string]AliasConfig{"gpt-smart": {Provider: "openai", Model: "gpt-real"}}
+	backend := newMockBackend(t)
+	server := serve(t, newTestServer(t, cfg, backend.URL))
+
+	for _, stream := range []bool{false, true} {
+		resp, body := post(t, server.URL+"/openai/v1/chat/completions",
+			fmt.Sprintf(`{"model":"gpt-smart","stream":%v,"messages":[{"role":"user","content":"hi"}]}`, stream))
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("stream %v: expected 200, got %d: %s", stream, resp.StatusCode, body)
+		}
+		if got := backend.last()["model"]; got != "gpt-real" {
+			t.Errorf("stream %v: expected the backend to get the aliased model, got %v", stream, got)
+		}
+	}
+}
+
+func TestModelFromQuery(t *testing.T) {
+	cfg := testConfig()
+	cfg.Models = append(cfg.Models, ModelConfig{Name: "gpt-other", Provider: "openai"})
+	backend := newMockBackend(t)
+	server := serve(t, newTestServer(t, cfg, backend.URL))
+
+	resp, body := post(t, server.URL+"/openai/v1/chat/completions?model=gpt-test", `{"messages":[{"role":"user","content":"hi"}]}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", resp.StatusCode, body)
+	}
+	if got := backend.last()["model"]; got != "gpt-test" {
+		t.Errorf("expected the model from the query, got %v", got)
+	}
+
+	// A model in the body takes precedence over the query.
+	post(t, server.URL+"/openai/v1/chat/completions?model=gpt-test", `{"model":"gpt-other","messages":[{"role":"user","content":"hi"}]}`)
+	if got := backend.last()["model"]; got != "gpt-other" {
+		t.Errorf("expected the model from the body, got %v", got)
+	}
+}
+
+func TestExtractModelFromURL(t *testing.T) {
+	tests := []struct {
+		url    string
+		format ai.Provider
+		want   string
+	}{
+		{"/openai/v1/chat/completions?model=gpt-4o", ai.ProviderOpenAI, "gpt-4o"},
+		{"/anthropic/v1/messages?model=claude-3", ai.ProviderAnthropic, "claude-3"},
+		{"/openai/v1/chat/completions", ai.ProviderOpenAI, ""},
+		{"/gemini/v1beta/models/gemini-pro:generateContent?model=other", ai.ProviderGemini, "gemini-pro"},
+		{"/gemini/v1beta/generateContent?model=gemini-flash", ai.ProviderGemini, "gemini-flash"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, tt.url, nil)
+		if got := extractModelFromURL(r, tt.format); got != tt.want {
+			t.Errorf("extractModelFromURL(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestErrorFormat(t *testing.T) {
+	server := serve(t, newTestServer(t, testConfig(), newMockBackend(t).URL))
+
+	resp, body := post(t, server.URL+"/anthropic/v1/messages", `{"model":"claude-unknown","max_tokens":10,"messages":[{"role":"user","content":"hi"}]}`)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown model, got %d: %s", resp.StatusCode, body)
+	}
+	var anthropicErr ai.AnthropicErrorResponse
+	if err := json.Unmarshal(body, &anthropicErr); err != nil {
+		t.Fatalf("failed to decode error: %v", err)
+	}
+	if anthropicErr.Type != "error" || anthropicErr.Error.Type != "invalid_request_error" || !strings.Contains(anthropicErr.Error.Message, "unknown model") {
+		t.Errorf("expected an Anthropic invalid_request_error, got %s", body)
+	}
+
+	resp, body = post(t, server.URL+"/openai/v1/chat/completions", `{"model":"gpt-unknown","messages":[{"role":"user","content":"hi"}]}`)
+	var openaiErr ai.OpenAIErrorResponse
+	if err := json.Unmarshal(body, &openaiErr); err != nil {
+		t.Fatalf("failed to decode error: %v", err)
+	}
+	if resp.StatusCode != http.StatusBadRequest || openaiErr.Error.Type != "invalid_request_error" || !strings.Contains(openaiErr.Error.Message, "unknown model") {
+		t.Errorf("expected an OpenAI invalid_request_error, got %d %s", resp.StatusCode, body)
+	}
+}
+
+func TestSplitRequest(t *testing.T) {
+	cfg := testConfig()
+	cfg.Models[0].Split = []SplitTarget{{Model: "gpt-b", Weight: 1}}
+	cfg.Models = append(cfg.Models, ModelConfig{Name: "gpt-b", Provider: "openai"})
+	backend := newMockBackend(t)
+	server := serve(t, newTestServer(t, cfg, backend.URL))
+
+	for _, stream := range []bool{false, true} {
+		resp, body := post(t, server.URL+"/openai/v1/chat/completions",
+			fmt.Sprintf(`{"model":"gpt-test","stream":%v,"messages":[{"role":"user","content":"hi"}]}`, stream))
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("stream %v: expected 200, got %d: %s", stream, resp.StatusCode, body)
+		}
+		if got := resp.Header.Get("X-Split-Target"); got != "gpt-b" {
+			t.Errorf("stream %v: expected X-Split-Target gpt-b, got %q", stream, got)
+		}
+		if got := backend.last()["model"]; got != "gpt-b" {
+			t.Errorf("stream %v: expected the backend to get the split target, got %v", stream, got)
+		}
+	}
+
+	resp, _ := post(t, server.URL+"/openai/v1/chat/completions", `{"model":"gpt-b","messages":[{"role":"user","content":"hi"}]}`)
+	if got := resp.Header.Get("X-Split-Target"); got != "" {
+		t.Errorf("expected no X-Split-Target for a model without a split, got %q", got)
+	}
+}
+
+func TestSystemPromptInjection(t *testing.T) {
+	cfg := testConfig()
+	cfg.SystemPrompt = &SystemPromptInjection{Mode: "prepend", Text: "Gateway rules."}
+	backend := newMockBackend(t)
+	server := serve(t, newTestServer(t, cfg, backend.URL))
+
+	for _, stream := range []bool{false, true} {
+		resp, body := post(t, server.URL+"/openai/v1/chat/completions", fmt.Sprintf(`{"model":"gpt-test","stream":%v,"messages":[
+			{"role":"system","content":"Be brief."},{"role":"user","content":"hi"}]}`, stream))
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("stream %v: expected 200, got %d: %s", stream, resp.StatusCode, body)
+		}
+		messages, _ := backend.last()["messages"].([]any)
+		if len(messages) == 0 {
+			t.Fatalf("stream %v: expected messages in the backend request, got %v", stream, backend.last())
+		}
+		system, _ := messages[0].(map[string]any)
+		if system["role"] != "system" || system["content"] != "Gateway rules.\n\nBe brief." {
+			t.Errorf("stream %v: expected the injected system prompt first, got %v", stream, messages[0])
+		}
+	}
+}
+
+func TestProviderBaseURL(t *testing.T) {
+	fromEnv, fromConfig := newMockBackend(t), newMockBackend(t)
+	cfg := testConfig()
+	cfg.Providers = map[string]ProviderConfig{"openai": {BaseURL: fromConfig.URL}}
+	server := serve(t, newTestServer(t, cfg, fromEnv.URL))
+
+	if resp, body := post(t, server.URL+"/openai/v1/chat/completions", `{"model":"gpt-test","messages":[{"role":"user","content":"hi"}]}`); resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", resp.StatusCode, body)
+	}
+	if fromConfig.last() == nil || fromEnv.last() != nil {
+		t.Error("expected providers.openai.base_url to take precedence over OPENAI_BASE_URL")
+	}
+}
+
+// streamingFakeClient is a fakeClient whose streams fail to open with err.
+type streamingFakeClient struct {
+	fakeClient
+}
+
+func (c *streamingFakeClient) Stream(ctx context.Context, req *ai.Request) (ai.StreamReader, error) {
+	return nil, c.err
+}
+
+func TestUnsupportedContentStatus(t *testing.T) {
+	s := newTestServer(t, testConfig(), newMockBackend(t).URL)
+	contentErr := ai.NewUnsupportedContentError(ai.ProviderOpenAI, ai.ContentTypeAudio)
+	s.current().clientPool.clients["openai"] = &streamingFakeClient{fakeClient{err: contentErr}}
+	server := serve(t, s)
+
+	for _, stream := range []bool{false, true} {
+		resp, body := post(t, server.URL+"/openai/v1/chat/completions",
+			fmt.Sprintf(`{"model":"gpt-test","stream":%v,"messages":[{"role":"user","content":"hi"}]}`, stream))
+		var errResp ai.OpenAIErrorResponse
+		json.Unmarshal(body, &errResp)
+		if resp.StatusCode != http.StatusBadRequest || errResp.Error.Type != "invalid_request_error" {
+			t.Errorf("stream %v: expected a 400 invalid_request_error, got %d %s", stream, resp.StatusCode, body)
+		}
+	}
+
+	if got := getErrorType(fmt.Errorf("generate: %w", contentErr)); got != "unsupported_content" {
+		t.Errorf("expected wrapped errors to be typed unsupported_content, got %q", got)
+	}
+	if got := backendErrorStatus(ai.NewServerError("openai", 502, "bad gateway", nil)); got != http.StatusInternalServerError {
+		t.Errorf("expected 500 for other backend errors, got %d", got)
+	}
+}
+
+func TestStreamClientDisconnect(t *testing.T) {
+	upstreamCanceled := make(chan struct{})
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"first\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done() // Keep generating until the gateway goes away
+		close(upstreamCanceled)
+	}))
+	defer backend.Close()
+	server := serve(t, newTestServer(t, testConfig(), backend.URL))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL+"/openai/v1/chat/completions",
+		strings.NewReader(`{"model":"gpt-test","stream":true,"messages":[{"role":"user","content":"hi"}]}`))
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// Wait for the first chunk, then disconnect.
+	buf := make([]byte, 512)
+	var stream string
+	for !strings.Contains(stream, "first") {
+		n, err := resp.Body.Read(buf)
+		if err != nil {
+			t.Fatalf("failed to read the first chunk: %v", err)
+		}
+		stream += string(buf[:n])
+	}
+	cancel()
+
+	select {
+	case <-upstreamCanceled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the upstream request to be canceled when the client disconnected")
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/liuzl/ai"
+)
+
+// keyedClient is a provider client bound to one API key
+type keyedClient struct {
+	name    string // Environment variable holding the key; used as the metrics label
+	client  ai.Client
+	weight  int
+	current int // Smooth weighted round-robin state
+}
+
+// balancedClient spreads requests for one provider across several API keys
+// using smooth weighted round-robin, moving on to another key when one is rate limited.
+// It implements ai.Client, ai.StreamingClient and io.Closer.
+type balancedClient struct {
+	provider ai.Provider
+	metrics  *MetricsCollector // May be nil
+
+	mu   sync.Mutex
+	keys []*keyedClient
+}
+
+func newBalancedClient(provider ai.Provider, keys []*keyedClient, metrics *MetricsCollector) *balancedClient {
+	return &balancedClient{provider: provider, keys: keys, metrics: metrics}
+}
+
+// next picks the next key not in tried, or nil when all have been tried.
+// Only the keys still in the running take part in the round, so keys skipped
+// on failover do not build up weight.
+func (b *balancedClient) next(tried map[*keyedClient]bool) *keyedClient {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var best *keyedClient
+	total := 0
+	for _, k := range b.keys {
+		if tried[k] {
+			continue
+		}
+		k.current += k.weight
+		total += k.weight
+		if best == nil || k.current > best.current {
+			best = k
+		}
+	}
+	if best != nil {
+		best.current -= total
+	}
+	return best
+}
+
+// do runs call with successive keys until one is not rate limited
+func (b *balancedClient) do(call func(ai.Client) error) error {
+	tried := make(map[*keyedClient]bool, len(b.keys))
+	var err error
+	for k := b.next(tried); k != nil; k = b.next(tried) {
+		tried[k] = true
+		err = call(k.client)
+
+		var rateLimitErr *ai.RateLimitError
+		switch {
+		case err == nil:
+			b.record(k, "success")
+			return nil
+		case errors.As(err, &rateLimitErr):
+			b.record(k, "rate_limited")
+		default:
+			b.record(k, "error")
+			return err
+		}
+	}
+	return err
+}
+
+func (b *balancedClient) record(k *keyedClient, status string) {
+	if b.metrics != nil {
+		b.metrics.RecordKeyRequest(string(b.provider), k.name, status)
+	}
+}
+
+// Generate sends req using the next available key
+func (b *balancedClient) Generate(ctx context.Context, req *ai.Request) (*ai.Response, error) {
+	var resp *ai.Response
+	err := b.do(func(c ai.Client) error {
+		var err error
+		resp, err = c.Generate(ctx, req)
+		return err
+	})
+	return resp, err
+}
+
+// Close closes the client of every key
+func (b *balancedClient) Close() error {
+	var errs []error
+	for _, k := range b.keys {
+		errs = append(errs, ai.CloseClient(k.client))
+	}
+	return errors.Join(errs...)
+}
+
+// Stream opens a stream using the next available key. Rate limits are only
+// detected when the stream is opened, not mid-stream.
+func (b *balancedClient) Stream(ctx context.Context, req *ai.Request) (ai.StreamReader, error) {
+	var reader ai.StreamReader
+	err := b.do(func(c ai.Client) error {
+		var err error
+		reader, err = ai.Stream(ctx, c, req)
+		return err
+	})
+	return reader, err
+}
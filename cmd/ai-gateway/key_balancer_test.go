@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/liuzl/ai"
+)
+
+// fakeClient counts its calls and fails each one with err.
+type fakeClient struct {
+	calls int
+	err   error
+}
+
+func (c *fakeClient) Generate(ctx context.Context, req *ai.Request) (*ai.Response, error) {
+	c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &ai.Response{Text: "ok"}, nil
+}
+
+func TestBalancedClientWeights(t *testing.T) {
+	a, b := &fakeClient{}, &fakeClient{}
+	client := newBalancedClient(ai.ProviderOpenAI, []*keyedClient{
+		{name: "KEY_A", client: a, weight: 3},
+		{name: "KEY_B", client: b, weight: 1},
+	}, nil)
+
+	for range 8 {
+		if _, err := client.Generate(context.Background(), &ai.Request{}); err != nil {
+			t.Fatalf("Generate failed: %v", err)
+		}
+	}
+	if a.calls != 6 || b.calls != 2 {
+		t.Errorf("expected a 3:1 split over 8 requests, got %d:%d", a.calls, b.calls)
+	}
+}
+
+func TestBalancedClientFailover(t *testing.T) {
+	limited := &fakeClient{err: ai.NewRateLimitError("openai", "slow down", 0, nil)}
+	healthy, other := &fakeClient{}, &fakeClient{}
+	client := newBalancedClient(ai.ProviderOpenAI, []*keyedClient{
+		{name: "KEY_A", client: limited, weight: 1},
+		{name: "KEY_B", client: healthy, weight: 1},
+		{name: "KEY_C", client: other, weight: 1},
+	}, nil)
+
+	for range 6 {
+		if _, err := client.Generate(context.Background(), &ai.Request{}); err != nil {
+			t.Fatalf("expected failover past the rate limited key, got %v", err)
+		}
+	}
+	// Keys skipped on failover must not build up weight, so the rate limited
+	// key is only tried for its own third of the requests.
+	if limited.calls != 2 || healthy.calls != 3 || other.calls != 3 {
+		t.Errorf("expected 2:3:3 attempts over 6 requests, got %d:%d:%d", limited.calls, healthy.calls, other.calls)
+	}
+
+	// Once every key is rate limited, the last rate limit error is returned.
+	healthy.err, other.err = limited.err, limited.err
+	_, err := client.Generate(context.Background(), &ai.Request{})
+	var rateLimitErr *ai.RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		t.Errorf("expected a rate limit error when all keys are limited, got %v", err)
+	}
+
+	// Other errors are returned without trying another key.
+	limited.err, healthy.err, other.err = errors.New("boom"), errors.New("boom"), errors.New("boom")
+	calls := limited.calls + healthy.calls + other.calls
+	if _, err := client.Generate(context.Background(), &ai.Request{}); err == nil {
+		t.Fatal("expected the error to be returned")
+	}
+	if n := limited.calls + healthy.calls + other.calls - calls; n != 1 {
+		t.Errorf("expected one attempt for a non rate limit error, got %d", n)
+	}
+}
@@ -40,6 +40,8 @@ func main() {
 	serverCfg := &ServerConfig{
 		ListenAddr: *listenAddr,
 		Verbose:    *verbose,
+		ConfigFile: *configFile,
+		AdminToken: os.Getenv("AI_GATEWAY_ADMIN_TOKEN"),
 	}
 
 	// Create proxy server
@@ -14,6 +14,8 @@ type MetricsCollector struct {
 	requestDuration *prometheus.HistogramVec
 	errorsTotal     *prometheus.CounterVec
 	activeRequests  *prometheus.GaugeVec
+	keyRequests     *prometheus.CounterVec
+	splitRequests   *prometheus.CounterVec
 }
 
 // NewMetricsCollector creates a new MetricsCollector and registers all metrics
@@ -48,6 +50,20 @@ func NewMetricsCollector() *MetricsCollector {
 			},
 			[]string{"format", "provider"},
 		),
+		keyRequests: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "proxy_api_key_requests_total",
+				Help: "Total number of backend requests per API key",
+			},
+			[]string{"provider", "key", "status"},
+		),
+		splitRequests: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "proxy_split_requests_total",
+				Help: "Total number of requests routed by a model's traffic split, per chosen target",
+			},
+			[]string{"model", "target"},
+		),
 	}
 
 	// Register all metrics
@@ -55,6 +71,8 @@ func NewMetricsCollector() *MetricsCollector {
 	prometheus.MustRegister(m.requestDuration)
 	prometheus.MustRegister(m.errorsTotal)
 	prometheus.MustRegister(m.activeRequests)
+	prometheus.MustRegister(m.keyRequests)
+	prometheus.MustRegister(m.splitRequests)
 
 	return m
 }
@@ -70,6 +88,17 @@ func (m *MetricsCollector) RecordError(format, model, provider, errorType string
 	m.errorsTotal.WithLabelValues(format, model, provider, errorType).Inc()
 }
 
+// RecordKeyRequest records a backend request made with a balanced API key.
+// key is the name of the environment variable holding it, never the key itself.
+func (m *MetricsCollector) RecordKeyRequest(provider, key, status string) {
+	m.keyRequests.WithLabelValues(provider, key, status).Inc()
+}
+
+// RecordSplit records a request for model served by target through a traffic split
+func (m *MetricsCollector) RecordSplit(model, target string) {
+	m.splitRequests.WithLabelValues(model, target).Inc()
+}
+
 // IncActiveRequests increments the active request counter
 func (m *MetricsCollector) IncActiveRequests(format, provider string) {
 	m.activeRequests.WithLabelValues(format, provider).Inc()
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"crypto/subtle"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"runtime/debug"
+	"strings"
 	"time"
 
 	"zliu.org/goutil/rest"
@@ -89,6 +92,35 @@ func RecoveryMiddleware() func(http.Handler) http.Handler {
 	}
 }
 
+// AdminAuthMiddleware admits only requests carrying token as a bearer token.
+// With an empty token the admin endpoints are disabled and answer 404.
+func AdminAuthMiddleware(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			status, message := 0, ""
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			switch {
+			case token == "":
+				status, message = http.StatusNotFound, "admin endpoints are disabled (set AI_GATEWAY_ADMIN_TOKEN)"
+			case !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1:
+				status, message = http.StatusUnauthorized, "invalid or missing admin token"
+			default:
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(map[string]any{
+				"error": map[string]any{
+					"message":    message,
+					"request_id": GetRequestID(r.Context()),
+				},
+			})
+		})
+	}
+}
+
 // responseWriter is a wrapper around http.ResponseWriter that captures the status code
 type responseWriter struct {
 	http.ResponseWriter
@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"sync/atomic"
 	"time"
 
 	"github.com/liuzl/ai"
@@ -22,34 +23,90 @@ var staticFiles embed.FS
 type ServerConfig struct {
 	ListenAddr string
 	Verbose    bool
+	ConfigFile string // YAML file re-read by /admin/reload
+	AdminToken string // Bearer token for /admin endpoints; empty disables them
 }
 
 // ProxyServer is the main proxy server
 type ProxyServer struct {
-	config           *ProxyConfig
+	state            atomic.Pointer[routingState]
 	serverCfg        *ServerConfig
-	clientPool       *ClientPool
 	converterFactory *ai.FormatConverterFactory
 	metrics          *MetricsCollector
 	httpServer       *http.Server
 }
 
+// routingState is the configuration and the client pool built from it. It is
+// replaced as a whole on reload; a request uses the state it started with.
+type routingState struct {
+	config     *ProxyConfig
+	clientPool *ClientPool
+}
+
 // NewProxyServer creates a new ProxyServer
 func NewProxyServer(cfg *ProxyConfig, serverCfg *ServerConfig) (*ProxyServer, error) {
 	s := &ProxyServer{
-		config:           cfg,
 		serverCfg:        serverCfg,
-		clientPool:       NewClientPool(),
 		converterFactory: &ai.FormatConverterFactory{},
 		metrics:          NewMetricsCollector(),
 	}
 
+	state, err := s.newRoutingState(cfg)
+	if err != nil {
+		return nil, err
+	}
+	s.state.Store(state)
+
+	return s, nil
+}
+
+// current returns the routing state in effect
+func (s *ProxyServer) current() *routingState {
+	return s.state.Load()
+}
+
+// newRoutingState builds the client pool for cfg and checks that every
+// configured provider has credentials
+func (s *ProxyServer) newRoutingState(cfg *ProxyConfig) (*routingState, error) {
+	var clientOpts []ai.Option
+	if cfg.CoalesceRequests {
+		clientOpts = append(clientOpts, ai.WithRequestCoalescing())
+	}
+	state := &routingState{
+		config:     cfg,
+		clientPool: NewClientPool(cfg.APIKeys, cfg.Providers, s.metrics, clientOpts...),
+	}
+
 	// Validate that all configured providers have credentials
-	if err := s.validateProviders(); err != nil {
+	if err := state.validateProviders(); err != nil {
+		state.clientPool.Close()
 		return nil, fmt.Errorf("provider validation failed: %w", err)
 	}
 
-	return s, nil
+	return state, nil
+}
+
+// Reload re-reads the configuration file and switches to it. The new
+// configuration is validated, and its clients created, before the switch; on
+// error the current configuration stays in effect. Requests in flight finish
+// with the configuration they started with.
+func (s *ProxyServer) Reload() error {
+	cfg, err := LoadConfig(s.serverCfg.ConfigFile)
+	if err != nil {
+		return err
+	}
+	state, err := s.newRoutingState(cfg)
+	if err != nil {
+		return err
+	}
+	old := s.state.Swap(state)
+
+	// Closing only drops idle connections, so in-flight requests are unaffected
+	if err := old.clientPool.Close(); err != nil {
+		rest.Log().Error().Err(err).Msg("failed to close replaced clients")
+	}
+	rest.Log().Info().Msgf("Reloaded configuration from %s: %d models", s.serverCfg.ConfigFile, len(cfg.Models))
+	return nil
 }
 
 // Start starts the HTTP server
@@ -67,7 +124,7 @@ func (s *ProxyServer) Start() error {
 	}
 
 	rest.Log().Info().Msgf("Starting proxy server on %s", s.serverCfg.ListenAddr)
-	rest.Log().Info().Msgf("Configured %d models", len(s.config.Models))
+	rest.Log().Info().Msgf("Configured %d models", len(s.current().config.Models))
 
 	return s.httpServer.ListenAndServe()
 }
@@ -75,7 +132,12 @@ func (s *ProxyServer) Start() error {
 // Shutdown gracefully shuts down the server
 func (s *ProxyServer) Shutdown(ctx context.Context) error {
 	rest.Log().Info().Msg("Shutting down server...")
-	return s.httpServer.Shutdown(ctx)
+	err := s.httpServer.Shutdown(ctx)
+	// Release the provider clients' idle connections
+	if closeErr := s.current().clientPool.Close(); closeErr != nil {
+		rest.Log().Error().Err(closeErr).Msg("failed to close clients")
+	}
+	return err
 }
 
 // setupRoutes configures all HTTP routes
@@ -86,6 +148,13 @@ func (s *ProxyServer) setupRoutes() *http.ServeMux {
 	mux.HandleFunc("/health", s.handleHealth)
 	mux.Handle("/metrics", s.metrics.Handler())
 
+	// Administration
+	mux.Handle("/admin/reload", AdminAuthMiddleware(s.serverCfg.AdminToken)(http.HandlerFunc(s.handleReload)))
+
+	// Model discovery for OpenAI-compatible clients
+	mux.HandleFunc("/v1/models", s.handleModels)
+	mux.HandleFunc("/openai/v1/models", s.handleModels)
+
 	// Format-specific endpoints
 	mux.HandleFunc("/openai/v1/chat/completions", s.handleOpenAI)
 	mux.HandleFunc("/anthropic/v1/messages", s.handleAnthropic)
@@ -138,20 +207,38 @@ func (s *ProxyServer) handleHealth(w http.ResponseWriter, r *http.Request) {
 
 	response := map[string]any{
 		"status":    "healthy",
-		"models":    len(s.config.Models),
+		"models":    len(s.current().config.Models),
 		"timestamp": time.Now().UTC().Format(time.RFC3339),
 	}
 
 	json.NewEncoder(w).Encode(response)
 }
 
+// handleReload handles the /admin/reload endpoint
+func (s *ProxyServer) handleReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.handleError(w, r, ai.ProviderOpenAI, "", "", fmt.Errorf("method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+	if err := s.Reload(); err != nil {
+		s.handleError(w, r, ai.ProviderOpenAI, "", "", fmt.Errorf("reload failed, keeping the current configuration: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"status": "reloaded",
+		"models": len(s.current().config.Models),
+	})
+}
+
 // validateProviders checks that all configured providers have valid credentials
-func (s *ProxyServer) validateProviders() error {
-	providers := s.config.GetProviders()
+func (st *routingState) validateProviders() error {
+	providers := st.config.GetProviders()
 
 	for _, provider := range providers {
 		// Try to create a client for each provider
-		if _, err := s.clientPool.GetClient(provider); err != nil {
+		if _, err := st.clientPool.GetClient(provider); err != nil {
 			return fmt.Errorf("failed to initialize provider %s: %w", provider, err)
 		}
 	}
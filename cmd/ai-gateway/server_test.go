@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReload(t *testing.T) {
+	first, second := newMockBackend(t), newMockBackend(t)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig := func(yaml string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(yaml), 0o600); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+	}
+	configFor := func(model, baseURL string) string {
+		return fmt.Sprintf("version: \"1.0\"\nmodels: [{name: %s, provider: openai}]\nproviders: {openai: {base_url: %q}}\n", model, baseURL)
+	}
+
+	writeConfig(configFor("gpt-test", first.URL))
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	s := newTestServer(t, cfg, first.URL)
+	s.serverCfg.ConfigFile = path
+	s.serverCfg.AdminToken = "admin-token"
+	server := serve(t, s)
+	chat := func(model string) int {
+		t.Helper()
+		resp, _ := post(t, server.URL+"/openai/v1/chat/completions", fmt.Sprintf(`{"model":%q,"messages":[{"role":"user","content":"hi"}]}`, model))
+		return resp.StatusCode
+	}
+	reload := func(token string) int {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/admin/reload", nil)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("reload failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if status := chat("gpt-test"); status != http.StatusOK || first.last() == nil {
+		t.Fatalf("expected the first backend to serve gpt-test, got %d", status)
+	}
+
+	// A reload switches models and backends.
+	writeConfig(configFor("gpt-new", second.URL))
+	if status := reload("wrong-token"); status != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a wrong admin token, got %d", status)
+	}
+	if status := reload("admin-token"); status != http.StatusOK {
+		t.Fatalf("expected the reload to succeed, got %d", status)
+	}
+	if status := chat("gpt-new"); status != http.StatusOK || second.last() == nil {
+		t.Errorf("expected the second backend to serve gpt-new after the reload, got %d", status)
+	}
+	if status := chat("gpt-test"); status != http.StatusBadRequest {
+		t.Errorf("expected gpt-test to be unknown after the reload, got %d", status)
+	}
+
+	// An invalid configuration keeps the current one.
+	writeConfig("version: \"1.0\"\nmodels: []\n")
+	if status := reload("admin-token"); status != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid configuration, got %d", status)
+	}
+	if status := chat("gpt-new"); status != http.StatusOK {
+		t.Errorf("expected the previous configuration to stay in effect, got %d", status)
+	}
+}
@@ -0,0 +1,138 @@
+package ai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// HashRequest returns a stable SHA-256 digest of the request, suitable as a
+// deduplication or cache key. Requests with identical content produce identical hashes.
+func HashRequest(req *Request) (string, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request for hashing: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// flightGroup coalesces concurrent calls sharing a key into a single execution,
+// following the semantics of golang.org/x/sync/singleflight.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+// flightCall is an in-flight or completed call within a flightGroup.
+type flightCall struct {
+	done     chan struct{} // Closed when the call completes
+	resp     *Response
+	err      error
+	panicked any // The value fn panicked with, if it did
+
+	// waiters counts the callers still waiting, guarded by flightGroup.mu;
+	// cancel stops the call once none are left.
+	waiters int
+	cancel  context.CancelFunc
+}
+
+func newFlightGroup() *flightGroup {
+	return &flightGroup{calls: make(map[string]*flightCall)}
+}
+
+// do executes fn once for all concurrent callers with the same key.
+// Every caller receives its own copy of the response so it can be modified safely.
+//
+// fn runs in its own goroutine with a context that keeps the values of the
+// first caller's ctx but not its cancellation, so one caller giving up does not
+// fail the others. Each caller stops waiting when its own ctx is done, and fn's
+// context is canceled once no caller is left waiting.
+func (g *flightGroup) do(ctx context.Context, key string, fn func(context.Context) (*Response, error)) (*Response, error) {
+	g.mu.Lock()
+	call, joined := g.calls[key]
+	if !joined {
+		callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		call = &flightCall{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = call
+		go g.run(callCtx, key, call, fn)
+	}
+	call.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-call.done:
+		// A panic continues in the caller that started the call; the
+		// others get an error.
+		if call.panicked != nil && !joined {
+			panic(call.panicked)
+		}
+		return copyResponse(call.resp), call.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		call.waiters--
+		if call.waiters == 0 {
+			// Later callers start a fresh call rather than join a canceled one
+			if g.calls[key] == call {
+				delete(g.calls, key)
+			}
+			call.cancel()
+		}
+		g.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// run executes fn for call and releases its waiters.
+func (g *flightGroup) run(ctx context.Context, key string, call *flightCall, fn func(context.Context) (*Response, error)) {
+	defer func() {
+		if r := recover(); r != nil {
+			call.panicked = r
+			call.resp, call.err = nil, errors.New("coalesced request panicked")
+		}
+		g.mu.Lock()
+		if g.calls[key] == call {
+			delete(g.calls, key)
+		}
+		g.mu.Unlock()
+		call.cancel()
+		close(call.done)
+	}()
+	call.resp, call.err = fn(ctx)
+}
+
+// copyResponse returns a copy of resp that shares none of its slices or its
+// Usage, so each coalesced caller can modify its response safely.
+func copyResponse(resp *Response) *Response {
+	if resp == nil {
+		return nil
+	}
+	c := *resp
+	if resp.ToolCalls != nil {
+		c.ToolCalls = append([]ToolCall(nil), resp.ToolCalls...)
+	}
+	if resp.Logprobs != nil {
+		c.Logprobs = append([]TokenLogprob(nil), resp.Logprobs...)
+	}
+	if resp.Media != nil {
+		c.Media = append([]MediaOutput(nil), resp.Media...)
+	}
+	if resp.Warnings != nil {
+		c.Warnings = append([]string(nil), resp.Warnings...)
+	}
+	if resp.Citations != nil {
+		c.Citations = append([]Citation(nil), resp.Citations...)
+	}
+	if resp.Raw != nil {
+		c.Raw = append(json.RawMessage(nil), resp.Raw...)
+	}
+	if resp.Usage != nil {
+		u := *resp.Usage
+		c.Usage = &u
+	}
+	return &c
+}
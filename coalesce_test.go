@@ -0,0 +1,233 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRequestCoalescing(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		// Keep the call in flight long enough for every caller to join it.
+		time.Sleep(300 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"shared answer"}}]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(
+		WithProvider(ProviderOpenAI),
+		WithAPIKey("test-key"),
+		WithBaseURL(server.URL),
+		WithRequestCoalescing(),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	const callers = 10
+	var wg sync.WaitGroup
+	responses := make([]*Response, callers)
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := &Request{Messages: []Message{{Role: RoleUser, Content: "same prompt"}}}
+			resp, err := client.Generate(context.Background(), req)
+			if err != nil {
+				t.Errorf("Generate failed: %v", err)
+				return
+			}
+			responses[i] = resp
+		}()
+	}
+	wg.Wait()
+
+	if got := hits.Load(); got != 1 {
+		t.Fatalf("expected 1 backend call, got %d", got)
+	}
+	for i, resp := range responses {
+		if resp == nil || resp.Text != "shared answer" {
+			t.Fatalf("caller %d: unexpected response %+v", i, resp)
+		}
+		if i > 0 && resp == responses[0] {
+			t.Fatalf("caller %d: response pointer shared with caller 0", i)
+		}
+	}
+
+	// Once the shared call completes, the next identical request reaches the backend again.
+	if _, err := client.Generate(context.Background(), &Request{Messages: []Message{{Role: RoleUser, Content: "same prompt"}}}); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if got := hits.Load(); got != 2 {
+		t.Fatalf("expected 2 backend calls after the flight completed, got %d", got)
+	}
+}
+
+// TestFlightGroupPanic tests that a panic in the shared call releases the
+// coalesced waiters with an error and frees the key for later calls
+func TestFlightGroupPanic(t *testing.T) {
+	g := newFlightGroup()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	leader := make(chan any)
+	go func() {
+		defer func() { leader <- recover() }()
+		g.do(context.Background(), "key", func(context.Context) (*Response, error) {
+			close(started)
+			<-release
+			panic("boom")
+		})
+	}()
+	<-started
+
+	waiter := make(chan error)
+	go func() {
+		_, err := g.do(context.Background(), "key", func(context.Context) (*Response, error) {
+			t.Error("expected the second call to join the first")
+			return nil, nil
+		})
+		waiter <- err
+	}()
+	// Give the second call time to join the flight before it fails.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	if r := <-leader; r != "boom" {
+		t.Errorf("expected the panic to reach the calling goroutine, got %v", r)
+	}
+	select {
+	case err := <-waiter:
+		if err == nil {
+			t.Error("expected the waiter to get an error")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("waiter still blocked after the shared call panicked")
+	}
+
+	resp, err := g.do(context.Background(), "key", func(context.Context) (*Response, error) { return &Response{Text: "ok"}, nil })
+	if err != nil || resp.Text != "ok" {
+		t.Errorf("expected a fresh call after the panic, got %+v, %v", resp, err)
+	}
+}
+
+// TestRequestCoalescingCancel tests that a caller giving up on a coalesced
+// request fails only itself
+func TestRequestCoalescingCancel(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		time.Sleep(300 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"shared answer"}}]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithProvider(ProviderOpenAI), WithAPIKey("test-key"), WithBaseURL(server.URL), WithRequestCoalescing())
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	req := func() *Request { return &Request{Messages: []Message{{Role: RoleUser, Content: "same prompt"}}} }
+
+	// The leader gives up once the call is in flight.
+	leaderCtx, cancelLeader := context.WithCancel(context.Background())
+	leader := make(chan error)
+	go func() {
+		_, err := client.Generate(leaderCtx, req())
+		leader <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	// One follower has a deadline shorter than the call, the other waits for it.
+	shortCtx, cancelShort := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancelShort()
+	short := make(chan error)
+	go func() {
+		_, err := client.Generate(shortCtx, req())
+		short <- err
+	}()
+	follower := make(chan *Response)
+	go func() {
+		resp, err := client.Generate(context.Background(), req())
+		if err != nil {
+			t.Errorf("expected the follower to succeed after the leader canceled, got %v", err)
+		}
+		follower <- resp
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancelLeader()
+
+	if err := <-leader; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the leader to be canceled, got %v", err)
+	}
+	start := time.Now()
+	if err := <-short; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the short follower to hit its deadline, got %v", err)
+	}
+	if waited := time.Since(start); waited > 150*time.Millisecond {
+		t.Errorf("expected the short follower to stop at its own deadline, waited %v more", waited)
+	}
+	if resp := <-follower; resp == nil || resp.Text != "shared answer" {
+		t.Errorf("expected the shared answer, got %+v", resp)
+	}
+	if got := hits.Load(); got != 1 {
+		t.Errorf("expected 1 backend call, got %d", got)
+	}
+}
+
+// TestFlightGroupAbandoned tests that the shared call is canceled once every
+// caller has stopped waiting, and that a later caller starts a fresh call
+func TestFlightGroupAbandoned(t *testing.T) {
+	g := newFlightGroup()
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan struct{})
+	go func() {
+		<-time.After(20 * time.Millisecond)
+		cancel()
+	}()
+	_, err := g.do(ctx, "key", func(ctx context.Context) (*Response, error) {
+		<-ctx.Done()
+		close(canceled)
+		return nil, ctx.Err()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the caller to be canceled, got %v", err)
+	}
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("expected the abandoned call to be canceled")
+	}
+
+	resp, err := g.do(context.Background(), "key", func(context.Context) (*Response, error) { return &Response{Text: "ok"}, nil })
+	if err != nil || resp.Text != "ok" {
+		t.Errorf("expected a fresh call, got %+v, %v", resp, err)
+	}
+}
+
+func TestHashRequest(t *testing.T) {
+	a := &Request{Model: "m", Messages: []Message{{Role: RoleUser, Content: "hello"}}}
+	b := &Request{Model: "m", Messages: []Message{{Role: RoleUser, Content: "hello"}}}
+	c := &Request{Model: "m", Messages: []Message{{Role: RoleUser, Content: "goodbye"}}}
+
+	ha, err := HashRequest(a)
+	if err != nil {
+		t.Fatalf("HashRequest failed: %v", err)
+	}
+	hb, _ := HashRequest(b)
+	hc, _ := HashRequest(c)
+
+	if ha != hb {
+		t.Errorf("expected identical requests to hash equally: %s != %s", ha, hb)
+	}
+	if ha == hc {
+		t.Errorf("expected different requests to hash differently")
+	}
+}
@@ -1,6 +1,10 @@
 package ai_test
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
@@ -122,6 +126,28 @@ func TestConfigValidation_Timeout(t *testing.T) {
 		}
 	})
 
+	t.Run("negative stream idle timeout", func(t *testing.T) {
+		_, err := ai.NewClient(
+			ai.WithProvider(ai.ProviderOpenAI),
+			ai.WithAPIKey("test-key"),
+			ai.WithStreamIdleTimeout(-1*time.Second),
+		)
+		if err == nil {
+			t.Fatal("Expected error for negative stream idle timeout")
+		}
+	})
+
+	t.Run("negative stream timeout", func(t *testing.T) {
+		_, err := ai.NewClient(
+			ai.WithProvider(ai.ProviderOpenAI),
+			ai.WithAPIKey("test-key"),
+			ai.WithStreamTimeout(-1*time.Second),
+		)
+		if err == nil || !strings.Contains(err.Error(), "stream timeout cannot be negative") {
+			t.Errorf("Expected 'stream timeout cannot be negative' error, got: %v", err)
+		}
+	})
+
 	t.Run("zero timeout", func(t *testing.T) {
 		_, err := ai.NewClient(
 			ai.WithProvider(ai.ProviderOpenAI),
@@ -299,6 +325,58 @@ func TestConfigValidation_Model(t *testing.T) {
 	})
 }
 
+// TestConfigValidation_ConnectionPool tests connection pool option validation.
+func TestConfigValidation_ConnectionPool(t *testing.T) {
+	tests := []struct {
+		name    string
+		opt     ai.Option
+		wantErr string
+	}{
+		{"zero max idle conns", ai.WithMaxIdleConns(0), "max idle connections must be positive"},
+		{"negative max idle conns per host", ai.WithMaxIdleConnsPerHost(-1), "max idle connections per host must be positive"},
+		{"zero idle conn timeout", ai.WithIdleConnTimeout(0), "idle connection timeout must be positive"},
+		{"valid max idle conns", ai.WithMaxIdleConns(500), ""},
+		{"valid max idle conns per host", ai.WithMaxIdleConnsPerHost(64), ""},
+		{"valid idle conn timeout", ai.WithIdleConnTimeout(2 * time.Minute), ""},
+		{"zero max response size", ai.WithMaxResponseSize(0), "max response size must be positive"},
+		{"valid max response size", ai.WithMaxResponseSize(1 << 20), ""},
+		{"zero backoff base", ai.WithBackoff(0, time.Second), "backoff base delay must be positive"},
+		{"backoff max below base", ai.WithBackoff(time.Second, time.Millisecond), "cannot be less than base delay"},
+		{"valid backoff", ai.WithBackoff(100*time.Millisecond, 5*time.Second), ""},
+		{"negative retry budget", ai.WithRetryBudget(-time.Second), "retry budget cannot be negative"},
+		{"valid retry budget", ai.WithRetryBudget(10 * time.Second), ""},
+		{"unknown parameter compatibility", ai.WithParameterCompatibility("lenient"), "parameter compatibility"},
+		{"strict parameter compatibility", ai.WithParameterCompatibility(ai.ParameterCompatibilityStrict), ""},
+		{"proxy URL without scheme", ai.WithProxyURL("proxy.corp.example:3128"), "proxy URL"},
+		{"proxy URL with unsupported scheme", ai.WithProxyURL("ftp://proxy.corp.example"), "proxy URL scheme must be"},
+		{"valid proxy URL", ai.WithProxyURL("http://proxy.corp.example:3128"), ""},
+		{"negative default max tokens", ai.WithDefaults(ai.Defaults{MaxTokens: -1}), "default max tokens cannot be negative"},
+		{"valid defaults", ai.WithDefaults(ai.Defaults{Model: "gpt-4o-mini", SystemPrompt: "Be brief.", MaxTokens: 256}), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ai.NewClient(
+				ai.WithProvider(ai.ProviderOpenAI),
+				ai.WithAPIKey("test-key"),
+				tt.opt,
+			)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Expected error containing %q", tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected %q error, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
 // TestConfigValidation_Complete tests a complete valid configuration.
 func TestConfigValidation_Complete(t *testing.T) {
 	_, err := ai.NewClient(
@@ -325,3 +403,90 @@ func TestConfigValidation_MultipleErrors(t *testing.T) {
 		t.Errorf("Expected provider error, got: %v", err)
 	}
 }
+
+// clearAIEnv unsets every environment variable read by NewClientFromEnv.
+func clearAIEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{
+		"AI_PROVIDER", "AI_MODEL",
+		"OPENAI_API_KEY", "OPENAI_MODEL", "OPENAI_BASE_URL",
+		"GEMINI_API_KEY", "GEMINI_MODEL", "GEMINI_BASE_URL",
+		"ANTHROPIC_API_KEY", "ANTHROPIC_MODEL", "ANTHROPIC_BASE_URL",
+	} {
+		t.Setenv(name, "")
+	}
+}
+
+// TestNewClientFromEnv_ModelPrecedence tests that *_MODEL wins over AI_MODEL.
+func TestNewClientFromEnv_ModelPrecedence(t *testing.T) {
+	var gotModel string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		gotModel = body.Model
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		aiModel      string
+		openaiModel  string
+		expectedPart string
+	}{
+		{"provider-specific wins", "generic-model", "openai-model", "openai-model"},
+		{"generic fallback", "generic-model", "", "generic-model"},
+		{"built-in default", "", "", "gpt-"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearAIEnv(t)
+			t.Setenv("OPENAI_API_KEY", "test-key")
+			t.Setenv("OPENAI_BASE_URL", server.URL)
+			t.Setenv("AI_MODEL", tt.aiModel)
+			t.Setenv("OPENAI_MODEL", tt.openaiModel)
+
+			client, err := ai.NewClientFromEnv()
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if _, err := client.Generate(context.Background(), &ai.Request{
+				Messages: []ai.Message{{Role: ai.RoleUser, Content: "hi"}},
+			}); err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+			if !strings.HasPrefix(gotModel, tt.expectedPart) {
+				t.Errorf("Expected model starting with %q, got %q", tt.expectedPart, gotModel)
+			}
+		})
+	}
+}
+
+// TestNewClientFromEnv_MissingKey tests the errors listing which env vars to set.
+func TestNewClientFromEnv_MissingKey(t *testing.T) {
+	t.Run("default provider", func(t *testing.T) {
+		clearAIEnv(t)
+		_, err := ai.NewClientFromEnv()
+		if err == nil {
+			t.Fatal("Expected error when no provider or key is set")
+		}
+		for _, want := range []string{"AI_PROVIDER is not set", "OPENAI_API_KEY", "AI_PROVIDER=gemini with GEMINI_API_KEY", "AI_PROVIDER=anthropic with ANTHROPIC_API_KEY"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("Expected error to mention %q, got: %v", want, err)
+			}
+		}
+	})
+
+	t.Run("explicit provider", func(t *testing.T) {
+		clearAIEnv(t)
+		t.Setenv("AI_PROVIDER", "anthropic")
+		t.Setenv("OPENAI_API_KEY", "test-key")
+		_, err := ai.NewClientFromEnv()
+		if err == nil || !strings.Contains(err.Error(), "ANTHROPIC_API_KEY") {
+			t.Errorf("Expected error naming ANTHROPIC_API_KEY, got: %v", err)
+		}
+	})
+}
@@ -1,7 +1,9 @@
 package ai
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
 	"time"
 )
 
@@ -138,22 +140,120 @@ func NewNetworkError(provider string, message string, err error) *NetworkError {
 	}
 }
 
+// TimeoutKind identifies which timeout a TimeoutError hit.
+type TimeoutKind string
+
+const (
+	TimeoutKindConnect  TimeoutKind = "connect"  // Establishing the connection timed out
+	TimeoutKindIdle     TimeoutKind = "idle"     // A stream sent nothing for WithStreamIdleTimeout
+	TimeoutKindDeadline TimeoutKind = "deadline" // The client timeout or context deadline passed
+	TimeoutKindDownload TimeoutKind = "download" // A media download used up its share of the deadline
+)
+
 // TimeoutError represents timeout errors (context deadline exceeded).
 type TimeoutError struct {
 	baseError
 	Duration time.Duration
+	Kind     TimeoutKind
 }
 
-// NewTimeoutError creates a new timeout error.
+// NewTimeoutError creates a new timeout error of kind TimeoutKindDeadline.
 func NewTimeoutError(provider string, duration time.Duration, err error) *TimeoutError {
+	return newTimeoutError(provider, TimeoutKindDeadline, duration, err)
+}
+
+func newTimeoutError(provider string, kind TimeoutKind, duration time.Duration, err error) *TimeoutError {
+	message := fmt.Sprintf("request timeout after %v", duration)
+	switch {
+	case kind == TimeoutKindDeadline && duration == 0:
+		message = "request deadline exceeded"
+	case kind == TimeoutKindConnect:
+		message = "connect timeout"
+	case kind == TimeoutKindIdle:
+		message = fmt.Sprintf("stream idle for %v", duration)
+	case kind == TimeoutKindDownload:
+		message = fmt.Sprintf("media download timeout after %v", duration)
+	}
 	return &TimeoutError{
 		baseError: baseError{
 			statusCode: 0, // No HTTP status for timeout errors
 			provider:   provider,
-			message:    fmt.Sprintf("request timeout after %v", duration),
+			message:    message,
 			err:        err,
 		},
 		Duration: duration,
+		Kind:     kind,
+	}
+}
+
+// UnexpectedResponseError represents a successful (2xx) response whose body is
+// not JSON, such as an HTML error page served by a proxy.
+type UnexpectedResponseError struct {
+	baseError
+	ContentType string
+	BodySnippet string
+}
+
+// NewUnexpectedResponseError creates a new unexpected response error.
+func NewUnexpectedResponseError(provider string, statusCode int, contentType, bodySnippet string) *UnexpectedResponseError {
+	return &UnexpectedResponseError{
+		baseError: baseError{
+			statusCode: statusCode,
+			provider:   provider,
+			message:    fmt.Sprintf("unexpected response content type %q: %s", contentType, bodySnippet),
+		},
+		ContentType: contentType,
+		BodySnippet: bodySnippet,
+	}
+}
+
+// ContentFilterError represents a successful response that carries no output
+// because the provider's safety filters blocked the prompt.
+type ContentFilterError struct {
+	baseError
+	Reason string // The provider's block reason, e.g. "SAFETY"
+}
+
+// NewContentFilterError creates a new content filter error.
+func NewContentFilterError(provider string, reason string) *ContentFilterError {
+	return &ContentFilterError{
+		baseError: baseError{
+			statusCode: http.StatusOK,
+			provider:   provider,
+			message:    fmt.Sprintf("content blocked: %s", reason),
+		},
+		Reason: reason,
+	}
+}
+
+// ErrUnsupportedContent is matched (via errors.Is) by *UnsupportedContentError.
+var ErrUnsupportedContent = errors.New("unsupported content")
+
+// UnsupportedContentError is returned when building a provider request from a
+// ContentPart whose type the provider does not accept, such as audio on OpenAI.
+// No request is sent, and the status code is 400.
+type UnsupportedContentError struct {
+	baseError
+	ContentType ContentType
+}
+
+// NewUnsupportedContentError creates a new unsupported content error.
+func NewUnsupportedContentError(provider Provider, contentType ContentType) *UnsupportedContentError {
+	msg := fmt.Sprintf("%s: %s input is not supported", ErrUnsupportedContent, contentType)
+	switch contentType {
+	case ContentTypeAudio, ContentTypeVideo:
+		msg += " (supported providers: gemini)"
+	case ContentTypeDocument:
+		msg += " (supported providers: gemini, anthropic)"
+	}
+	return &UnsupportedContentError{
+		baseError: baseError{
+			statusCode: http.StatusBadRequest,
+			message:    msg,
+			provider:   string(provider),
+			err:        ErrUnsupportedContent,
+		},
+		ContentType: contentType,
 	}
 }
 
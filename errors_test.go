@@ -1,6 +1,7 @@
 package ai_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -288,3 +289,54 @@ func TestErrorTypeDistinction(t *testing.T) {
 		t.Error("invalidReqErr should be InvalidRequestError")
 	}
 }
+
+func TestContentFilterError(t *testing.T) {
+	err := ai.NewContentFilterError("gemini", "SAFETY")
+
+	if !strings.Contains(err.Error(), "content blocked: SAFETY") {
+		t.Errorf("Expected 'content blocked: SAFETY' in error message, got: %s", err.Error())
+	}
+	if err.StatusCode() != 200 {
+		t.Errorf("Expected status code 200, got %d", err.StatusCode())
+	}
+
+	var filterErr *ai.ContentFilterError
+	if !errors.As(err, &filterErr) || filterErr.Reason != "SAFETY" {
+		t.Error("Expected error to be ContentFilterError with reason SAFETY")
+	}
+}
+
+// TestUnsupportedContentError tests that content a provider cannot accept fails
+// with an UnsupportedContentError before any request is sent.
+func TestUnsupportedContentError(t *testing.T) {
+	tests := []struct {
+		provider ai.Provider
+		part     ai.ContentPart
+	}{
+		{ai.ProviderOpenAI, ai.NewAudioPartFromBase64("UklGRg==", "wav")},
+		{ai.ProviderAnthropic, ai.NewVideoPartFromURL("https://example.com/clip.mp4", "mp4")},
+		{ai.ProviderMistral, ai.NewAudioPartFromBase64("UklGRg==", "wav")},
+	}
+	for _, tt := range tests {
+		client, err := ai.NewClient(ai.WithProvider(tt.provider), ai.WithAPIKey("test-key"), ai.WithBaseURL("http://127.0.0.1:0"))
+		if err != nil {
+			t.Fatalf("failed to create client: %v", err)
+		}
+		req := &ai.Request{Messages: []ai.Message{{Role: ai.RoleUser, ContentParts: []ai.ContentPart{tt.part}}}}
+		_, err = client.Generate(context.Background(), req)
+
+		var contentErr *ai.UnsupportedContentError
+		if !errors.As(err, &contentErr) {
+			t.Fatalf("%s: expected UnsupportedContentError, got %v", tt.provider, err)
+		}
+		if contentErr.Provider() != string(tt.provider) || contentErr.ContentType != tt.part.Type {
+			t.Errorf("%s: expected provider and content type to be recorded, got %+v", tt.provider, contentErr)
+		}
+		if contentErr.StatusCode() != 400 {
+			t.Errorf("%s: expected status 400, got %d", tt.provider, contentErr.StatusCode())
+		}
+		if !errors.Is(err, ai.ErrUnsupportedContent) {
+			t.Errorf("%s: expected error to match ErrUnsupportedContent", tt.provider)
+		}
+	}
+}
@@ -26,6 +26,10 @@ type FormatConverter interface {
 	// Returns the response struct that can be marshaled to JSON for the provider's API.
 	ConvertResponseToFormat(universalResp *Response, originalModel string) (any, error)
 
+	// ConvertErrorToFormat builds an error body in this format's native error
+	// shape, so the format's SDKs can parse errors the gateway returns.
+	ConvertErrorToFormat(statusCode int, message string) any
+
 	// GetEndpoint returns the API endpoint path for this format (e.g., "/v1/chat/completions", "/v1/messages").
 	GetEndpoint() string
 
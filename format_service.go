@@ -33,6 +33,32 @@ func ConvertResponse(targetFormat string, resp *Response) ([]byte, error) {
 	}
 }
 
+// ConvertResponseFull converts a universal Response into a complete provider
+// response payload, as the provider's own API would return it: unlike
+// ConvertResponse, it includes the model, a response ID where the format has
+// one, the finish reason and token usage. usage overrides resp.Usage when
+// non-nil.
+func ConvertResponseFull(targetFormat string, resp *Response, model string, usage *Usage) ([]byte, error) {
+	if resp == nil {
+		return nil, fmt.Errorf("response cannot be nil")
+	}
+	if usage != nil {
+		r := *resp
+		r.Usage = usage
+		resp = &r
+	}
+
+	converter, err := NewFormatConverterFactory().GetConverter(Provider(targetFormat))
+	if err != nil {
+		return nil, fmt.Errorf("unsupported provider format: %s", targetFormat)
+	}
+	payload, err := converter.ConvertResponseToFormat(resp, model)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(payload)
+}
+
 // --- OpenAI Conversion ---
 
 type openAIRequestFormat struct {
@@ -223,8 +249,8 @@ func toGeminiFormat(resp *Response) ([]byte, error) {
 	}
 
 	for _, tc := range resp.ToolCalls {
-		var args map[string]any
-		if err := json.Unmarshal([]byte(tc.Arguments), &args); err != nil {
+		args, err := decodeArguments(tc.Arguments)
+		if err != nil {
 			return nil, fmt.Errorf("failed to unmarshal tool call arguments: %w", err)
 		}
 		parts = append(parts, geminiPartFormat{
@@ -271,12 +297,12 @@ type anthropicMessageFormat struct {
 }
 
 type anthropicContentBlockFormat struct {
-	Type      string         `json:"type"`
-	Text      string         `json:"text,omitempty"`
-	ID        string         `json:"id,omitempty"`
-	ToolUseID string         `json:"tool_use_id,omitempty"`
-	Name      string         `json:"name,omitempty"`
-	Input     map[string]any `json:"input,omitempty"`
+	Type      string `json:"type"`
+	Text      string `json:"text,omitempty"`
+	ID        string `json:"id,omitempty"`
+	ToolUseID string `json:"tool_use_id,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Input     any    `json:"input,omitempty"` // map[string]any; any so an empty input is kept
 }
 
 type anthropicResponseFormat struct {
@@ -343,8 +369,8 @@ func toAnthropicFormat(resp *Response) ([]byte, error) {
 	}
 
 	for _, tc := range resp.ToolCalls {
-		var input map[string]any
-		if err := json.Unmarshal([]byte(tc.Arguments), &input); err != nil {
+		input, err := decodeArguments(tc.Arguments)
+		if err != nil {
 			return nil, fmt.Errorf("failed to unmarshal tool call arguments: %w", err)
 		}
 		content = append(content, anthropicContentBlockFormat{
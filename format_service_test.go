@@ -1,8 +1,14 @@
 package ai_test
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/liuzl/ai"
@@ -214,6 +220,276 @@ func TestFormatConversion(t *testing.T) {
 	}
 }
 
+func TestConvertResponseFull(t *testing.T) {
+	resp := &ai.Response{
+		Text:         "Once upon a",
+		FinishReason: ai.FinishReasonLength,
+		Usage:        &ai.Usage{InputTokens: 12, OutputTokens: 3, TotalTokens: 15},
+	}
+	testCases := []struct {
+		provider string
+		want     map[string]any // dotted path -> expected value
+	}{
+		{"openai", map[string]any{
+			"model":                   "gpt-4o",
+			"choices.0.finish_reason": "length",
+			"usage.prompt_tokens":     12.0,
+			"usage.completion_tokens": 3.0,
+			"usage.total_tokens":      15.0,
+		}},
+		{"anthropic", map[string]any{
+			"model":               "gpt-4o",
+			"stop_reason":         "max_tokens",
+			"usage.input_tokens":  12.0,
+			"usage.output_tokens": 3.0,
+		}},
+		{"gemini", map[string]any{
+			"candidates.0.finishReason":          "MAX_TOKENS",
+			"usageMetadata.promptTokenCount":     12.0,
+			"usageMetadata.candidatesTokenCount": 3.0,
+			"usageMetadata.totalTokenCount":      15.0,
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.provider, func(t *testing.T) {
+			respBytes, err := ai.ConvertResponseFull(tc.provider, resp, "gpt-4o", nil)
+			if err != nil {
+				t.Fatalf("ConvertResponseFull failed: %v", err)
+			}
+			var got map[string]any
+			if err := json.Unmarshal(respBytes, &got); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			for path, want := range tc.want {
+				if v := lookupPath(got, path); v != want {
+					t.Errorf("%s: expected %v, got %v in %s", path, want, v, respBytes)
+				}
+			}
+		})
+	}
+
+	t.Run("usage override", func(t *testing.T) {
+		respBytes, err := ai.ConvertResponseFull("openai", resp, "gpt-4o", &ai.Usage{InputTokens: 1, OutputTokens: 2})
+		if err != nil {
+			t.Fatalf("ConvertResponseFull failed: %v", err)
+		}
+		var got map[string]any
+		_ = json.Unmarshal(respBytes, &got)
+		if v := lookupPath(got, "usage.total_tokens"); v != 3.0 {
+			t.Errorf("expected overridden usage, got %v in %s", v, respBytes)
+		}
+		if resp.Usage.InputTokens != 12 {
+			t.Errorf("expected the response not to be modified, got %+v", resp.Usage)
+		}
+	})
+}
+
+func TestConvertErrorToFormat(t *testing.T) {
+	testCases := []struct {
+		provider   ai.Provider
+		statusCode int
+		want       map[string]any // dotted path -> expected value
+	}{
+		{ai.ProviderOpenAI, 400, map[string]any{
+			"error.message": "bad input",
+			"error.type":    "invalid_request_error",
+			"error.code":    nil,
+		}},
+		{ai.ProviderOpenAI, 429, map[string]any{
+			"error.type": "rate_limit_error",
+			"error.code": "rate_limit_exceeded",
+		}},
+		{ai.ProviderAnthropic, 400, map[string]any{
+			"type":          "error",
+			"error.type":    "invalid_request_error",
+			"error.message": "bad input",
+		}},
+		{ai.ProviderAnthropic, 401, map[string]any{"error.type": "authentication_error"}},
+		{ai.ProviderAnthropic, 500, map[string]any{"error.type": "api_error"}},
+		{ai.ProviderGemini, 400, map[string]any{
+			"error.code":    400.0,
+			"error.message": "bad input",
+			"error.status":  "INVALID_ARGUMENT",
+		}},
+		{ai.ProviderGemini, 429, map[string]any{"error.status": "RESOURCE_EXHAUSTED"}},
+	}
+
+	factory := ai.NewFormatConverterFactory()
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("%s %d", tc.provider, tc.statusCode), func(t *testing.T) {
+			converter, err := factory.GetConverter(tc.provider)
+			if err != nil {
+				t.Fatalf("GetConverter failed: %v", err)
+			}
+			data, err := json.Marshal(converter.ConvertErrorToFormat(tc.statusCode, "bad input"))
+			if err != nil {
+				t.Fatalf("failed to marshal error: %v", err)
+			}
+			var got map[string]any
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("failed to unmarshal error: %v", err)
+			}
+			for path, want := range tc.want {
+				if v := lookupPath(got, path); v != want {
+					t.Errorf("%s: expected %v, got %v in %s", path, want, v, data)
+				}
+			}
+		})
+	}
+}
+
+func TestConverterSamplingParameters(t *testing.T) {
+	testCases := []struct {
+		format ai.Provider
+		path   string
+		body   string
+	}{
+		{ai.ProviderOpenAI, "/v1/chat/completions", `{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}],
+			"temperature":0.3,"top_p":0.9,"max_tokens":128,"stop":"END"}`},
+		{ai.ProviderAnthropic, "/v1/messages", `{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}],
+			"temperature":0.3,"top_p":0.9,"max_tokens":128,"stop_sequences":["END"]}`},
+		{ai.ProviderGemini, "/v1beta/models/gpt-4o:generateContent", `{"contents":[{"role":"user","parts":[{"text":"hi"}]}],
+			"generationConfig":{"temperature":0.3,"topP":0.9,"maxOutputTokens":128,"stopSequences":["END"]}}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.format), func(t *testing.T) {
+			converter, err := ai.NewFormatConverterFactory().GetConverter(tc.format)
+			if err != nil {
+				t.Fatalf("GetConverter failed: %v", err)
+			}
+			providerReq, err := converter.DecodeRequest(httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body)))
+			if err != nil {
+				t.Fatalf("DecodeRequest failed: %v", err)
+			}
+			req, err := converter.ConvertRequestFromFormat(providerReq)
+			if err != nil {
+				t.Fatalf("ConvertRequestFromFormat failed: %v", err)
+			}
+			if req.Temperature == nil || *req.Temperature != 0.3 || req.TopP == nil || *req.TopP != 0.9 ||
+				req.MaxTokens != 128 || !reflect.DeepEqual(req.StopSequences, []string{"END"}) {
+				t.Fatalf("expected sampling parameters in the universal request, got %+v", req)
+			}
+
+			// Send it on to an OpenAI backend, as the gateway would.
+			var sent map[string]any
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				json.NewDecoder(r.Body).Decode(&sent)
+				w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`))
+			}))
+			defer server.Close()
+			client, err := ai.NewClient(ai.WithProvider(ai.ProviderOpenAI), ai.WithAPIKey("test-key"), ai.WithBaseURL(server.URL))
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+			req.Model = "gpt-4o"
+			if _, err := client.Generate(context.Background(), req); err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+			want := map[string]any{"temperature": 0.3, "top_p": 0.9, "max_tokens": 128.0, "stop": []any{"END"}}
+			for key, value := range want {
+				if !reflect.DeepEqual(sent[key], value) {
+					t.Errorf("%s: expected %v in the backend request, got %v", key, value, sent[key])
+				}
+			}
+		})
+	}
+}
+
+func TestOpenAIStopSequences(t *testing.T) {
+	converter, err := ai.NewFormatConverterFactory().GetConverter(ai.ProviderOpenAI)
+	if err != nil {
+		t.Fatalf("GetConverter failed: %v", err)
+	}
+	for stop, want := range map[string][]string{
+		`"END"`:          {"END"},
+		`["END","STOP"]`: {"END", "STOP"},
+		`null`:           nil,
+	} {
+		body := `{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}],"stop":` + stop + `}`
+		providerReq, err := converter.DecodeRequest(httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body)))
+		if err != nil {
+			t.Fatalf("%s: DecodeRequest failed: %v", stop, err)
+		}
+		req, err := converter.ConvertRequestFromFormat(providerReq)
+		if err != nil {
+			t.Fatalf("%s: ConvertRequestFromFormat failed: %v", stop, err)
+		}
+		if !reflect.DeepEqual(req.StopSequences, want) {
+			t.Errorf("stop %s: expected stop sequences %q, got %q", stop, want, req.StopSequences)
+		}
+	}
+}
+
+// TestConverterNumberPrecision tests that numbers in tool calls survive
+// conversion to the universal format exactly as the client sent them
+func TestConverterNumberPrecision(t *testing.T) {
+	testCases := []struct {
+		format ai.Provider
+		path   string
+		body   string
+	}{
+		{ai.ProviderOpenAI, "/v1/chat/completions", `{"model":"gpt-4o","messages":[{"role":"user","content":"count"},
+			{"role":"assistant","tool_calls":[{"id":"call_1","type":"function","function":{"name":"count","arguments":"{\"n\":12345678901234567890,\"x\":0.1}"}}]},
+			{"role":"tool","tool_call_id":"call_1","content":"done"}]}`},
+		{ai.ProviderAnthropic, "/v1/messages", `{"model":"gpt-4o","max_tokens":16,"messages":[{"role":"user","content":"count"},
+			{"role":"assistant","content":[{"type":"tool_use","id":"call_1","name":"count","input":{"n":12345678901234567890,"x":0.1}}]},
+			{"role":"user","content":[{"type":"tool_result","tool_use_id":"call_1","content":"done"}]}]}`},
+		{ai.ProviderGemini, "/v1beta/models/gpt-4o:generateContent", `{"contents":[{"role":"user","parts":[{"text":"count"}]},
+			{"role":"model","parts":[{"functionCall":{"name":"count","args":{"n":12345678901234567890,"x":0.1}}}]},
+			{"role":"user","parts":[{"functionResponse":{"name":"count","response":{"result":"done"}}}]}]}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.format), func(t *testing.T) {
+			converter, err := ai.NewFormatConverterFactory().GetConverter(tc.format)
+			if err != nil {
+				t.Fatalf("GetConverter failed: %v", err)
+			}
+			providerReq, err := converter.DecodeRequest(httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body)))
+			if err != nil {
+				t.Fatalf("DecodeRequest failed: %v", err)
+			}
+			req, err := converter.ConvertRequestFromFormat(providerReq)
+			if err != nil {
+				t.Fatalf("ConvertRequestFromFormat failed: %v", err)
+			}
+
+			if len(req.Messages) < 2 || len(req.Messages[1].ToolCalls) != 1 {
+				t.Fatalf("expected the assistant tool call in the universal request, got %+v", req.Messages)
+			}
+			var args map[string]json.RawMessage
+			if err := json.Unmarshal([]byte(req.Messages[1].ToolCalls[0].Arguments), &args); err != nil {
+				t.Fatalf("invalid tool call arguments: %v", err)
+			}
+			if string(args["n"]) != "12345678901234567890" || string(args["x"]) != "0.1" {
+				t.Errorf("expected the tool call arguments exactly as sent, got %s", req.Messages[1].ToolCalls[0].Arguments)
+			}
+		})
+	}
+}
+
+// lookupPath walks a decoded JSON value along a dotted path of object keys
+// and array indexes, returning nil if the path does not exist.
+func lookupPath(v any, path string) any {
+	for _, key := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]any:
+			v = node[key]
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i >= len(node) {
+				return nil
+			}
+			v = node[i]
+		default:
+			return nil
+		}
+	}
+	return v
+}
+
 func TestInvalidFormat(t *testing.T) {
 	t.Run("Invalid source format", func(t *testing.T) {
 		_, err := ai.ConvertRequest("invalid", []byte("{}"))
@@ -2,17 +2,31 @@ package ai
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/hex"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"strings"
 	"sync"
+	"time"
 )
 
+// geminiDefaultMaxOutputTokens is sent as maxOutputTokens when neither the
+// request nor the model's known output limit sets one.
+const geminiDefaultMaxOutputTokens = 8192
+
 // geminiAdapter implements the providerAdapter interface for Google Gemini.
-type geminiAdapter struct{}
+type geminiAdapter struct {
+	// downloadTimeout bounds each media download; see WithDownloadTimeout.
+	downloadTimeout time.Duration
+	// downloadBudget is the share of the deadline downloads may use; see WithDownloadBudget.
+	downloadBudget float64
+	// fetchMedia replaces the built-in downloaders when set; see WithMediaFetcher.
+	fetchMedia func(ctx context.Context, url string) ([]byte, string, error)
+	// imageMaxDim bounds downloaded images; see WithImageMaxDimension.
+	imageMaxDim int
+	params      paramPolicy
+}
 
 func (a *geminiAdapter) getModel(req *Request) string {
 	if req.Model == "" {
@@ -37,6 +51,24 @@ func (a *geminiAdapter) newStreamDecoder(r io.Reader) streamDecoder {
 // buildRequestPayload converts the universal Request into the provider-specific
 // request body struct. It handles parallel downloading of external media resources.
 func (a *geminiAdapter) buildRequestPayload(ctx context.Context, req *Request) (any, error) {
+	req = req.hoistSystemMessage()
+	// Resolve unsupported parameters before any media is downloaded.
+	if len(req.LogitBias) > 0 {
+		if err := a.params.unsupported(ProviderGemini, a.getModel(req), "LogitBias"); err != nil {
+			return nil, err
+		}
+	}
+	if req.ReasoningEffort != "" {
+		if err := a.params.unsupported(ProviderGemini, a.getModel(req), "ReasoningEffort"); err != nil {
+			return nil, err
+		}
+	}
+	if req.Logprobs {
+		if err := a.params.unsupported(ProviderGemini, a.getModel(req), "Logprobs"); err != nil {
+			return nil, err
+		}
+	}
+
 	// 1. Prepare skeleton contents and identify download tasks
 	contents, tasks, err := a.prepareContents(req)
 	if err != nil {
@@ -50,6 +82,13 @@ func (a *geminiAdapter) buildRequestPayload(ctx context.Context, req *Request) (
 		}
 	}
 
+	// Gemini has no reliable assistant prefill; emulate it, as for OpenAI, by
+	// asking the model to continue the trailing model turn.
+	if _, ok := req.AssistantPrefill(); ok {
+		instruction := prefillInstruction
+		contents = append(contents, geminiContent{Role: "user", Parts: []geminiPart{{Text: &instruction}}})
+	}
+
 	// 3. Assemble final request
 	geminiReq := &geminiGenerateContentRequest{
 		Contents: contents,
@@ -87,7 +126,22 @@ func (a *geminiAdapter) buildRequestPayload(ctx context.Context, req *Request) (
 
 	// Configuration
 	geminiReq.GenerationConfig = &geminiGenConfig{
-		MaxOutputTokens: 8192,
+		MaxOutputTokens: geminiDefaultMaxOutputTokens,
+		StopSequences:   req.StopSequences,
+		Temperature:     req.Temperature,
+		TopP:            req.TopP,
+		TopK:            req.TopK,
+	}
+	if req.MaxTokens > 0 {
+		geminiReq.GenerationConfig.MaxOutputTokens = req.MaxTokens
+	}
+	if req.Thinking != nil {
+		config := geminiReq.GenerationConfig
+		config.ThinkingConfig = &geminiThinkingConfig{ThinkingBudget: req.Thinking.BudgetTokens, IncludeThoughts: true}
+		// Thinking counts against maxOutputTokens, so leave room for the answer.
+		if config.MaxOutputTokens <= req.Thinking.BudgetTokens {
+			config.MaxOutputTokens = req.Thinking.BudgetTokens + geminiDefaultMaxOutputTokens
+		}
 	}
 
 	return geminiReq, nil
@@ -101,7 +155,7 @@ type downloadTask struct {
 }
 
 func (a *geminiAdapter) prepareContents(req *Request) ([]geminiContent, []*downloadTask, error) {
-	contents := make([]geminiContent, len(req.Messages))
+	contents := make([]geminiContent, 0, len(req.Messages))
 	var allTasks []*downloadTask
 
 	for i, msg := range req.Messages {
@@ -110,12 +164,19 @@ func (a *geminiAdapter) prepareContents(req *Request) ([]geminiContent, []*downl
 		if err != nil {
 			return nil, nil, fmt.Errorf("message[%d]: %w", i, err)
 		}
+		allTasks = append(allTasks, tasks...)
 
-		contents[i] = geminiContent{
+		// Gemini expects the responses to a turn's function calls together in
+		// one user content, so consecutive tool results are grouped.
+		if msg.Role == RoleTool && i > 0 && req.Messages[i-1].Role == RoleTool {
+			last := &contents[len(contents)-1]
+			last.Parts = append(last.Parts, parts...)
+			continue
+		}
+		contents = append(contents, geminiContent{
 			Role:  role,
 			Parts: parts,
-		}
-		allTasks = append(allTasks, tasks...)
+		})
 	}
 
 	return contents, allTasks, nil
@@ -136,9 +197,13 @@ func (a *geminiAdapter) processMessageParts(msg Message, allMsgs []Message, msgI
 	var parts []geminiPart
 	var tasks []*downloadTask
 
-	// 1. Handle ContentParts (Multimodal)
+	// 1. Handle ContentParts (Multimodal). This applies to every role: model
+	// turns and tool results may carry images as inline data too.
 	if len(msg.ContentParts) > 0 {
 		for _, part := range msg.ContentParts {
+			if part.Type == ContentTypeText && part.Text == "" {
+				continue // Gemini rejects empty parts, e.g. beside function calls
+			}
 			p, t, err := a.processSinglePart(part)
 			if err != nil {
 				return nil, nil, err
@@ -157,8 +222,8 @@ func (a *geminiAdapter) processMessageParts(msg Message, allMsgs []Message, msgI
 	// 3. Handle Tool Calls (Assistant -> Model)
 	if msg.Role == RoleAssistant && len(msg.ToolCalls) > 0 {
 		for _, tc := range msg.ToolCalls {
-			var args map[string]any
-			if err := json.Unmarshal([]byte(tc.Arguments), &args); err != nil {
+			args, err := decodeArguments(tc.Arguments)
+			if err != nil {
 				return nil, nil, fmt.Errorf("invalid tool call arguments: %w", err)
 			}
 			parts = append(parts, geminiPart{
@@ -173,23 +238,14 @@ func (a *geminiAdapter) processMessageParts(msg Message, allMsgs []Message, msgI
 
 	// 4. Handle Tool Responses (Tool -> User)
 	if msg.Role == RoleTool {
-		// Find matching tool call in previous messages
-		// Note: This relies on the convention that tool response follows tool call.
-		// In complex histories, we might need a better lookup, but this matches original logic.
-		var matchingToolCall *ToolCall
-		if msgIdx > 0 {
-			prevMsg := allMsgs[msgIdx-1]
-			for _, tc := range prevMsg.ToolCalls {
-				if tc.ID == msg.ToolCallID {
-					matchingToolCall = &tc
-					break
-				}
-			}
-		}
+		// Find the matching tool call in earlier assistant messages. Results
+		// for parallel calls follow each other, so the call is not
+		// necessarily in the previous message.
+		matchingToolCall := findToolCall(allMsgs[:msgIdx], msg.ToolCallID)
 
 		if matchingToolCall != nil {
-			var responseData map[string]any
-			if err := json.Unmarshal([]byte(msg.Content), &responseData); err != nil {
+			responseData, err := decodeArguments(msg.Content)
+			if err != nil {
 				// Wrap raw content if not JSON
 				responseData = map[string]any{"content": msg.Content}
 			}
@@ -211,15 +267,28 @@ func (a *geminiAdapter) processMessageParts(msg Message, allMsgs []Message, msgI
 	return parts, tasks, nil
 }
 
+// findToolCall returns the most recent tool call with the given ID in msgs, or
+// nil if there is none.
+func findToolCall(msgs []Message, id string) *ToolCall {
+	for i := len(msgs) - 1; i >= 0; i-- {
+		for j := range msgs[i].ToolCalls {
+			if msgs[i].ToolCalls[j].ID == id {
+				return &msgs[i].ToolCalls[j]
+			}
+		}
+	}
+	return nil
+}
+
 func (a *geminiAdapter) processSinglePart(part ContentPart) (geminiPart, *downloadTask, error) {
+	if err := part.checkSource(); err != nil {
+		return geminiPart{}, nil, err
+	}
 	switch part.Type {
 	case ContentTypeText:
 		return geminiPart{Text: &part.Text}, nil, nil
 
 	case ContentTypeImage:
-		if part.ImageSource == nil {
-			return geminiPart{}, nil, nil
-		}
 		if part.ImageSource.Type == ImageSourceTypeURL {
 			// Create placeholder part to be filled by download task
 			p := geminiPart{InlineData: &geminiInlineData{}}
@@ -245,19 +314,8 @@ func (a *geminiAdapter) processSinglePart(part ContentPart) (geminiPart, *downlo
 		}
 
 	case ContentTypeAudio:
-		if part.AudioSource == nil {
-			return geminiPart{}, nil, nil
-		}
 		if part.AudioSource.Type == MediaSourceTypeURL {
-			p := geminiPart{InlineData: &geminiInlineData{}}
-			// Store format in MimeType temporarily or deduce later?
-			// The download task needs to know the expected format to set MimeType correctly
-			// We can set a temporary MimeType based on format and fix it if needed
-			mimeType := "audio/" + part.AudioSource.Format
-			if part.AudioSource.Format == "mp3" {
-				mimeType = "audio/mpeg"
-			}
-			p.InlineData.MimeType = mimeType
+			p := geminiPart{InlineData: &geminiInlineData{MimeType: geminiAudioMIMEType(part.AudioSource.Format)}}
 
 			return p, &downloadTask{
 				URL:        part.AudioSource.URL,
@@ -265,20 +323,13 @@ func (a *geminiAdapter) processSinglePart(part ContentPart) (geminiPart, *downlo
 				TargetPart: &p,
 			}, nil
 		} else {
-			mimeType := "audio/" + part.AudioSource.Format
-			if part.AudioSource.Format == "mp3" {
-				mimeType = "audio/mpeg"
-			}
 			return geminiPart{InlineData: &geminiInlineData{
-				MimeType: mimeType,
-				Data:     part.AudioSource.Data,
+				MimeType: geminiAudioMIMEType(part.AudioSource.Format),
+				Data:     cleanBase64(part.AudioSource.Data),
 			}}, nil, nil
 		}
 
 	case ContentTypeVideo:
-		if part.VideoSource == nil {
-			return geminiPart{}, nil, nil
-		}
 		if part.VideoSource.Type == MediaSourceTypeURL {
 			p := geminiPart{InlineData: &geminiInlineData{}}
 			mimeType := "video/" + part.VideoSource.Format
@@ -299,14 +350,11 @@ func (a *geminiAdapter) processSinglePart(part ContentPart) (geminiPart, *downlo
 			}
 			return geminiPart{InlineData: &geminiInlineData{
 				MimeType: mimeType,
-				Data:     part.VideoSource.Data,
+				Data:     cleanBase64(part.VideoSource.Data),
 			}}, nil, nil
 		}
 
 	case ContentTypeDocument:
-		if part.DocumentSource == nil {
-			return geminiPart{}, nil, nil
-		}
 		if part.DocumentSource.Type == MediaSourceTypeURL {
 			p := geminiPart{InlineData: &geminiInlineData{MimeType: part.DocumentSource.MimeType}}
 			return p, &downloadTask{
@@ -317,25 +365,65 @@ func (a *geminiAdapter) processSinglePart(part ContentPart) (geminiPart, *downlo
 		} else {
 			return geminiPart{InlineData: &geminiInlineData{
 				MimeType: part.DocumentSource.MimeType,
-				Data:     part.DocumentSource.Data,
+				Data:     cleanBase64(part.DocumentSource.Data),
 			}}, nil, nil
 		}
 
 	default:
-		return geminiPart{}, nil, fmt.Errorf("unsupported content type: %s", part.Type)
+		return geminiPart{}, nil, NewUnsupportedContentError(ProviderGemini, part.Type)
 	}
 }
 
-func cleanBase64(data string) string {
-	if strings.HasPrefix(data, "data:") {
-		if idx := strings.Index(data, ","); idx != -1 {
-			return data[idx+1:]
+// geminiAudioMIMETypes maps AudioSource formats to the MIME types Gemini
+// accepts. Other formats are sent as "audio/" + format.
+var geminiAudioMIMETypes = map[string]string{
+	"mp3":  "audio/mpeg",
+	"m4a":  "audio/mp4",
+	"aif":  "audio/aiff",
+	"aiff": "audio/aiff",
+	"wav":  "audio/wav",
+	"aac":  "audio/aac",
+	"ogg":  "audio/ogg",
+	"flac": "audio/flac",
+}
+
+// geminiAudioMIMEType returns the MIME type for an AudioSource format.
+func geminiAudioMIMEType(format string) string {
+	format = strings.ToLower(format)
+	if mimeType, ok := geminiAudioMIMETypes[format]; ok {
+		return mimeType
+	}
+	return "audio/" + format
+}
+
+// fetchWithHook downloads t with the WithMediaFetcher function and returns it
+// base64-encoded, filling in the part's MIME type if the request left it unset.
+func (a *geminiAdapter) fetchWithHook(ctx context.Context, t *downloadTask) (string, error) {
+	data, mimeType, err := a.fetchMedia(ctx, t.URL)
+	if err != nil {
+		return "", err
+	}
+	limit := maxMediaSize
+	if t.Type == ContentTypeImage {
+		limit = maxImageSize
+	}
+	if len(data) > limit {
+		return "", fmt.Errorf("media exceeds maximum size of %d bytes", limit)
+	}
+	if t.TargetPart.InlineData.MimeType == "" {
+		if mimeType == "" && t.Type == ContentTypeImage {
+			mimeType = imageMIMEType(detectImageFormat("", t.URL))
 		}
+		t.TargetPart.InlineData.MimeType = mimeType
 	}
-	return data
+	return base64.StdEncoding.EncodeToString(data), nil
 }
 
-func (a *geminiAdapter) executeDownloads(ctx context.Context, tasks []*downloadTask) error {
+func (a *geminiAdapter) executeDownloads(parent context.Context, tasks []*downloadTask) error {
+	ctx, cancelBudget := downloadBudgetContext(parent, a.downloadBudget)
+	defer cancelBudget()
+	begin := time.Now()
+
 	var wg sync.WaitGroup
 	// Buffered channel to collect first error
 	errChan := make(chan error, len(tasks))
@@ -350,26 +438,35 @@ func (a *geminiAdapter) executeDownloads(ctx context.Context, tasks []*downloadT
 			// Acquire semaphore
 			select {
 			case sem <- struct{}{}:
+				defer func() { <-sem }()
 			case <-ctx.Done():
-				return // Context cancelled, skip
 			}
-			defer func() { <-sem }()
 
 			// Check context again
 			if ctx.Err() != nil {
+				if parent.Err() == nil {
+					// The budget ran out while this download was queued.
+					errChan <- fmt.Errorf("download failed for %s: %w", t.URL,
+						newTimeoutError(string(ProviderGemini), TimeoutKindDownload, time.Since(begin).Round(time.Millisecond), ctx.Err()))
+				}
 				return
 			}
 
 			var data, format string
 			var err error
 
+			// Give each download its own deadline so one slow asset cannot
+			// consume the budget of the generation call.
+			dctx, cancel := downloadContext(ctx, a.downloadTimeout)
+			defer cancel()
+			start := time.Now()
+
 			// Use appropriate downloader based on type
-			// Note: We use a shorter timeout for individual downloads if needed,
-			// but relying on parent context is usually better.
-			// We'll trust the parent context to handle overall timeout.
-			switch t.Type {
-			case ContentTypeImage:
-				data, format, err = downloadImageToBase64(ctx, t.URL)
+			switch {
+			case a.fetchMedia != nil:
+				data, err = a.fetchWithHook(dctx, t)
+			case t.Type == ContentTypeImage:
+				data, format, err = downloadImageToBase64(dctx, t.URL)
 				if err == nil && t.TargetPart.InlineData.MimeType == "" {
 					// Detect mimetype if not already set (for images)
 					t.TargetPart.InlineData.MimeType = "image/" + format
@@ -379,9 +476,20 @@ func (a *geminiAdapter) executeDownloads(ctx context.Context, tasks []*downloadT
 				}
 			default:
 				// Audio, Video, Document use generic downloader
-				data, err = downloadMediaToBase64(ctx, t.URL)
+				data, err = downloadMediaToBase64(dctx, t.URL)
+			}
+			if err == nil && t.Type == ContentTypeImage && a.imageMaxDim > 0 {
+				var resized, format string
+				if resized, format, err = resizeBase64Image(data, a.imageMaxDim); err == nil && format != "" {
+					data = resized
+					t.TargetPart.InlineData.MimeType = "image/" + format
+				}
 			}
 
+			if err != nil && dctx.Err() == context.DeadlineExceeded && parent.Err() == nil {
+				// Cut off by the download timeout or budget rather than the caller.
+				err = newTimeoutError(string(ProviderGemini), TimeoutKindDownload, time.Since(start).Round(time.Millisecond), err)
+			}
 			if err != nil {
 				// Non-blocking send to error channel
 				select {
@@ -404,8 +512,8 @@ func (a *geminiAdapter) executeDownloads(ctx context.Context, tasks []*downloadT
 		return err
 	}
 	// Check context one last time
-	if ctx.Err() != nil {
-		return ctx.Err()
+	if parent.Err() != nil {
+		return parent.Err()
 	}
 
 	return nil
@@ -413,17 +521,45 @@ func (a *geminiAdapter) executeDownloads(ctx context.Context, tasks []*downloadT
 
 func (a *geminiAdapter) parseResponse(providerResp []byte) (*Response, error) {
 	var geminiResp geminiGenerateContentResponse
-	if err := json.Unmarshal(providerResp, &geminiResp); err != nil {
+	if err := decodeFirstJSON(providerResp, &geminiResp); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal gemini response: %w", err)
 	}
 	if len(geminiResp.Candidates) == 0 {
-		return &Response{}, nil
+		if fb := geminiResp.PromptFeedback; fb != nil && fb.BlockReason != "" {
+			return nil, NewContentFilterError(string(ProviderGemini), fb.BlockReason)
+		}
+		return nil, fmt.Errorf("gemini response has no candidates")
 	}
 	candidate := geminiResp.Candidates[0]
-	universalResp := &Response{}
+	universalResp := &Response{
+		FinishReason: geminiFinishReason(candidate.FinishReason),
+		Citations:    geminiCitations(candidate.GroundingMetadata),
+	}
+	if candidate.FinishMessage != "" {
+		universalResp.Warnings = append(universalResp.Warnings, candidate.FinishMessage)
+	}
+	if u := geminiResp.UsageMetadata; u != nil {
+		// Gemini counts thoughts separately from the candidates.
+		universalResp.Usage = &Usage{
+			InputTokens:     u.PromptTokenCount,
+			OutputTokens:    u.CandidatesTokenCount + u.ThoughtsTokenCount,
+			TotalTokens:     u.TotalTokenCount,
+			ReasoningTokens: u.ThoughtsTokenCount,
+		}
+	}
 	for _, part := range candidate.Content.Parts {
 		if part.Text != nil {
-			universalResp.Text += *part.Text
+			if part.Thought {
+				universalResp.Reasoning += *part.Text
+			} else {
+				universalResp.Text += *part.Text
+			}
+		}
+		if part.InlineData != nil && !part.Thought {
+			universalResp.Media = append(universalResp.Media, MediaOutput{
+				MimeType: part.InlineData.MimeType,
+				Data:     part.InlineData.Data,
+			})
 		}
 		if part.FunctionCall != nil {
 			args, err := json.Marshal(part.FunctionCall.Args)
@@ -431,13 +567,8 @@ func (a *geminiAdapter) parseResponse(providerResp []byte) (*Response, error) {
 				return nil, fmt.Errorf("failed to marshal gemini function call args: %w", err)
 			}
 			// Gemini API does not provide a tool_call_id, so we generate one.
-			// Using crypto/rand for a secure random ID.
-			randBytes := make([]byte, 8)
-			if _, err := rand.Read(randBytes); err != nil {
-				return nil, fmt.Errorf("failed to generate random tool call ID: %w", err)
-			}
 			toolCall := ToolCall{
-				ID:               "gemini-tool-call-" + hex.EncodeToString(randBytes),
+				ID:               "gemini-tool-call-" + generateRandomID(16),
 				Type:             "function",
 				Function:         part.FunctionCall.Name,
 				Arguments:        string(args),
@@ -446,9 +577,27 @@ func (a *geminiAdapter) parseResponse(providerResp []byte) (*Response, error) {
 			universalResp.ToolCalls = append(universalResp.ToolCalls, toolCall)
 		}
 	}
+	// Gemini reports STOP for function calls; surface them as tool calls like the other providers.
+	if len(universalResp.ToolCalls) > 0 && universalResp.FinishReason == FinishReasonStop {
+		universalResp.FinishReason = FinishReasonToolCalls
+	}
 	return universalResp, nil
 }
 
+// geminiFinishReason normalizes a Gemini finishReason.
+func geminiFinishReason(reason string) FinishReason {
+	switch reason {
+	case "STOP":
+		return FinishReasonStop
+	case "MAX_TOKENS":
+		return FinishReasonLength
+	case "SAFETY", "RECITATION", "BLOCKLIST", "PROHIBITED_CONTENT", "SPII", "IMAGE_SAFETY":
+		return FinishReasonContentFilter
+	default:
+		return FinishReason(strings.ToLower(reason))
+	}
+}
+
 func (a *geminiAdapter) enableStreaming(payload any) {
 	// Gemini uses a dedicated streaming endpoint; no payload changes needed.
 }
@@ -464,10 +613,10 @@ func (a *geminiAdapter) parseStreamEvent(event *sseEvent, acc *streamAccumulator
 	}
 
 	var chunkResp geminiStreamResponse
-	if err := json.Unmarshal(event.Data, &chunkResp); err != nil {
+	if err := decodeFirstJSON(event.Data, &chunkResp); err != nil {
 		// Some responses are wrapped in an array; try to decode that.
 		var arr []geminiStreamResponse
-		if errArr := json.Unmarshal(event.Data, &arr); errArr == nil && len(arr) > 0 {
+		if errArr := decodeFirstJSON(event.Data, &arr); errArr == nil && len(arr) > 0 {
 			chunkResp = arr[0]
 		} else {
 			return nil, false, fmt.Errorf("failed to unmarshal gemini stream event: %w", err)
@@ -479,15 +628,22 @@ func (a *geminiAdapter) parseStreamEvent(event *sseEvent, acc *streamAccumulator
 	}
 
 	if len(chunkResp.Candidates) == 0 {
+		if fb := chunkResp.PromptFeedback; fb != nil && fb.BlockReason != "" {
+			return nil, false, NewContentFilterError(string(ProviderGemini), fb.BlockReason)
+		}
 		return nil, false, nil
 	}
 
 	candidate := chunkResp.Candidates[0]
-	chunk := &StreamChunk{}
+	chunk := &StreamChunk{Citations: geminiCitations(candidate.GroundingMetadata)}
 
 	for _, part := range candidate.Content.Parts {
 		if part.Text != nil {
-			chunk.TextDelta += *part.Text
+			if part.Thought {
+				chunk.ReasoningDelta += *part.Text
+			} else {
+				chunk.TextDelta += *part.Text
+			}
 		}
 		if part.FunctionCall != nil {
 			args, err := json.Marshal(part.FunctionCall.Args)
@@ -521,15 +677,53 @@ func (a *geminiAdapter) parseStreamEvent(event *sseEvent, acc *streamAccumulator
 	done := candidate.FinishReason != ""
 	if done {
 		chunk.Done = true
+		chunk.FinishReason = geminiFinishReason(candidate.FinishReason)
+		// Gemini reports STOP after function calls, as in parseResponse.
+		if chunk.FinishReason == FinishReasonStop && (len(acc.order) > 0 || len(chunk.ToolCallDeltas) > 0) {
+			chunk.FinishReason = FinishReasonToolCalls
+		}
 	}
 
-	if chunk.TextDelta == "" && len(chunk.ToolCallDeltas) == 0 && !chunk.Done {
+	if chunk.TextDelta == "" && chunk.ReasoningDelta == "" && len(chunk.ToolCallDeltas) == 0 && len(chunk.Citations) == 0 && !chunk.Done {
 		return nil, false, nil
 	}
 
 	return chunk, done, nil
 }
 
+// geminiCitations converts grounding metadata into one citation per supported
+// segment and source, or one per web source when no supports are given.
+func geminiCitations(md *geminiGroundingMetadata) []Citation {
+	if md == nil {
+		return nil
+	}
+	var citations []Citation
+	if len(md.GroundingSupports) == 0 {
+		for _, gc := range md.GroundingChunks {
+			if gc.Web != nil {
+				citations = append(citations, Citation{URL: gc.Web.URI, Title: gc.Web.Title})
+			}
+		}
+		return citations
+	}
+	for _, support := range md.GroundingSupports {
+		for _, idx := range support.GroundingChunkIndices {
+			if idx < 0 || idx >= len(md.GroundingChunks) || md.GroundingChunks[idx].Web == nil {
+				continue
+			}
+			web := md.GroundingChunks[idx].Web
+			citations = append(citations, Citation{
+				URL:        web.URI,
+				Title:      web.Title,
+				Text:       support.Segment.Text,
+				StartIndex: support.Segment.StartIndex,
+				EndIndex:   support.Segment.EndIndex,
+			})
+		}
+	}
+	return citations
+}
+
 // cleanJSONSchemaForGemini removes fields from JSON Schema that Gemini API doesn't support.
 // Specifically removes: $schema, additionalProperties (recursively)
 func cleanJSONSchemaForGemini(schema json.RawMessage) (json.RawMessage, error) {
@@ -1,8 +1,6 @@
 package ai
 
 import (
-	"crypto/rand"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -21,7 +19,9 @@ func NewGeminiFormatConverter() *GeminiFormatConverter {
 // DecodeRequest decodes the request body into the Gemini request struct.
 func (c *GeminiFormatConverter) DecodeRequest(r *http.Request) (any, error) {
 	var req GeminiGenerateContentRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	dec := json.NewDecoder(r.Body)
+	dec.UseNumber() // Keep numbers in tool inputs and schemas exact
+	if err := dec.Decode(&req); err != nil {
 		return nil, fmt.Errorf("failed to decode Gemini request: %w", err)
 	}
 
@@ -70,6 +70,32 @@ func (c *GeminiFormatConverter) ConvertResponseToFormat(universalResp *Response,
 	return c.ConvertResponseToGemini(universalResp)
 }
 
+// ConvertErrorToFormat converts an error to the Google API error format used by Gemini.
+func (c *GeminiFormatConverter) ConvertErrorToFormat(statusCode int, message string) any {
+	status := "INVALID_ARGUMENT"
+	switch statusCode {
+	case http.StatusUnauthorized:
+		status = "UNAUTHENTICATED"
+	case http.StatusForbidden:
+		status = "PERMISSION_DENIED"
+	case http.StatusNotFound:
+		status = "NOT_FOUND"
+	case http.StatusTooManyRequests:
+		status = "RESOURCE_EXHAUSTED"
+	case http.StatusNotImplemented:
+		status = "UNIMPLEMENTED"
+	case http.StatusServiceUnavailable:
+		status = "UNAVAILABLE"
+	case http.StatusGatewayTimeout:
+		status = "DEADLINE_EXCEEDED"
+	default:
+		if statusCode >= http.StatusInternalServerError {
+			status = "INTERNAL"
+		}
+	}
+	return &GeminiErrorResponse{Error: GeminiError{Code: statusCode, Message: message, Status: status}}
+}
+
 // ConvertRequestToUniversal converts a Gemini request to Universal format.
 func (c *GeminiFormatConverter) ConvertRequestToUniversal(geminiReq *GeminiGenerateContentRequest) (*Request, error) {
 	if geminiReq == nil {
@@ -79,6 +105,13 @@ func (c *GeminiFormatConverter) ConvertRequestToUniversal(geminiReq *GeminiGener
 	universalReq := &Request{
 		Messages: make([]Message, 0, len(geminiReq.Contents)),
 	}
+	if config := geminiReq.GenerationConfig; config != nil {
+		universalReq.StopSequences = config.StopSequences
+		universalReq.Temperature = config.Temperature
+		universalReq.TopP = config.TopP
+		universalReq.TopK = config.TopK
+		universalReq.MaxTokens = config.MaxOutputTokens
+	}
 
 	// Extract system instruction if present
 	if geminiReq.SystemInstruction != nil && len(geminiReq.SystemInstruction.Parts) > 0 {
@@ -153,10 +186,8 @@ func (c *GeminiFormatConverter) ConvertRequestToUniversal(geminiReq *GeminiGener
 						return nil, fmt.Errorf("failed to marshal function call args: %w", err)
 					}
 					// Generate ID for the tool call
-					randBytes := make([]byte, 8)
-					rand.Read(randBytes)
 					msg.ToolCalls = append(msg.ToolCalls, ToolCall{
-						ID:               "gemini-" + hex.EncodeToString(randBytes),
+						ID:               "gemini-" + generateRandomID(16),
 						Type:             "function",
 						Function:         part.FunctionCall.Name,
 						Arguments:        string(args),
@@ -213,9 +244,17 @@ func (c *GeminiFormatConverter) ConvertResponseToGemini(universalResp *Response)
 					Parts: make([]geminiPart, 0),
 					Role:  "model",
 				},
+				FinishReason: geminiFinishReasonFor(universalResp.FinishReason),
 			},
 		},
 	}
+	if u := universalResp.Usage; u != nil {
+		geminiResp.UsageMetadata = &geminiUsageMetadata{
+			PromptTokenCount:     u.InputTokens,
+			CandidatesTokenCount: u.OutputTokens,
+			TotalTokenCount:      u.TotalTokens,
+		}
+	}
 
 	// Add text content if present
 	if universalResp.Text != "" {
@@ -228,8 +267,8 @@ func (c *GeminiFormatConverter) ConvertResponseToGemini(universalResp *Response)
 
 	// Add tool calls if present
 	for _, tc := range universalResp.ToolCalls {
-		var args map[string]any
-		if err := json.Unmarshal([]byte(tc.Arguments), &args); err != nil {
+		args, err := decodeArguments(tc.Arguments)
+		if err != nil {
 			return nil, fmt.Errorf("failed to unmarshal tool call arguments: %w", err)
 		}
 		geminiResp.Candidates[0].Content.Parts = append(
@@ -247,6 +286,19 @@ func (c *GeminiFormatConverter) ConvertResponseToGemini(universalResp *Response)
 	return geminiResp, nil
 }
 
+// geminiFinishReasonFor maps a normalized finish reason back to Gemini's;
+// Gemini reports tool calls, and responses without a reason, as STOP.
+func geminiFinishReasonFor(reason FinishReason) string {
+	switch reason {
+	case FinishReasonLength:
+		return "MAX_TOKENS"
+	case FinishReasonContentFilter:
+		return "SAFETY"
+	default:
+		return "STOP"
+	}
+}
+
 // --- Gemini Stream Handler ---
 
 type GeminiStreamHandler struct{}
@@ -296,8 +348,8 @@ func buildGeminiStreamChunk(chunk *StreamChunk) any {
 		})
 	}
 	for _, tc := range chunk.ToolCallDeltas {
-		var args map[string]any
-		if err := json.Unmarshal([]byte(tc.ArgumentsDelta), &args); err != nil {
+		args, err := decodeArguments(tc.ArgumentsDelta)
+		if err != nil {
 			args = map[string]any{"raw": tc.ArgumentsDelta}
 		}
 		candidate.Candidates[0].Content.Parts = append(candidate.Candidates[0].Content.Parts, geminiStreamPart{
@@ -309,7 +361,7 @@ func buildGeminiStreamChunk(chunk *StreamChunk) any {
 		})
 	}
 	if chunk.Done {
-		candidate.Candidates[0].FinishReason = "STOP"
+		candidate.Candidates[0].FinishReason = geminiFinishReasonFor(chunk.FinishReason)
 	}
 	return candidate
 }
@@ -318,15 +370,30 @@ func buildGeminiStreamChunk(chunk *StreamChunk) any {
 
 // GeminiGenerateContentRequest represents a Gemini generateContent request.
 type GeminiGenerateContentRequest struct {
-	Contents          []geminiContent `json:"contents"`
-	Tools             []geminiTool    `json:"tools,omitempty"`
-	SystemInstruction *geminiContent  `json:"systemInstruction,omitempty"`
-	Stream            bool            `json:"stream,omitempty"`
+	Contents          []geminiContent  `json:"contents"`
+	Tools             []geminiTool     `json:"tools,omitempty"`
+	SystemInstruction *geminiContent   `json:"systemInstruction,omitempty"`
+	GenerationConfig  *geminiGenConfig `json:"generationConfig,omitempty"`
+	Stream            bool             `json:"stream,omitempty"`
 }
 
 // GeminiGenerateContentResponse represents a Gemini generateContent response.
 type GeminiGenerateContentResponse struct {
-	Candidates []geminiCandidate `json:"candidates"`
+	Candidates    []geminiCandidate    `json:"candidates"`
+	UsageMetadata *geminiUsageMetadata `json:"usageMetadata,omitempty"`
+}
+
+// GeminiErrorResponse is the body of a Gemini (Google API) error.
+type GeminiErrorResponse struct {
+	Error GeminiError `json:"error"`
+}
+
+// GeminiError describes a Google API error: the HTTP status code and its
+// canonical status name, e.g. 400 and "INVALID_ARGUMENT".
+type GeminiError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Status  string `json:"status"`
 }
 
 // Local Gemini streaming payload types (compatible with provider schema).
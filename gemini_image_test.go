@@ -4,9 +4,11 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -245,3 +247,264 @@ func TestGeminiImageBase64StillWorks(t *testing.T) {
 		t.Errorf("Unexpected response: %s", resp.Text)
 	}
 }
+
+// TestGeminiDownloadTimeout tests that a hanging media download is cut off by its own
+// deadline while a fast download in the same request still completes
+func TestGeminiDownloadTimeout(t *testing.T) {
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("fast image"))
+	}))
+	defer fast.Close()
+
+	release := make(chan struct{})
+	hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer hanging.Close()
+	defer close(release)
+
+	run := func(t *testing.T, adapter *geminiAdapter, ctx context.Context) {
+		t.Helper()
+		fastPart := geminiPart{InlineData: &geminiInlineData{}}
+		slowPart := geminiPart{InlineData: &geminiInlineData{MimeType: "application/pdf"}}
+		tasks := []*downloadTask{
+			{URL: fast.URL + "/a.png", Type: ContentTypeImage, TargetPart: &fastPart},
+			{URL: hanging.URL + "/b.pdf", Type: ContentTypeDocument, TargetPart: &slowPart},
+		}
+
+		start := time.Now()
+		err := adapter.executeDownloads(ctx, tasks)
+		elapsed := time.Since(start)
+
+		if err == nil || !strings.Contains(err.Error(), hanging.URL) {
+			t.Fatalf("Expected download error for the hanging URL, got: %v", err)
+		}
+		if elapsed > 2*time.Second {
+			t.Errorf("Expected hanging download to be cut off quickly, took %v", elapsed)
+		}
+		if ctx.Err() != nil {
+			t.Errorf("Expected parent context to remain usable, got %v", ctx.Err())
+		}
+		if want := base64.StdEncoding.EncodeToString([]byte("fast image")); fastPart.InlineData.Data != want {
+			t.Errorf("Expected fast download to be assigned %q, got %q", want, fastPart.InlineData.Data)
+		}
+		if slowPart.InlineData.Data != "" {
+			t.Errorf("Expected hanging download to be unassigned, got %q", slowPart.InlineData.Data)
+		}
+	}
+
+	t.Run("configured timeout", func(t *testing.T) {
+		run(t, &geminiAdapter{downloadTimeout: 200 * time.Millisecond}, context.Background())
+	})
+
+	t.Run("fraction of remaining deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		run(t, &geminiAdapter{}, ctx)
+	})
+}
+
+// TestGeminiDownloadBudget tests that a slow media download is cut off once it
+// has used its share of a tight request deadline, with a download timeout
+// error rather than a deadline error from the generation call
+func TestGeminiDownloadBudget(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer slow.Close()
+	defer close(release)
+
+	var calls int
+	geminiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":"ok"}]},"finishReason":"STOP"}]}`))
+	}))
+	defer geminiServer.Close()
+
+	client, err := NewClient(WithProvider(ProviderGemini), WithAPIKey("test-key"), WithBaseURL(geminiServer.URL),
+		WithDownloadTimeout(time.Minute), WithDownloadBudget(0.2))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	_, err = client.Generate(ctx, &Request{Messages: []Message{{
+		Role:         RoleUser,
+		ContentParts: []ContentPart{NewImagePartFromURL(slow.URL + "/slow.png")},
+	}}})
+	elapsed := time.Since(start)
+
+	var timeoutErr *TimeoutError
+	if !errors.As(err, &timeoutErr) || timeoutErr.Kind != TimeoutKindDownload {
+		t.Fatalf("expected a download timeout error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), slow.URL) {
+		t.Errorf("expected the error to name the slow URL, got %v", err)
+	}
+	if elapsed > time.Second || ctx.Err() != nil {
+		t.Errorf("expected the download cut off well before the deadline, took %v", elapsed)
+	}
+	if calls != 0 {
+		t.Errorf("expected no generation call after the failed download, got %d", calls)
+	}
+}
+
+// TestGeminiMediaFetcher tests that media URLs are fetched with the configured
+// fetcher instead of an HTTP request to the URL
+func TestGeminiMediaFetcher(t *testing.T) {
+	var mediaHits int
+	mediaServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mediaHits++
+	}))
+	defer mediaServer.Close()
+
+	var body struct {
+		Contents []struct {
+			Parts []struct {
+				InlineData *geminiInlineData `json:"inlineData"`
+			} `json:"parts"`
+		} `json:"contents"`
+	}
+	geminiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":"ok"}]},"finishReason":"STOP"}]}`))
+	}))
+	defer geminiServer.Close()
+
+	var mu sync.Mutex
+	var fetched []string
+	fetch := func(ctx context.Context, url string) ([]byte, string, error) {
+		mu.Lock()
+		fetched = append(fetched, url)
+		mu.Unlock()
+		if strings.HasSuffix(url, ".pdf") {
+			return []byte("%PDF-1.7"), "application/pdf", nil
+		}
+		return []byte("cat pixels"), "", nil
+	}
+	client, err := NewClient(WithProvider(ProviderGemini), WithAPIKey("test-key"), WithBaseURL(geminiServer.URL),
+		WithMediaFetcher(fetch))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	_, err = client.Generate(context.Background(), &Request{Messages: []Message{{
+		Role: RoleUser,
+		ContentParts: []ContentPart{
+			NewImagePartFromURL(mediaServer.URL + "/private/cat.jpg"),
+			NewDocumentPartFromURL(mediaServer.URL+"/private/report.pdf", ""),
+		},
+	}}})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	if mediaHits != 0 {
+		t.Errorf("expected no HTTP requests to the media URLs, got %d", mediaHits)
+	}
+	if len(fetched) != 2 {
+		t.Errorf("expected both URLs passed to the fetcher, got %v", fetched)
+	}
+	if len(body.Contents) != 1 || len(body.Contents[0].Parts) != 2 {
+		t.Fatalf("expected one turn with two parts, got %+v", body.Contents)
+	}
+	image, doc := body.Contents[0].Parts[0].InlineData, body.Contents[0].Parts[1].InlineData
+	if image == nil || image.MimeType != "image/jpeg" || image.Data != base64.StdEncoding.EncodeToString([]byte("cat pixels")) {
+		t.Errorf("expected the fetched image with a MIME type from its URL, got %+v", image)
+	}
+	if doc == nil || doc.MimeType != "application/pdf" || doc.Data != base64.StdEncoding.EncodeToString([]byte("%PDF-1.7")) {
+		t.Errorf("expected the fetched document with the fetcher's MIME type, got %+v", doc)
+	}
+}
+
+// TestGeminiAssistantMultimodalMessage tests that images in assistant and tool
+// messages become inline data in their own turns, alongside tool calls and results
+func TestGeminiAssistantMultimodalMessage(t *testing.T) {
+	imageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write([]byte("jpeg bytes"))
+	}))
+	defer imageServer.Close()
+
+	req := &Request{Messages: []Message{
+		{Role: RoleUser, Content: "Draw a cat, then crop it."},
+		{Role: RoleAssistant, ContentParts: []ContentPart{
+			NewTextPart("Here is the cat."),
+			NewImagePartFromBase64("Y2F0", "png"),
+		}, ToolCalls: []ToolCall{{ID: "call_1", Function: "crop", Arguments: `{"size":64}`}}},
+		{Role: RoleTool, ToolCallID: "call_1", Content: `{"ok":true}`, ContentParts: []ContentPart{
+			NewImagePartFromURL(imageServer.URL + "/cropped.jpg"),
+		}},
+	}}
+
+	payload, err := (&geminiAdapter{}).buildRequestPayload(context.Background(), req)
+	if err != nil {
+		t.Fatalf("buildRequestPayload failed: %v", err)
+	}
+	contents := payload.(*geminiGenerateContentRequest).Contents
+	if len(contents) != 3 {
+		t.Fatalf("expected 3 turns, got %d", len(contents))
+	}
+
+	model := contents[1]
+	if model.Role != "model" || len(model.Parts) != 3 {
+		t.Fatalf("expected a model turn with text, image and function call, got %+v", model)
+	}
+	if model.Parts[0].Text == nil || *model.Parts[0].Text != "Here is the cat." {
+		t.Errorf("expected the text part first, got %+v", model.Parts[0])
+	}
+	if data := model.Parts[1].InlineData; data == nil || data.MimeType != "image/png" || data.Data != "Y2F0" {
+		t.Errorf("expected the image as inline data, got %+v", model.Parts[1])
+	}
+	if call := model.Parts[2].FunctionCall; call == nil || call.Name != "crop" {
+		t.Errorf("expected the function call last, got %+v", model.Parts[2])
+	}
+
+	tool := contents[2]
+	if tool.Role != "user" || len(tool.Parts) != 2 {
+		t.Fatalf("expected a user turn with the image and function response, got %+v", tool)
+	}
+	want := base64.StdEncoding.EncodeToString([]byte("jpeg bytes"))
+	if data := tool.Parts[0].InlineData; data == nil || data.MimeType != "image/jpeg" || data.Data != want {
+		t.Errorf("expected the downloaded image as inline data, got %+v", tool.Parts[0])
+	}
+	if resp := tool.Parts[1].FunctionResponse; resp == nil || resp.Name != "crop" || resp.Response["ok"] != true {
+		t.Errorf("expected the function response, got %+v", tool.Parts[1])
+	}
+}
+
+// TestGeminiResponseMedia tests that images returned as inline data are
+// captured in Response.Media, and that text-only responses leave it empty
+func TestGeminiResponseMedia(t *testing.T) {
+	resp, err := (&geminiAdapter{}).parseResponse([]byte(`{"candidates":[{"content":{"role":"model","parts":[
+		{"text":"Here is a cat."},
+		{"inlineData":{"mimeType":"image/png","data":"Y2F0"}},
+		{"inlineData":{"mimeType":"image/png","data":"ZHJhZnQ="},"thought":true}
+	]},"finishReason":"STOP"}]}`))
+	if err != nil {
+		t.Fatalf("parseResponse failed: %v", err)
+	}
+	if resp.Text != "Here is a cat." {
+		t.Errorf("expected the text, got %q", resp.Text)
+	}
+	if len(resp.Media) != 1 || resp.Media[0] != (MediaOutput{MimeType: "image/png", Data: "Y2F0"}) {
+		t.Errorf("expected the image in Media without the thought image, got %+v", resp.Media)
+	}
+
+	resp, err = (&geminiAdapter{}).parseResponse([]byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":"ok"}]},"finishReason":"STOP"}]}`))
+	if err != nil {
+		t.Fatalf("parseResponse failed: %v", err)
+	}
+	if resp.Media != nil {
+		t.Errorf("expected no media for a text-only response, got %+v", resp.Media)
+	}
+}
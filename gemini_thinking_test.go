@@ -0,0 +1,84 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGeminiThinking(t *testing.T) {
+	var payload map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"candidates":[{"content":{"role":"model","parts":[
+			{"text":"Adding two and two.","thought":true},
+			{"text":"4"}]},"finishReason":"STOP"}],
+			"usageMetadata":{"promptTokenCount":8,"candidatesTokenCount":1,"thoughtsTokenCount":20,"totalTokenCount":29}}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithProvider(ProviderGemini), WithAPIKey("test-key"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	resp, err := client.Generate(context.Background(), &Request{
+		Messages: []Message{{Role: RoleUser, Content: "What is 2+2?"}},
+		Thinking: &ThinkingConfig{BudgetTokens: 10000},
+	})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	if resp.Text != "4" {
+		t.Errorf("expected answer text %q, got %q", "4", resp.Text)
+	}
+	if resp.Reasoning != "Adding two and two." {
+		t.Errorf("unexpected reasoning: %q", resp.Reasoning)
+	}
+	if resp.Usage == nil || resp.Usage.ReasoningTokens != 20 || resp.Usage.OutputTokens != 21 {
+		t.Errorf("expected thoughts counted as reasoning output tokens, got %+v", resp.Usage)
+	}
+
+	config, _ := payload["generationConfig"].(map[string]any)
+	thinking, _ := config["thinkingConfig"].(map[string]any)
+	if thinking["thinkingBudget"] != float64(10000) || thinking["includeThoughts"] != true {
+		t.Errorf("unexpected thinkingConfig payload: %v", config["thinkingConfig"])
+	}
+	if maxTokens, _ := config["maxOutputTokens"].(float64); maxTokens <= 10000 {
+		t.Errorf("expected maxOutputTokens above the thinking budget, got %v", config["maxOutputTokens"])
+	}
+}
+
+func TestGeminiThinkingStreaming(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `[{"candidates":[{"content":{"parts":[{"text":"Thinking...","thought":true}]}}]},
+			{"candidates":[{"content":{"parts":[{"text":"4"}]},"finishReason":"STOP"}]}]`)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithProvider(ProviderGemini), WithAPIKey("test-key"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	reader, err := Stream(context.Background(), client, &Request{
+		Messages: []Message{{Role: RoleUser, Content: "What is 2+2?"}},
+		Thinking: &ThinkingConfig{BudgetTokens: 1024},
+	})
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	resp, err := AccumulateStream(reader)
+	if err != nil {
+		t.Fatalf("AccumulateStream failed: %v", err)
+	}
+	if resp.Text != "4" || resp.Reasoning != "Thinking..." {
+		t.Errorf("expected thoughts separated from text, got text %q reasoning %q", resp.Text, resp.Reasoning)
+	}
+}
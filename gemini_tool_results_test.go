@@ -0,0 +1,45 @@
+package ai
+
+import (
+	"context"
+	"testing"
+)
+
+// TestGeminiGroupsToolResults tests that consecutive tool results become one
+// user turn of function responses, each matched to its call by ID
+func TestGeminiGroupsToolResults(t *testing.T) {
+	req := &Request{Messages: []Message{
+		{Role: RoleUser, Content: "Weather and time in Paris?"},
+		{Role: RoleAssistant, ToolCalls: []ToolCall{
+			{ID: "call_1", Function: "get_weather", Arguments: `{"city":"Paris"}`},
+			{ID: "call_2", Function: "get_time", Arguments: `{"city":"Paris"}`},
+		}},
+		{Role: RoleTool, ToolCallID: "call_2", Content: `{"time":"14:00"}`},
+		{Role: RoleTool, ToolCallID: "call_1", Content: "sunny"},
+		{Role: RoleUser, Content: "Thanks!"},
+	}}
+
+	payload, err := (&geminiAdapter{}).buildRequestPayload(context.Background(), req)
+	if err != nil {
+		t.Fatalf("buildRequestPayload failed: %v", err)
+	}
+	contents := payload.(*geminiGenerateContentRequest).Contents
+	if len(contents) != 4 {
+		t.Fatalf("expected 4 turns, got %d: %+v", len(contents), contents)
+	}
+
+	results := contents[2]
+	if results.Role != "user" || len(results.Parts) != 2 {
+		t.Fatalf("expected one user turn with both function responses, got %+v", results)
+	}
+	if resp := results.Parts[0].FunctionResponse; resp == nil || resp.Name != "get_time" || resp.Response["time"] != "14:00" {
+		t.Errorf("expected the get_time response first, got %+v", results.Parts[0])
+	}
+	if resp := results.Parts[1].FunctionResponse; resp == nil || resp.Name != "get_weather" || resp.Response["content"] != "sunny" {
+		t.Errorf("expected the get_weather response second, got %+v", results.Parts[1])
+	}
+
+	if last := contents[3]; last.Role != "user" || len(last.Parts) != 1 || last.Parts[0].Text == nil || *last.Parts[0].Text != "Thanks!" {
+		t.Errorf("expected the follow-up user turn to stay separate, got %+v", last)
+	}
+}
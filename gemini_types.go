@@ -12,7 +12,19 @@ type geminiGenerateContentRequest struct {
 }
 
 type geminiGenConfig struct {
-	MaxOutputTokens int `json:"maxOutputTokens,omitempty"`
+	MaxOutputTokens int                   `json:"maxOutputTokens,omitempty"`
+	StopSequences   []string              `json:"stopSequences,omitempty"`
+	Temperature     *float64              `json:"temperature,omitempty"`
+	TopP            *float64              `json:"topP,omitempty"`
+	TopK            *int                  `json:"topK,omitempty"`
+	ThinkingConfig  *geminiThinkingConfig `json:"thinkingConfig,omitempty"`
+}
+
+// geminiThinkingConfig enables thinking on Gemini 2.5 models. IncludeThoughts
+// asks for thought summaries, returned as parts marked Thought.
+type geminiThinkingConfig struct {
+	ThinkingBudget  int  `json:"thinkingBudget"`
+	IncludeThoughts bool `json:"includeThoughts,omitempty"`
 }
 
 type geminiContent struct {
@@ -26,6 +38,7 @@ type geminiPart struct {
 	FunctionCall     *geminiFunctionCall     `json:"functionCall,omitempty"`
 	FunctionResponse *geminiFunctionResponse `json:"functionResponse,omitempty"`
 	ThoughtSignature string                  `json:"thoughtSignature,omitempty"`
+	Thought          bool                    `json:"thought,omitempty"` // Text is a thought summary, not answer text
 }
 
 type geminiInlineData struct {
@@ -54,17 +67,60 @@ type geminiFunctionDeclaration struct {
 }
 
 type geminiGenerateContentResponse struct {
-	Candidates []geminiCandidate `json:"candidates"`
+	Candidates     []geminiCandidate     `json:"candidates"`
+	UsageMetadata  *geminiUsageMetadata  `json:"usageMetadata,omitempty"`
+	PromptFeedback *geminiPromptFeedback `json:"promptFeedback,omitempty"`
+}
+
+// geminiPromptFeedback explains why a prompt produced no candidates.
+type geminiPromptFeedback struct {
+	BlockReason string `json:"blockReason,omitempty"`
+}
+
+type geminiUsageMetadata struct {
+	PromptTokenCount     int `json:"promptTokenCount"`
+	CandidatesTokenCount int `json:"candidatesTokenCount"`
+	TotalTokenCount      int `json:"totalTokenCount"`
+	ThoughtsTokenCount   int `json:"thoughtsTokenCount,omitempty"`
 }
 
 type geminiCandidate struct {
-	Content geminiContent `json:"content"`
-	// FinishReason is only used for streaming responses.
-	FinishReason string `json:"finishReason,omitempty"`
+	Content           geminiContent            `json:"content"`
+	FinishReason      string                   `json:"finishReason,omitempty"`
+	FinishMessage     string                   `json:"finishMessage,omitempty"` // Explains an unusual finish
+	GroundingMetadata *geminiGroundingMetadata `json:"groundingMetadata,omitempty"`
+}
+
+// geminiGroundingMetadata describes the sources used when search grounding is enabled.
+type geminiGroundingMetadata struct {
+	GroundingChunks   []geminiGroundingChunk   `json:"groundingChunks,omitempty"`
+	GroundingSupports []geminiGroundingSupport `json:"groundingSupports,omitempty"`
+}
+
+type geminiGroundingChunk struct {
+	Web *geminiWebSource `json:"web,omitempty"`
+}
+
+type geminiWebSource struct {
+	URI   string `json:"uri"`
+	Title string `json:"title,omitempty"`
+}
+
+// geminiGroundingSupport links a segment of the response to the chunks supporting it.
+type geminiGroundingSupport struct {
+	Segment               geminiSegment `json:"segment"`
+	GroundingChunkIndices []int         `json:"groundingChunkIndices"`
+}
+
+type geminiSegment struct {
+	StartIndex int    `json:"startIndex,omitempty"`
+	EndIndex   int    `json:"endIndex,omitempty"`
+	Text       string `json:"text,omitempty"`
 }
 
 // geminiStreamResponse mirrors the streaming payload shape.
 type geminiStreamResponse struct {
-	Candidates []geminiCandidate `json:"candidates"`
-	Done       bool              `json:"done,omitempty"`
+	Candidates     []geminiCandidate     `json:"candidates"`
+	PromptFeedback *geminiPromptFeedback `json:"promptFeedback,omitempty"`
+	Done           bool                  `json:"done,omitempty"`
 }
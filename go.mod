@@ -6,6 +6,7 @@ require (
 	github.com/joho/godotenv v1.5.1
 	github.com/modelcontextprotocol/go-sdk v0.2.0
 	github.com/prometheus/client_golang v1.23.2
+	golang.org/x/image v0.32.0
 	gopkg.in/yaml.v3 v3.0.1
 	zliu.org/goutil v1.0.0
 )
@@ -0,0 +1,65 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+)
+
+// Pinger is implemented by clients that can verify connectivity and credentials
+// against their provider without generating content.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Ping checks that the client can reach its provider with valid credentials.
+// It returns the same typed errors as Generate (e.g. *AuthenticationError for bad
+// credentials, *NetworkError when the provider is unreachable).
+// Clients that do not implement Pinger are probed with a minimal Generate call.
+func Ping(ctx context.Context, client Client) error {
+	if p, ok := client.(Pinger); ok {
+		return p.Ping(ctx)
+	}
+	_, err := client.Generate(ctx, &Request{
+		Messages: []Message{{Role: RoleUser, Content: "ping"}},
+	})
+	return err
+}
+
+// pingAdapter is implemented by providers that offer a lightweight connectivity probe.
+type pingAdapter interface {
+	// getPingRequest returns the method, endpoint and optional body of the
+	// probe request. model is the client's default model, if any.
+	getPingRequest(model string) (method, path string, body any)
+}
+
+// Ping issues the provider's lightweight probe request.
+func (c *genericClient) Ping(ctx context.Context) error {
+	p, ok := c.adapter.(pingAdapter)
+	if !ok {
+		return fmt.Errorf("ping not supported by provider %s", c.b.provider)
+	}
+	method, path, body := p.getPingRequest(c.defaults.Model)
+	_, err := c.b.doRequestRaw(ctx, method, path, body)
+	return err
+}
+
+// getPingRequest lists models, which requires valid credentials but no generation.
+func (a *openaiAdapter) getPingRequest(string) (string, string, any) {
+	return "GET", "/models", nil
+}
+
+// getPingRequest lists models, which requires valid credentials but no generation.
+func (a *geminiAdapter) getPingRequest(string) (string, string, any) {
+	return "GET", "/models", nil
+}
+
+// getPingRequest sends the smallest possible message request, to the client's
+// default model so that accounts and compatible servers without the built-in
+// default can still be probed.
+func (a *anthropicAdapter) getPingRequest(model string) (string, string, any) {
+	return "POST", a.getEndpoint(""), &anthropicMessagesRequest{
+		Model:     a.getModel(&Request{Model: model}),
+		Messages:  []anthropicMessage{{Role: "user", Content: []anthropicContentBlock{{Type: "text", Text: "ping"}}}},
+		MaxTokens: 1,
+	}
+}
@@ -0,0 +1,84 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPing(t *testing.T) {
+	providers := []struct {
+		provider Provider
+		path     string
+		method   string
+	}{
+		{ProviderOpenAI, "/v1/models", "GET"},
+		{ProviderGemini, "/v1beta/models", "GET"},
+		{ProviderAnthropic, "/v1/messages", "POST"},
+	}
+
+	for _, p := range providers {
+		t.Run(string(p.provider), func(t *testing.T) {
+			status := http.StatusOK
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != p.path || r.Method != p.method {
+					t.Errorf("unexpected probe %s %s", r.Method, r.URL.Path)
+				}
+				w.WriteHeader(status)
+				if status == http.StatusOK {
+					w.Write([]byte(`{}`))
+				} else {
+					w.Write([]byte(`{"error":{"message":"invalid api key"}}`))
+				}
+			}))
+			defer server.Close()
+
+			client, err := NewClient(WithProvider(p.provider), WithAPIKey("test-key"), WithBaseURL(server.URL))
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+
+			if err := Ping(context.Background(), client); err != nil {
+				t.Fatalf("expected successful ping, got %v", err)
+			}
+
+			status = http.StatusUnauthorized
+			err = Ping(context.Background(), client)
+			var authErr *AuthenticationError
+			if !errors.As(err, &authErr) {
+				t.Fatalf("expected *AuthenticationError, got %T: %v", err, err)
+			}
+		})
+	}
+}
+
+// TestPingModel tests that the Anthropic probe uses the client's default model
+func TestPingModel(t *testing.T) {
+	var model string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		model = body.Model
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	for _, opt := range []Option{WithModel("claude-sonnet-4-5"), WithDefaults(Defaults{Model: "claude-sonnet-4-5"})} {
+		model = ""
+		client, err := NewClient(WithProvider(ProviderAnthropic), WithAPIKey("test-key"), WithBaseURL(server.URL), opt)
+		if err != nil {
+			t.Fatalf("failed to create client: %v", err)
+		}
+		if err := Ping(context.Background(), client); err != nil {
+			t.Fatalf("Ping failed: %v", err)
+		}
+		if model != "claude-sonnet-4-5" {
+			t.Errorf("expected the probe to use the configured model, got %q", model)
+		}
+	}
+}
@@ -4,15 +4,38 @@ import (
 	"bytes"
 	"context"
 	"crypto/rand"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log"
+	"mime"
+	"net"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"syscall"
 	"time"
+	"unicode/utf8"
+)
+
+// ErrResponseTooLarge is returned when a provider response body exceeds the
+// limit configured with WithMaxResponseSize.
+var ErrResponseTooLarge = errors.New("response body exceeds maximum size")
+
+const (
+	// Default connection pool settings for the shared transport.
+	defaultMaxIdleConns        = 100              // Total idle connections across all hosts
+	defaultMaxIdleConnsPerHost = 10               // Idle connections per host (net/http default is 2, which is too low)
+	defaultIdleConnTimeout     = 90 * time.Second // How long idle connections stay alive
+	defaultKeepAlive           = 30 * time.Second // TCP keep-alive period, as in net.Dialer's default
+
+	// defaultUserAgent identifies this library to providers unless overridden with WithUserAgent.
+	defaultUserAgent = "liuzl-ai/0.1.0"
 )
 
 // baseClient handles the underlying HTTP transport, including authentication,
@@ -24,21 +47,46 @@ type baseClient struct {
 	headers    http.Header
 	maxRetries int
 	provider   string
+
+	maxResponseSize int64
+	// retryBaseDelay and retryMaxDelay shape the backoff between retries; see WithBackoff.
+	retryBaseDelay time.Duration
+	retryMaxDelay  time.Duration
+	// retryBudget caps the total time spent on attempts and retry delays; see WithRetryBudget.
+	retryBudget time.Duration
+	// streamTimeout bounds a whole stream in place of httpClient.Timeout; see WithStreamTimeout.
+	streamTimeout time.Duration
+	// Transport timeouts, kept to report in timeout errors; see WithDialTimeout,
+	// WithTLSHandshakeTimeout and WithResponseHeaderTimeout.
+	dialTimeout   time.Duration
+	tlsTimeout    time.Duration
+	headerTimeout time.Duration
 }
 
+// defaultStreamTimeout bounds streams unless WithStreamTimeout is set. Streams
+// legitimately outlast unary calls, so they do not share WithTimeout.
+const defaultStreamTimeout = 10 * time.Minute
+
+// Default retry backoff, used unless WithBackoff is set.
+const (
+	defaultRetryBaseDelay = 1 * time.Second
+	defaultRetryMaxDelay  = 30 * time.Second
+)
+
 // newBaseClient creates and configures a new baseClient.
 func newBaseClient(provider, baseURL, apiVersion string, timeout time.Duration, headers http.Header, maxRetries int) *baseClient {
 	if headers == nil {
 		headers = make(http.Header)
 	}
 	headers.Set("Content-Type", "application/json")
+	headers.Set("User-Agent", defaultUserAgent)
 
 	// Configure transport with proper connection pooling for better performance
 	transport := &http.Transport{
-		MaxIdleConns:        100,              // Total idle connections across all hosts
-		MaxIdleConnsPerHost: 10,               // Idle connections per host (default is 2, which is too low)
-		IdleConnTimeout:     90 * time.Second, // How long idle connections stay alive
-		DisableCompression:  true,             // AI API responses are often already compressed or not compressible
+		MaxIdleConns:        defaultMaxIdleConns,
+		MaxIdleConnsPerHost: defaultMaxIdleConnsPerHost,
+		IdleConnTimeout:     defaultIdleConnTimeout,
+		DisableCompression:  true, // AI API responses are often already compressed or not compressible
 	}
 
 	return &baseClient{
@@ -51,6 +99,76 @@ func newBaseClient(provider, baseURL, apiVersion string, timeout time.Duration,
 		headers:    headers,
 		maxRetries: maxRetries,
 		provider:   provider,
+
+		maxResponseSize: maxResponseSize,
+		retryBaseDelay:  defaultRetryBaseDelay,
+		retryMaxDelay:   defaultRetryMaxDelay,
+		streamTimeout:   defaultStreamTimeout,
+	}
+}
+
+// applyConfig adjusts the underlying transport according to client-level options.
+func (c *baseClient) applyConfig(cfg *Config) {
+	if cfg.userAgent != "" {
+		c.headers.Set("User-Agent", cfg.userAgent)
+	}
+	if cfg.maxResponseSize > 0 {
+		c.maxResponseSize = cfg.maxResponseSize
+	}
+	if cfg.retryBaseDelay > 0 {
+		c.retryBaseDelay = cfg.retryBaseDelay
+		c.retryMaxDelay = cfg.retryMaxDelay
+	}
+	c.retryBudget = cfg.retryBudget
+	if cfg.streamTimeout > 0 {
+		c.streamTimeout = cfg.streamTimeout
+	}
+
+	transport, ok := c.httpClient.Transport.(*http.Transport)
+	if !ok {
+		return
+	}
+
+	if cfg.maxIdleConns > 0 {
+		transport.MaxIdleConns = cfg.maxIdleConns
+	}
+	if cfg.maxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = cfg.maxIdleConnsPerHost
+	}
+	if cfg.idleConnTimeout > 0 {
+		transport.IdleConnTimeout = cfg.idleConnTimeout
+	}
+	c.dialTimeout, c.tlsTimeout, c.headerTimeout = cfg.dialTimeout, cfg.tlsTimeout, cfg.headerTimeout
+	if cfg.dialTimeout > 0 {
+		transport.DialContext = (&net.Dialer{Timeout: cfg.dialTimeout, KeepAlive: defaultKeepAlive}).DialContext
+	}
+	if cfg.tlsTimeout > 0 {
+		transport.TLSHandshakeTimeout = cfg.tlsTimeout
+	}
+	if cfg.headerTimeout > 0 {
+		transport.ResponseHeaderTimeout = cfg.headerTimeout
+	}
+
+	if cfg.proxyURL != "" {
+		// validateConfig has already checked that the URL parses.
+		if proxyURL, err := url.Parse(cfg.proxyURL); err == nil {
+			transport.Proxy = http.ProxyURL(proxyURL)
+		}
+	}
+
+	if cfg.insecureSkipVerify {
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{}
+		}
+		transport.TLSClientConfig.InsecureSkipVerify = true
+		log.Printf("ai: WARNING: TLS certificate verification is disabled for %s", c.baseURL)
+	}
+
+	if cfg.forceHTTP1 {
+		// A non-nil, empty TLSNextProto map disables the automatic HTTP/2 upgrade,
+		// pinning the connection to HTTP/1.1 even when the server offers h2.
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
 	}
 }
 
@@ -71,14 +189,17 @@ func (c *baseClient) doRequestRaw(ctx context.Context, method, path string, reqB
 	if err != nil {
 		return nil, fmt.Errorf("invalid base URL: %w", err)
 	}
+	// Split off any query string so JoinPath does not escape it into the path.
+	path, rawQuery, _ := strings.Cut(path, "?")
 	u.Path, err = url.JoinPath(u.Path, c.apiVersion, path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to join URL path: %w", err)
 	}
+	u.RawQuery = rawQuery
 
 	var httpResp *http.Response
-	baseDelay := 1 * time.Second
-	maxDelay := 30 * time.Second
+	var wrote atomic.Bool // Whether the last attempt's request was written
+	start := time.Now()
 	for attempt := range c.maxRetries {
 		// Create a new request body for each attempt
 		var body io.Reader
@@ -86,7 +207,8 @@ func (c *baseClient) doRequestRaw(ctx context.Context, method, path string, reqB
 			body = bytes.NewReader(jsonBody)
 		}
 
-		httpReq, reqErr := http.NewRequestWithContext(ctx, method, u.String(), body)
+		wrote.Store(false)
+		httpReq, reqErr := http.NewRequestWithContext(traceWrote(ctx, &wrote), method, u.String(), body)
 		if reqErr != nil {
 			return nil, fmt.Errorf("failed to create HTTP request: %w", reqErr)
 		}
@@ -97,35 +219,32 @@ func (c *baseClient) doRequestRaw(ctx context.Context, method, path string, reqB
 		if err == nil && httpResp.StatusCode < 500 {
 			break // Success or non-retriable error
 		}
-		// Close response body if we're going to retry (not the last attempt)
-		if attempt < c.maxRetries-1 && httpResp != nil && httpResp.Body != nil {
+		if err != nil && !isRetriableNetworkError(err) {
+			break // Cancellation, deadline or a permanent failure such as a bad certificate
+		}
+		if attempt == c.maxRetries-1 {
+			break
+		}
+		delay := c.retryDelay(attempt)
+		if c.retryBudget > 0 && time.Since(start)+delay > c.retryBudget {
+			break // Retrying would exceed the budget; return the last error
+		}
+		// Close response body since we're going to retry
+		if httpResp != nil && httpResp.Body != nil {
 			httpResp.Body.Close()
 		}
-		if attempt < c.maxRetries-1 {
-			// Calculate backoff duration 2^attempt
-			backoff := min(baseDelay*(1<<attempt), maxDelay)
-			// Add jitter (randomness) to avoid thundering herd
-			// Use crypto/rand for unpredictable jitter
-			randomBytes := make([]byte, 2)
-			_, _ = rand.Read(randomBytes)                             // Ignore error - worst case is 0 jitter
-			jitterMs := int(randomBytes[0])<<8 | int(randomBytes[1])  // 0-65535
-			jitter := time.Duration(jitterMs%1000) * time.Millisecond // 0-999ms
-			sleepDuration := backoff + jitter
-
-			// Sleep with context cancellation support
-			select {
-			case <-time.After(sleepDuration):
-				// Continue to next retry
-			case <-ctx.Done():
-				// Context cancelled, return immediately
-				return nil, fmt.Errorf("request canceled during retry: %w", ctx.Err())
-			}
+		// Sleep with context cancellation support
+		select {
+		case <-time.After(delay):
+			// Continue to next retry
+		case <-ctx.Done():
+			// Context cancelled, return immediately
+			return nil, fmt.Errorf("request canceled during retry: %w", ctx.Err())
 		}
 	}
 	if err != nil {
-		// Check for timeout error
-		if errors.Is(err, context.DeadlineExceeded) {
-			return nil, NewTimeoutError(c.provider, c.httpClient.Timeout, err)
+		if timeoutErr := c.timeoutError(ctx, err, c.httpClient.Timeout, wrote.Load()); timeoutErr != nil {
+			return nil, timeoutErr
 		}
 		// Check for context cancellation
 		if errors.Is(err, context.Canceled) {
@@ -139,11 +258,16 @@ func (c *baseClient) doRequestRaw(ctx context.Context, method, path string, reqB
 	}
 	defer httpResp.Body.Close()
 
-	// Read response with size limit to prevent memory exhaustion from malicious servers
-	respBodyBytes, err := io.ReadAll(io.LimitReader(httpResp.Body, maxResponseSize))
+	// Read response with size limit to prevent memory exhaustion from malicious servers.
+	// One extra byte is allowed through so an oversized body can be told apart
+	// from one that is exactly at the limit.
+	respBodyBytes, err := io.ReadAll(io.LimitReader(httpResp.Body, c.maxResponseSize+1))
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
+	if int64(len(respBodyBytes)) > c.maxResponseSize {
+		return nil, fmt.Errorf("[%s] %w (%d bytes)", c.provider, ErrResponseTooLarge, c.maxResponseSize)
+	}
 
 	if httpResp.StatusCode >= 400 {
 		// Try to parse structured API error
@@ -180,9 +304,61 @@ func (c *baseClient) doRequestRaw(ctx context.Context, method, path string, reqB
 		}
 	}
 
+	if err := checkJSONResponse(c.provider, httpResp, respBodyBytes); err != nil {
+		return nil, err
+	}
+
 	return respBodyBytes, nil
 }
 
+// maxBodySnippet bounds how much of an unexpected body is quoted in errors.
+const maxBodySnippet = 200
+
+// checkJSONResponse rejects a successful response that declares a non-JSON content
+// type and whose body does not look like JSON either. Mislabeled JSON (e.g. served
+// as text/plain) and responses without a Content-Type are accepted.
+func checkJSONResponse(provider string, resp *http.Response, body []byte) error {
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err == nil && (strings.HasSuffix(mediaType, "json") || mediaType == "text/plain") {
+		return nil
+	}
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		return nil
+	}
+
+	snippet := string(trimmed)
+	if len(snippet) > maxBodySnippet {
+		// Cut at a rune boundary so the message stays valid UTF-8.
+		cut := maxBodySnippet
+		for cut > 0 && !utf8.RuneStart(snippet[cut]) {
+			cut--
+		}
+		snippet = snippet[:cut] + "..."
+	}
+	return NewUnexpectedResponseError(provider, resp.StatusCode, contentType, snippet)
+}
+
+// retryDelay returns the wait before retry attempt+1: exponential backoff from
+// retryBaseDelay plus up to 50% jitter, capped at retryMaxDelay.
+func (c *baseClient) retryDelay(attempt int) time.Duration {
+	backoff := c.retryBaseDelay << min(attempt, 30) // Bound the shift to avoid overflow
+	if backoff <= 0 || backoff > c.retryMaxDelay {
+		backoff = c.retryMaxDelay
+	}
+	// Add jitter (randomness) to avoid thundering herd
+	// Use crypto/rand for unpredictable jitter
+	randomBytes := make([]byte, 2)
+	_, _ = rand.Read(randomBytes)                                             // Ignore error - worst case is 0 jitter
+	jitterFrac := float64(int(randomBytes[0])<<8|int(randomBytes[1])) / 65536 // [0, 1)
+	jitter := time.Duration(float64(backoff) / 2 * jitterFrac)
+	return min(backoff+jitter, c.retryMaxDelay)
+}
+
 // parseRetryAfter parses the Retry-After header and returns the duration.
 // It supports both seconds (integer) and HTTP date formats.
 func parseRetryAfter(header string) time.Duration {
@@ -206,9 +382,76 @@ func parseRetryAfter(header string) time.Duration {
 	return 0
 }
 
+// traceWrote returns ctx with a trace that records in wrote whether the
+// request was written to the connection.
+func traceWrote(ctx context.Context, wrote *atomic.Bool) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		WroteRequest: func(info httptrace.WroteRequestInfo) { wrote.Store(info.Err == nil) },
+	})
+}
+
+// timeoutError classifies err from httpClient.Do of a request made with ctx,
+// returning a *TimeoutError if it is a timeout and nil otherwise. timeout is
+// the client timeout or the deadline ctx carries, and wrote reports whether the
+// request was written. A dial or TLS handshake that times out is a connect
+// timeout; the request's timeout, a context deadline or
+// WithResponseHeaderTimeout is a deadline timeout.
+func (c *baseClient) timeoutError(ctx context.Context, err error, timeout time.Duration, wrote bool) *TimeoutError {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" && opErr.Timeout() {
+		return newTimeoutError(c.provider, TimeoutKindConnect, c.dialTimeout, err)
+	}
+
+	// net/http does not export its transport timeout errors, so they are told
+	// apart by when they happen while ctx is still live. The TLS handshake
+	// timeout fires before the request is written and, unlike the client
+	// timeout, does not match context.DeadlineExceeded. The response header
+	// timeout fires after, and before the client timeout when it is shorter.
+	var netErr net.Error
+	isTimeout := errors.As(err, &netErr) && netErr.Timeout()
+	if isTimeout && ctx.Err() == nil {
+		switch {
+		case !wrote && !errors.Is(err, context.DeadlineExceeded):
+			return newTimeoutError(c.provider, TimeoutKindConnect, c.tlsTimeout, err)
+		case wrote && c.headerTimeout > 0 && (timeout <= 0 || c.headerTimeout < timeout):
+			return newTimeoutError(c.provider, TimeoutKindDeadline, c.headerTimeout, err)
+		}
+	}
+	if isTimeout || errors.Is(err, context.DeadlineExceeded) {
+		return NewTimeoutError(c.provider, timeout, err)
+	}
+	return nil
+}
+
+// isRetriableNetworkError reports whether err from httpClient.Do is a
+// transient network failure worth retrying: a dropped or reset connection, a
+// refused or timed-out dial, or a temporary DNS failure. Cancellation and
+// deadlines are never retried.
+func isRetriableNetworkError(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" && opErr.Timeout() {
+		return true
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNABORTED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTemporary || dnsErr.IsTimeout
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
 // doStream performs an HTTP request expecting an SSE response.
 // It returns the raw *http.Response and its Body for streaming consumption.
-// The caller is responsible for closing the body.
+// The caller is responsible for closing the body. The whole stream is bounded
+// by streamTimeout rather than the unary client timeout.
 func (c *baseClient) doStream(ctx context.Context, method, path string, reqBody any) (*http.Response, io.ReadCloser, error) {
 	var jsonBody []byte
 	if reqBody != nil {
@@ -233,18 +476,28 @@ func (c *baseClient) doStream(ctx context.Context, method, path string, reqBody
 		body = bytes.NewReader(jsonBody)
 	}
 
-	httpReq, reqErr := http.NewRequestWithContext(ctx, method, u.String(), body)
+	ctx, cancel := context.WithTimeout(ctx, c.streamTimeout)
+	var wrote atomic.Bool
+	httpReq, reqErr := http.NewRequestWithContext(traceWrote(ctx, &wrote), method, u.String(), body)
 	if reqErr != nil {
+		cancel()
 		return nil, nil, fmt.Errorf("failed to create HTTP request: %w", reqErr)
 	}
 	httpReq.Header = c.headers.Clone()
 	httpReq.Header.Set("Accept", "text/event-stream")
 
-	httpResp, err := c.httpClient.Do(httpReq)
+	// Use the shared transport without the unary timeout, which would also
+	// cut off reading the body; the context above bounds the stream instead.
+	streamClient := *c.httpClient
+	streamClient.Timeout = 0
+	httpResp, err := streamClient.Do(httpReq)
 	if err != nil {
-		// Check for timeout error
-		if errors.Is(err, context.DeadlineExceeded) {
-			return nil, nil, NewTimeoutError(c.provider, c.httpClient.Timeout, err)
+		// Classify while ctx is live: canceling first would hide which
+		// transport timeout fired.
+		timeoutErr := c.timeoutError(ctx, err, c.streamTimeout, wrote.Load())
+		cancel()
+		if timeoutErr != nil {
+			return nil, nil, timeoutErr
 		}
 		if errors.Is(err, context.Canceled) {
 			return nil, nil, fmt.Errorf("request canceled: %w", err)
@@ -253,8 +506,9 @@ func (c *baseClient) doStream(ctx context.Context, method, path string, reqBody
 	}
 
 	if httpResp.StatusCode >= 400 {
-		respBodyBytes, _ := io.ReadAll(io.LimitReader(httpResp.Body, maxResponseSize))
+		respBodyBytes, _ := io.ReadAll(io.LimitReader(httpResp.Body, c.maxResponseSize))
 		httpResp.Body.Close()
+		cancel()
 
 		// Try to parse structured API error
 		var apiError struct {
@@ -287,5 +541,17 @@ func (c *baseClient) doStream(ctx context.Context, method, path string, reqBody
 		}
 	}
 
-	return httpResp, httpResp.Body, nil
+	return httpResp, &cancelOnClose{ReadCloser: httpResp.Body, cancel: cancel}, nil
+}
+
+// cancelOnClose releases a stream's timeout context when its body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
 }
@@ -3,10 +3,16 @@ package ai
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+	"unicode/utf8"
 )
 
 // TestHTTPClientSuccessNoRetry tests a successful request without retries
@@ -58,6 +64,93 @@ func TestHTTPClientRetryOn500(t *testing.T) {
 	}
 }
 
+// TestRetryDelayJitterAndCap tests that retry delays vary and never exceed the cap
+func TestRetryDelayJitterAndCap(t *testing.T) {
+	client := newBaseClient("test", "http://example.com", "", 5*time.Second, nil, 3)
+	client.applyConfig(&Config{retryBaseDelay: 100 * time.Millisecond, retryMaxDelay: 500 * time.Millisecond})
+
+	for attempt := range 6 {
+		floor := min(100*time.Millisecond<<attempt, 500*time.Millisecond)
+		seen := make(map[time.Duration]bool)
+		for range 50 {
+			d := client.retryDelay(attempt)
+			if d < floor || d > 500*time.Millisecond {
+				t.Fatalf("attempt %d: delay %v outside [%v, %v]", attempt, d, floor, 500*time.Millisecond)
+			}
+			seen[d] = true
+		}
+		// Below the cap, jitter should make delays differ.
+		if floor < 500*time.Millisecond && len(seen) < 2 {
+			t.Errorf("attempt %d: expected jittered delays, got %v", attempt, seen)
+		}
+	}
+}
+
+// TestHTTPClientWithBackoff tests that WithBackoff shortens retries through the public API
+func TestHTTPClientWithBackoff(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(
+		WithProvider(ProviderOpenAI),
+		WithAPIKey("test-key"),
+		WithBaseURL(server.URL),
+		WithBackoff(time.Millisecond, 5*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	start := time.Now()
+	if _, err := client.Generate(context.Background(), &Request{Messages: []Message{{Role: RoleUser, Content: "hi"}}}); err != nil {
+		t.Fatalf("Expected success after retries, got error: %v", err)
+	}
+	if attempts.Load() != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts.Load())
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected short backoff, took %v", elapsed)
+	}
+}
+
+// TestHTTPClientRetryBudget tests that retries stop once the retry budget is
+// spent, however many retries are allowed
+func TestHTTPClientRetryBudget(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"error":{"message":"boom"}}`))
+	}))
+	defer server.Close()
+
+	client := newBaseClient("test", server.URL, "", 5*time.Second, nil, 100)
+	client.applyConfig(&Config{retryBaseDelay: 300 * time.Millisecond, retryMaxDelay: 300 * time.Millisecond, retryBudget: 2 * time.Second})
+
+	start := time.Now()
+	_, err := client.doRequestRaw(context.Background(), "GET", "/", nil)
+	elapsed := time.Since(start)
+
+	var serverErr *ServerError
+	if !errors.As(err, &serverErr) {
+		t.Fatalf("Expected the last ServerError, got %v", err)
+	}
+	if elapsed > 2500*time.Millisecond {
+		t.Errorf("Expected to give up within the 2s budget, took %v", elapsed)
+	}
+	if n := attempts.Load(); n < 2 || n >= 100 {
+		t.Errorf("Expected a few attempts within the budget, got %d", n)
+	}
+}
+
 // TestHTTPClientRetryOn503 tests retry behavior on 503 Service Unavailable
 func TestHTTPClientRetryOn503(t *testing.T) {
 	attempts := 0
@@ -233,6 +326,53 @@ func TestHTTPClientContextCancellation(t *testing.T) {
 	}
 }
 
+// TestHTTPClientRetryOnDroppedConnection tests that a connection dropped before
+// the response is retried, while a canceled request is not
+func TestHTTPClientRetryOnDroppedConnection(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Errorf("hijack failed: %v", err)
+				return
+			}
+			conn.Close()
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"result":"success"}`))
+	}))
+	defer server.Close()
+
+	client := newBaseClient("test", server.URL, "", 5*time.Second, nil, 3)
+	client.retryBaseDelay = time.Millisecond
+	body, err := client.doRequestRaw(context.Background(), "POST", "/test", map[string]string{"key": "value"})
+	if err != nil {
+		t.Fatalf("Expected success after the dropped connection, got error: %v", err)
+	}
+	if string(body) != `{"result":"success"}` || attempts.Load() != 2 {
+		t.Errorf("Expected the second attempt to succeed, got %s after %d attempts", body, attempts.Load())
+	}
+
+	attempts.Store(0)
+	hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		<-r.Context().Done()
+	}))
+	defer hanging.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	client = newBaseClient("test", hanging.URL, "", 5*time.Second, nil, 3)
+	if _, err := client.doRequestRaw(ctx, "POST", "/test", nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected a cancellation error, got %v", err)
+	}
+	if attempts.Load() != 1 {
+		t.Errorf("Expected a canceled request not to be retried, got %d attempts", attempts.Load())
+	}
+}
+
 // TestHTTPClientTimeout tests request timeout
 func TestHTTPClientTimeout(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -375,3 +515,441 @@ func TestHTTPClientProviderInError(t *testing.T) {
 		t.Errorf("Expected AuthenticationError, got %T", err)
 	}
 }
+
+// TestHTTPClientForceHTTP1 tests that WithForceHTTP1 disables the HTTP/2 upgrade on the transport
+func TestHTTPClientForceHTTP1(t *testing.T) {
+	transportFor := func(opts ...Option) *http.Transport {
+		t.Helper()
+		opts = append([]Option{WithProvider(ProviderOpenAI), WithAPIKey("test-key")}, opts...)
+		client, err := NewClient(opts...)
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		transport, ok := client.(*genericClient).b.httpClient.Transport.(*http.Transport)
+		if !ok {
+			t.Fatalf("Expected *http.Transport, got %T", client.(*genericClient).b.httpClient.Transport)
+		}
+		return transport
+	}
+
+	forced := transportFor(WithForceHTTP1())
+	if forced.ForceAttemptHTTP2 {
+		t.Error("Expected ForceAttemptHTTP2 to be false")
+	}
+	if forced.TLSNextProto == nil || len(forced.TLSNextProto) != 0 {
+		t.Errorf("Expected non-nil empty TLSNextProto, got %v", forced.TLSNextProto)
+	}
+
+	// Without the option the transport keeps the default HTTP/2 negotiation
+	if def := transportFor(); def.TLSNextProto != nil {
+		t.Errorf("Expected nil TLSNextProto by default, got %v", def.TLSNextProto)
+	}
+}
+
+// TestHTTPClientInsecureSkipVerify tests that WithInsecureSkipVerify accepts a self-signed certificate
+func TestHTTPClientInsecureSkipVerify(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"hello"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	generate := func(opts ...Option) error {
+		t.Helper()
+		opts = append([]Option{WithProvider(ProviderOpenAI), WithAPIKey("test-key"), WithBaseURL(server.URL),
+			WithBackoff(time.Millisecond, time.Millisecond)}, opts...)
+		client, err := NewClient(opts...)
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		_, err = client.Generate(context.Background(), &Request{Messages: []Message{{Role: RoleUser, Content: "hi"}}})
+		return err
+	}
+
+	if err := generate(WithInsecureSkipVerify(true)); err != nil {
+		t.Errorf("Expected success with verification disabled, got %v", err)
+	}
+	if err := generate(); err == nil {
+		t.Error("Expected a certificate error without WithInsecureSkipVerify")
+	}
+}
+
+// TestHTTPClientMaxIdleConnsPerHostReuse tests that a larger per-host pool keeps
+// connections alive across bursts of concurrent requests
+func TestHTTPClientMaxIdleConnsPerHostReuse(t *testing.T) {
+	const concurrency = 20
+
+	newConnsFor := func(opts ...Option) int64 {
+		t.Helper()
+		var newConns atomic.Int64
+		var arrived atomic.Int64
+		release := make(chan struct{})
+
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Hold each request until the whole burst has arrived so every
+			// request needs its own connection.
+			if arrived.Add(1)%concurrency == 0 {
+				close(release)
+			}
+			select {
+			case <-release:
+			case <-time.After(5 * time.Second):
+			}
+			w.Write([]byte(`{}`))
+		}))
+		server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+			if state == http.StateNew {
+				newConns.Add(1)
+			}
+		}
+		server.Start()
+		defer server.Close()
+
+		opts = append([]Option{WithProvider(ProviderOpenAI), WithAPIKey("test-key"), WithBaseURL(server.URL)}, opts...)
+		client, err := NewClient(opts...)
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		b := client.(*genericClient).b
+
+		for range 2 {
+			release = make(chan struct{})
+			var wg sync.WaitGroup
+			for range concurrency {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if _, err := b.doRequestRaw(context.Background(), "POST", "/test", nil); err != nil {
+						t.Errorf("Request failed: %v", err)
+					}
+				}()
+			}
+			wg.Wait()
+		}
+		return newConns.Load()
+	}
+
+	if got := newConnsFor(WithMaxIdleConnsPerHost(concurrency)); got != concurrency {
+		t.Errorf("Expected %d connections with a pool of %d, got %d", concurrency, concurrency, got)
+	}
+	if got := newConnsFor(); got <= concurrency {
+		t.Errorf("Expected default pool (%d per host) to open more than %d connections, got %d",
+			defaultMaxIdleConnsPerHost, concurrency, got)
+	}
+}
+
+// TestHTTPClientUserAgent tests that the User-Agent header reaches the server for unary and streaming requests
+func TestHTTPClientUserAgent(t *testing.T) {
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("User-Agent"))
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	for _, tt := range []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{"default", nil, defaultUserAgent},
+		{"custom", []Option{WithUserAgent("my-app/2.0")}, "my-app/2.0"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got = nil
+			opts := append([]Option{WithProvider(ProviderOpenAI), WithAPIKey("test-key"), WithBaseURL(server.URL)}, tt.opts...)
+			client, err := NewClient(opts...)
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+			b := client.(*genericClient).b
+
+			if _, err := b.doRequestRaw(context.Background(), "POST", "/test", nil); err != nil {
+				t.Fatalf("Unary request failed: %v", err)
+			}
+			_, body, err := b.doStream(context.Background(), "POST", "/test", nil)
+			if err != nil {
+				t.Fatalf("Streaming request failed: %v", err)
+			}
+			body.Close()
+
+			if len(got) != 2 || got[0] != tt.want || got[1] != tt.want {
+				t.Errorf("Expected User-Agent %q on both requests, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestHTTPClientMaxResponseSize verifies oversized bodies are rejected instead of truncated
+func TestHTTPClientMaxResponseSize(t *testing.T) {
+	const limit = 1024
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		chunk := make([]byte, 256)
+		for i := range chunk {
+			chunk[i] = 'x'
+		}
+		// Stream well past the limit so the client has to stop reading early.
+		for range 64 {
+			if _, err := w.Write(chunk); err != nil {
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithProvider(ProviderOpenAI), WithAPIKey("test-key"), WithBaseURL(server.URL), WithMaxResponseSize(limit))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	b := client.(*genericClient).b
+
+	_, err = b.doRequestRaw(context.Background(), "POST", "/test", nil)
+	if !errors.Is(err, ErrResponseTooLarge) {
+		t.Fatalf("Expected ErrResponseTooLarge, got: %v", err)
+	}
+
+	// A body exactly at the limit is still accepted.
+	exact := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(make([]byte, limit))
+	}))
+	defer exact.Close()
+	b.baseURL = exact.URL
+	body, err := b.doRequestRaw(context.Background(), "POST", "/test", nil)
+	if err != nil {
+		t.Fatalf("Expected body at the limit to succeed, got: %v", err)
+	}
+	if len(body) != limit {
+		t.Errorf("Expected %d bytes, got %d", limit, len(body))
+	}
+}
+
+// TestHTTPClientUnexpectedContentType tests that an HTML page served with 200 yields a clear error
+func TestHTTPClientUnexpectedContentType(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/html":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte("<html><body>Gateway login required</body></html>"))
+		case "/long":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			// The 200-byte cut falls inside the multi-byte rune at index 199.
+			w.Write([]byte(strings.Repeat("a", 199) + strings.Repeat("é", 10)))
+		case "/mislabeled":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`{"result":"success"}`))
+		default:
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte(`{"result":"success"}`))
+		}
+	}))
+	defer server.Close()
+
+	client := newBaseClient("test", server.URL, "", 5*time.Second, nil, 3)
+
+	_, err := client.doRequestRaw(context.Background(), "POST", "/html", nil)
+	var unexpected *UnexpectedResponseError
+	if !errors.As(err, &unexpected) {
+		t.Fatalf("Expected *UnexpectedResponseError, got %T: %v", err, err)
+	}
+	if unexpected.ContentType != "text/html; charset=utf-8" || unexpected.StatusCode() != http.StatusOK {
+		t.Errorf("Unexpected error details: content type %q, status %d", unexpected.ContentType, unexpected.StatusCode())
+	}
+	if unexpected.BodySnippet != "<html><body>Gateway login required</body></html>" {
+		t.Errorf("Expected body snippet in error, got %q", unexpected.BodySnippet)
+	}
+
+	_, err = client.doRequestRaw(context.Background(), "POST", "/long", nil)
+	if !errors.As(err, &unexpected) {
+		t.Fatalf("Expected *UnexpectedResponseError, got %T: %v", err, err)
+	}
+	if want := strings.Repeat("a", 199) + "..."; unexpected.BodySnippet != want || !utf8.ValidString(err.Error()) {
+		t.Errorf("Expected the snippet cut before the split rune, got %q", unexpected.BodySnippet)
+	}
+
+	for _, path := range []string{"/mislabeled", "/plain"} {
+		if _, err := client.doRequestRaw(context.Background(), "POST", path, nil); err != nil {
+			t.Errorf("Expected JSON body at %s to be accepted, got: %v", path, err)
+		}
+	}
+}
+
+// TestHTTPClientProxyURL verifies provider requests are routed through the configured proxy
+func TestHTTPClientProxyURL(t *testing.T) {
+	var gotHost, gotPath string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A forward proxy receives the absolute target URL.
+		gotHost, gotPath = r.URL.Host, r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"via proxy"}}]}`))
+	}))
+	defer proxy.Close()
+
+	client, err := NewClient(
+		WithProvider(ProviderOpenAI),
+		WithAPIKey("test-key"),
+		WithBaseURL("http://api.example.invalid"),
+		WithProxyURL(proxy.URL),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	resp, err := client.Generate(context.Background(), &Request{Messages: []Message{{Role: RoleUser, Content: "hi"}}})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if resp.Text != "via proxy" {
+		t.Errorf("Expected response from proxy, got %q", resp.Text)
+	}
+	if gotHost != "api.example.invalid" || gotPath != "/v1/chat/completions" {
+		t.Errorf("Expected proxied request to api.example.invalid/v1/chat/completions, got %s%s", gotHost, gotPath)
+	}
+}
+
+func TestTimeoutKinds(t *testing.T) {
+	t.Run("connect", func(t *testing.T) {
+		client := newBaseClient("test", "http://127.0.0.1:1", "", time.Second, nil, 1)
+		// A dial deadline that has already passed times out before connecting.
+		client.httpClient.Transport.(*http.Transport).DialContext = (&net.Dialer{Timeout: time.Nanosecond}).DialContext
+		_, err := client.doRequestRaw(context.Background(), "POST", "/test", nil)
+		var timeoutErr *TimeoutError
+		if !errors.As(err, &timeoutErr) || timeoutErr.Kind != TimeoutKindConnect {
+			t.Fatalf("expected a connect TimeoutError, got %T: %v", err, err)
+		}
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(200 * time.Millisecond)
+		}))
+		defer server.Close()
+
+		client := newBaseClient("test", server.URL, "", 50*time.Millisecond, nil, 1)
+		_, err := client.doRequestRaw(context.Background(), "POST", "/test", nil)
+		var timeoutErr *TimeoutError
+		if !errors.As(err, &timeoutErr) || timeoutErr.Kind != TimeoutKindDeadline {
+			t.Fatalf("expected a deadline TimeoutError, got %T: %v", err, err)
+		}
+	})
+
+	t.Run("idle", func(t *testing.T) {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Hello\"}}]}\n\n")
+			w.(http.Flusher).Flush()
+			<-release // Stall like a provider that stopped sending.
+		}))
+		defer server.Close()
+		defer close(release)
+
+		client, err := NewClient(WithProvider(ProviderOpenAI), WithAPIKey("test-key"), WithBaseURL(server.URL),
+			WithStreamIdleTimeout(50*time.Millisecond))
+		if err != nil {
+			t.Fatalf("failed to create client: %v", err)
+		}
+		reader, err := Stream(context.Background(), client, &Request{Messages: []Message{{Role: RoleUser, Content: "hi"}}})
+		if err != nil {
+			t.Fatalf("Stream failed: %v", err)
+		}
+		defer reader.Close()
+
+		if _, err := reader.Recv(); err != nil {
+			t.Fatalf("expected the first chunk, got %v", err)
+		}
+		_, err = reader.Recv()
+		var timeoutErr *TimeoutError
+		if !errors.As(err, &timeoutErr) || timeoutErr.Kind != TimeoutKindIdle {
+			t.Fatalf("expected an idle TimeoutError, got %T: %v", err, err)
+		}
+		if timeoutErr.Duration != 50*time.Millisecond {
+			t.Errorf("expected the idle timeout as duration, got %v", timeoutErr.Duration)
+		}
+	})
+}
+
+// TestTransportTimeouts tests that WithDialTimeout, WithTLSHandshakeTimeout and
+// WithResponseHeaderTimeout configure the transport and surface as typed
+// timeout errors
+func TestTransportTimeouts(t *testing.T) {
+	req := &Request{Messages: []Message{{Role: RoleUser, Content: "hi"}}}
+	// send makes a unary call or opens a stream, which take separate paths.
+	send := func(client Client, stream bool) error {
+		if stream {
+			_, err := Stream(context.Background(), client, req)
+			return err
+		}
+		_, err := client.Generate(context.Background(), req)
+		return err
+	}
+
+	t.Run("dial", func(t *testing.T) {
+		// 10.255.255.1 is unroutable; the nanosecond timeout makes the dial fail
+		// even where the network answers for it.
+		client, err := NewClient(WithProvider(ProviderOpenAI), WithAPIKey("test-key"),
+			WithBaseURL("http://10.255.255.1"), WithDialTimeout(time.Nanosecond), WithBackoff(time.Millisecond, time.Millisecond))
+		if err != nil {
+			t.Fatalf("failed to create client: %v", err)
+		}
+		_, err = client.Generate(context.Background(), req)
+		var timeoutErr *TimeoutError
+		if !errors.As(err, &timeoutErr) || timeoutErr.Kind != TimeoutKindConnect || timeoutErr.Duration != time.Nanosecond {
+			t.Fatalf("expected a connect TimeoutError, got %T: %v", err, err)
+		}
+	})
+
+	t.Run("TLS handshake", func(t *testing.T) {
+		// The listener accepts connections but never answers the handshake.
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed to listen: %v", err)
+		}
+		defer listener.Close()
+		go func() {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+			}
+		}()
+
+		client, err := NewClient(WithProvider(ProviderOpenAI), WithAPIKey("test-key"),
+			WithBaseURL("https://"+listener.Addr().String()), WithTLSHandshakeTimeout(20*time.Millisecond), WithBackoff(time.Millisecond, time.Millisecond))
+		if err != nil {
+			t.Fatalf("failed to create client: %v", err)
+		}
+		for _, stream := range []bool{false, true} {
+			err := send(client, stream)
+			var timeoutErr *TimeoutError
+			if !errors.As(err, &timeoutErr) || timeoutErr.Kind != TimeoutKindConnect || timeoutErr.Duration != 20*time.Millisecond {
+				t.Fatalf("stream=%v: expected a connect TimeoutError after 20ms, got %T: %v", stream, err, err)
+			}
+		}
+	})
+
+	t.Run("response header", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(200 * time.Millisecond)
+		}))
+		defer server.Close()
+
+		client, err := NewClient(WithProvider(ProviderOpenAI), WithAPIKey("test-key"),
+			WithBaseURL(server.URL), WithResponseHeaderTimeout(20*time.Millisecond), WithBackoff(time.Millisecond, time.Millisecond))
+		if err != nil {
+			t.Fatalf("failed to create client: %v", err)
+		}
+		for _, stream := range []bool{false, true} {
+			err := send(client, stream)
+			var timeoutErr *TimeoutError
+			if !errors.As(err, &timeoutErr) || timeoutErr.Kind != TimeoutKindDeadline || timeoutErr.Duration != 20*time.Millisecond {
+				t.Fatalf("stream=%v: expected a deadline TimeoutError after 20ms, got %T: %v", stream, err, err)
+			}
+		}
+	})
+
+	if _, err := NewClient(WithProvider(ProviderOpenAI), WithAPIKey("test-key"), WithDialTimeout(-time.Second)); err == nil {
+		t.Error("expected a negative dial timeout to be rejected")
+	}
+}
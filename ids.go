@@ -0,0 +1,48 @@
+package ai
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// idGenerator holds the function installed with SetIDGenerator, if any.
+var idGenerator atomic.Pointer[func(n int) string]
+
+// SetIDGenerator replaces the function used to produce the random part of
+// generated IDs (response and message IDs in converted responses, and tool
+// call IDs for Gemini). The function receives the desired length and should
+// return a string of that length. Passing nil restores the default
+// crypto/rand hex generator. It is safe to call concurrently with requests.
+func SetIDGenerator(fn func(n int) string) {
+	if fn == nil {
+		idGenerator.Store(nil)
+		return
+	}
+	idGenerator.Store(&fn)
+}
+
+// generateRandomID returns an ID of the given length from the configured generator.
+func generateRandomID(length int) string {
+	if fn := idGenerator.Load(); fn != nil {
+		return (*fn)(length)
+	}
+	return randomHexID(length)
+}
+
+// randomHexID is the default ID generator.
+func randomHexID(length int) string {
+	// Generate a cryptographically secure random ID
+	b := make([]byte, (length+1)/2) // Each byte becomes 2 hex chars
+	if _, err := rand.Read(b); err != nil {
+		// Fallback to timestamp-based ID if random generation fails
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	id := hex.EncodeToString(b)
+	if len(id) > length {
+		return id[:length]
+	}
+	return id
+}
@@ -0,0 +1,43 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSetIDGenerator(t *testing.T) {
+	SetIDGenerator(func(n int) string { return strings.Repeat("a", n) })
+	t.Cleanup(func() { SetIDGenerator(nil) })
+
+	resp := &Response{Text: "hello"}
+
+	openaiResp, err := NewOpenAIFormatConverter().ConvertResponseToOpenAI(resp, "gpt-4o", 0, 0)
+	if err != nil {
+		t.Fatalf("ConvertResponseToOpenAI failed: %v", err)
+	}
+	if want := "chatcmpl-" + strings.Repeat("a", 29); openaiResp.ID != want {
+		t.Errorf("expected OpenAI ID %q, got %q", want, openaiResp.ID)
+	}
+
+	anthropicResp, err := NewAnthropicFormatConverter().ConvertResponseToAnthropic(resp, "claude-sonnet-4-5")
+	if err != nil {
+		t.Fatalf("ConvertResponseToAnthropic failed: %v", err)
+	}
+	if want := "msg_" + strings.Repeat("a", 29); anthropicResp.ID != want {
+		t.Errorf("expected Anthropic ID %q, got %q", want, anthropicResp.ID)
+	}
+
+	geminiResp, err := (&geminiAdapter{}).parseResponse([]byte(`{"candidates":[{"content":{"parts":[
+		{"functionCall":{"name":"get_weather","args":{"city":"Paris"}}}]}}]}`))
+	if err != nil {
+		t.Fatalf("parseResponse failed: %v", err)
+	}
+	if len(geminiResp.ToolCalls) != 1 || geminiResp.ToolCalls[0].ID != "gemini-tool-call-"+strings.Repeat("a", 16) {
+		t.Errorf("expected deterministic Gemini tool call ID, got %+v", geminiResp.ToolCalls)
+	}
+
+	SetIDGenerator(nil)
+	if a, b := generateRandomID(29), generateRandomID(29); a == b || len(a) != 29 {
+		t.Errorf("expected distinct random IDs of length 29 after reset, got %q and %q", a, b)
+	}
+}
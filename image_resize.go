@@ -0,0 +1,102 @@
+package ai
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	_ "image/gif" // Register the GIF decoder
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // Register the WebP decoder
+)
+
+// resizeImageData downscales the encoded image data to fit within maxDim
+// pixels on its longer side, preserving the aspect ratio. JPEG images are
+// re-encoded as JPEG and all others as PNG, and format reports which. Images
+// already within the bound, and formats that cannot be decoded, are returned
+// unchanged with an empty format.
+func resizeImageData(data []byte, maxDim int) ([]byte, string, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || (config.Width <= maxDim && config.Height <= maxDim) {
+		return data, "", nil
+	}
+	src, srcFormat, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	width, height := maxDim, maxDim
+	if config.Width > config.Height {
+		height = max(1, config.Height*maxDim/config.Width)
+	} else {
+		width = max(1, config.Width*maxDim/config.Height)
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+
+	var buf bytes.Buffer
+	if srcFormat == "jpeg" {
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 90})
+	} else {
+		srcFormat = "png"
+		err = png.Encode(&buf, dst)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode resized image: %w", err)
+	}
+	return buf.Bytes(), srcFormat, nil
+}
+
+// resizeBase64Image is resizeImageData for base64 data, with or without a
+// data URI prefix. An empty format means data was left unchanged.
+func resizeBase64Image(data string, maxDim int) (string, string, error) {
+	raw, err := base64.StdEncoding.DecodeString(cleanBase64(data))
+	if err != nil {
+		return data, "", nil // Left for the provider to reject
+	}
+	resized, format, err := resizeImageData(raw, maxDim)
+	if err != nil || format == "" {
+		return data, "", err
+	}
+	return base64.StdEncoding.EncodeToString(resized), format, nil
+}
+
+// resizeImages returns req with every base64 image larger than maxDim pixels
+// on a side downscaled to fit; see WithImageMaxDimension. The request is
+// cloned first so the caller's request is not modified. A maxDim of zero
+// leaves req unchanged.
+func resizeImages(req *Request, maxDim int) (*Request, error) {
+	if maxDim <= 0 {
+		return req, nil
+	}
+	var resized *Request
+	for i, msg := range req.Messages {
+		for j, part := range msg.ContentParts {
+			if part.Type != ContentTypeImage || part.ImageSource == nil || part.ImageSource.Type != ImageSourceTypeBase64 {
+				continue
+			}
+			data, format, err := resizeBase64Image(part.ImageSource.Data, maxDim)
+			if err != nil {
+				return nil, fmt.Errorf("message[%d].content_parts[%d]: %w", i, j, err)
+			}
+			if format == "" {
+				continue
+			}
+			if resized == nil {
+				resized = req.Clone()
+			}
+			resized.Messages[i].ContentParts[j].ImageSource = &ImageSource{
+				Type:   ImageSourceTypeBase64,
+				Data:   data,
+				Format: format,
+			}
+		}
+	}
+	if resized == nil {
+		return req, nil
+	}
+	return resized, nil
+}
@@ -0,0 +1,135 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// testPNG returns a PNG of the given size.
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := range width {
+		img.Set(x, x*height/width, color.RGBA{R: 255, A: 255})
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("failed to encode PNG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// decodedSize decodes base64 image data and returns its dimensions.
+func decodedSize(t *testing.T, data string) (int, int) {
+	t.Helper()
+	raw, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		t.Fatalf("invalid base64 image: %v", err)
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("failed to decode image: %v", err)
+	}
+	return config.Width, config.Height
+}
+
+// TestImageMaxDimension tests that an oversized base64 image is downscaled to
+// fit the bound before it is sent, while a small one is sent unchanged
+func TestImageMaxDimension(t *testing.T) {
+	var urls []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []struct {
+				Content []struct {
+					ImageURL struct {
+						URL string `json:"url"`
+					} `json:"image_url"`
+				} `json:"content"`
+			} `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		urls = nil
+		for _, part := range body.Messages[0].Content {
+			urls = append(urls, part.ImageURL.URL)
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithProvider(ProviderOpenAI), WithAPIKey("test-key"), WithBaseURL(server.URL),
+		WithImageMaxDimension(512))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	large := base64.StdEncoding.EncodeToString(testPNG(t, 1200, 600))
+	small := base64.StdEncoding.EncodeToString(testPNG(t, 64, 32))
+	req := &Request{Messages: []Message{{Role: RoleUser, ContentParts: []ContentPart{
+		NewImagePartFromBase64(large, "png"),
+		NewImagePartFromBase64(small, "png"),
+	}}}}
+	if _, err := client.Generate(context.Background(), req); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	if len(urls) != 2 {
+		t.Fatalf("expected two images, got %v", urls)
+	}
+	prefix := "data:image/png;base64,"
+	if !strings.HasPrefix(urls[0], prefix) {
+		t.Fatalf("expected a PNG data URI, got %.40s", urls[0])
+	}
+	if width, height := decodedSize(t, strings.TrimPrefix(urls[0], prefix)); width != 512 || height != 256 {
+		t.Errorf("expected the large image downscaled to 512x256, got %dx%d", width, height)
+	}
+	if urls[1] != prefix+small {
+		t.Error("expected the small image to be sent unchanged")
+	}
+	if req.Messages[0].ContentParts[0].ImageSource.Data != large {
+		t.Error("expected the caller's request to be left unchanged")
+	}
+}
+
+// TestGeminiImageMaxDimension tests that an image Gemini downloads is
+// downscaled before it is embedded
+func TestGeminiImageMaxDimension(t *testing.T) {
+	imageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(testPNG(t, 300, 900))
+	}))
+	defer imageServer.Close()
+
+	var data string
+	geminiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body geminiGenerateContentRequest
+		json.NewDecoder(r.Body).Decode(&body)
+		if inline := body.Contents[0].Parts[0].InlineData; inline != nil {
+			data = inline.Data
+		}
+		w.Write([]byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":"ok"}]},"finishReason":"STOP"}]}`))
+	}))
+	defer geminiServer.Close()
+
+	client, err := NewClient(WithProvider(ProviderGemini), WithAPIKey("test-key"), WithBaseURL(geminiServer.URL),
+		WithImageMaxDimension(300))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	_, err = client.Generate(context.Background(), &Request{Messages: []Message{{Role: RoleUser, ContentParts: []ContentPart{
+		NewImagePartFromURL(imageServer.URL + "/tall.png"),
+	}}}})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if width, height := decodedSize(t, data); width != 100 || height != 300 {
+		t.Errorf("expected the downloaded image downscaled to 100x300, got %dx%d", width, height)
+	}
+}
@@ -7,15 +7,43 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"time"
 )
 
 const (
 	// Maximum size limits for downloaded media to prevent memory exhaustion
 	maxImageSize    = 100 * 1024 * 1024 // 100 MB
 	maxMediaSize    = 500 * 1024 * 1024 // 500 MB for video/audio
-	maxResponseSize = 10 * 1024 * 1024  // 10 MB for API responses
+	maxResponseSize = 10 * 1024 * 1024  // 10 MB default for API responses, see WithMaxResponseSize
 )
 
+// defaultDownloadBudget is the share of the time left before the deadline that
+// media downloads may use when WithDownloadBudget is not set.
+const defaultDownloadBudget = 0.5
+
+// downloadBudgetContext derives the context shared by all media downloads of a
+// request: it ends once the budget share of the time remaining before ctx's
+// deadline is spent, leaving the rest for the generation call itself.
+func downloadBudgetContext(ctx context.Context, budget float64) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	if budget <= 0 {
+		budget = defaultDownloadBudget
+	}
+	return context.WithTimeout(ctx, time.Duration(float64(time.Until(deadline))*budget))
+}
+
+// downloadContext derives the context for a single media download from the
+// budget context, applying the configured download timeout.
+func downloadContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
 // downloadImageToBase64 downloads an image from a URL and converts it to base64.
 // This is used for providers like Gemini that don't support image URLs directly.
 // The context should already have a timeout if needed.
@@ -70,6 +98,14 @@ func downloadImageToBase64(ctx context.Context, imageURL string) (string, string
 	return b.String(), format, nil
 }
 
+// imageMIMEType returns the MIME type for an image format such as "jpg" or "png".
+func imageMIMEType(format string) string {
+	if format == "jpg" {
+		return "image/jpeg"
+	}
+	return "image/" + format
+}
+
 // detectImageFormat detects image format from Content-Type header or URL extension.
 func detectImageFormat(contentType, imageURL string) string {
 	// Try to detect from Content-Type header first
@@ -156,3 +192,61 @@ func downloadMediaToBase64(ctx context.Context, mediaURL string) (string, error)
 
 	return b.String(), nil
 }
+
+// cleanBase64 strips a data URI prefix (e.g. "data:application/pdf;base64,")
+// so providers that take raw base64 receive only the payload.
+func cleanBase64(data string) string {
+	if strings.HasPrefix(data, "data:") {
+		if idx := strings.Index(data, ","); idx != -1 {
+			return data[idx+1:]
+		}
+	}
+	return data
+}
+
+// encodeReaderToBase64 streams r into a base64 string, enforcing maxImageSize.
+func encodeReaderToBase64(r io.Reader) (string, error) {
+	var b strings.Builder
+	encoder := base64.NewEncoder(base64.StdEncoding, &b)
+	n, err := io.Copy(encoder, io.LimitReader(r, maxImageSize+1))
+	encoder.Close()
+	if err != nil {
+		return "", fmt.Errorf("failed to read/encode image data: %w", err)
+	}
+	if n == 0 {
+		return "", fmt.Errorf("image reader returned no data")
+	}
+	if n > maxImageSize {
+		return "", fmt.Errorf("image exceeds maximum size of %d bytes", int64(maxImageSize))
+	}
+	return b.String(), nil
+}
+
+// resolveImageReaders returns req with every reader-backed image replaced by its
+// base64 encoding. The request is cloned first so the caller's request is not modified.
+func resolveImageReaders(req *Request) (*Request, error) {
+	var resolved *Request
+	for i, msg := range req.Messages {
+		for j, part := range msg.ContentParts {
+			if part.Type != ContentTypeImage || part.ImageSource == nil || part.ImageSource.Type != ImageSourceTypeReader {
+				continue
+			}
+			data, err := encodeReaderToBase64(part.ImageSource.Reader)
+			if err != nil {
+				return nil, fmt.Errorf("message[%d].content_parts[%d]: %w", i, j, err)
+			}
+			if resolved == nil {
+				resolved = req.Clone()
+			}
+			resolved.Messages[i].ContentParts[j].ImageSource = &ImageSource{
+				Type:   ImageSourceTypeBase64,
+				Data:   data,
+				Format: part.ImageSource.Format,
+			}
+		}
+	}
+	if resolved == nil {
+		return req, nil
+	}
+	return resolved, nil
+}
@@ -0,0 +1,129 @@
+package ai
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// repairJSON makes a best-effort attempt to turn slightly malformed JSON, as
+// models sometimes emit in tool arguments, into valid JSON. It strips Markdown
+// code fences, escapes raw control characters inside strings, drops trailing
+// commas, and closes unterminated strings, objects and arrays. Valid input is
+// returned unchanged. The second result reports whether the returned string is
+// valid JSON.
+func repairJSON(s string) (string, bool) {
+	if json.Valid([]byte(s)) {
+		return s, true
+	}
+
+	text := strings.TrimSpace(s)
+	if strings.HasPrefix(text, "```") {
+		text = strings.TrimPrefix(text, "```json")
+		text = strings.TrimPrefix(text, "```")
+		text = strings.TrimSuffix(strings.TrimSpace(text), "```")
+		text = strings.TrimSpace(text)
+	}
+
+	var b strings.Builder
+	var closers []byte
+	inString, escaped := false, false
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			case c == '\n':
+				b.WriteString(`\n`)
+				continue
+			case c == '\r':
+				b.WriteString(`\r`)
+				continue
+			case c == '\t':
+				b.WriteString(`\t`)
+				continue
+			}
+			b.WriteByte(c)
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '{':
+			closers = append(closers, '}')
+		case '[':
+			closers = append(closers, ']')
+		case '}', ']':
+			if len(closers) > 0 {
+				closers = closers[:len(closers)-1]
+			}
+		case ',':
+			// Drop a comma that is only followed by whitespace and a closing bracket.
+			j := i + 1
+			for j < len(text) && strings.IndexByte(" \t\r\n", text[j]) >= 0 {
+				j++
+			}
+			if j == len(text) || text[j] == '}' || text[j] == ']' {
+				continue
+			}
+		}
+		b.WriteByte(c)
+	}
+
+	if inString {
+		if escaped {
+			b.WriteByte('\\')
+		}
+		b.WriteByte('"')
+	}
+	for i := len(closers) - 1; i >= 0; i-- {
+		b.WriteByte(closers[i])
+	}
+
+	repaired := b.String()
+	if !json.Valid([]byte(repaired)) {
+		return s, false
+	}
+	return repaired, true
+}
+
+// repairToolCallArguments repairs the arguments of each tool call in place and
+// returns the names of the calls it changed. Arguments that cannot be repaired
+// are left as they are.
+func repairToolCallArguments(calls []ToolCall) []string {
+	var changed []string
+	for i := range calls {
+		if repaired, ok := repairJSON(calls[i].Arguments); ok && repaired != calls[i].Arguments {
+			calls[i].Arguments = repaired
+			changed = append(changed, calls[i].Function)
+		}
+	}
+	return changed
+}
+
+// repairRequestToolArguments returns req with repaired tool call arguments in its
+// messages. The request is cloned first so the caller's request is not modified.
+func repairRequestToolArguments(req *Request) *Request {
+	var repaired *Request
+	for i, msg := range req.Messages {
+		for j, tc := range msg.ToolCalls {
+			fixed, ok := repairJSON(tc.Arguments)
+			if !ok || fixed == tc.Arguments {
+				continue
+			}
+			if repaired == nil {
+				repaired = req.Clone()
+			}
+			repaired.Messages[i].ToolCalls[j].Arguments = fixed
+		}
+	}
+	if repaired == nil {
+		return req
+	}
+	return repaired
+}
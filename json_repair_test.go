@@ -0,0 +1,96 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRepairJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"valid untouched", `{"city": "Paris", "days": [1, 2]}`, `{"city": "Paris", "days": [1, 2]}`},
+		{"trailing comma in object", `{"city":"Paris",}`, `{"city":"Paris"}`},
+		{"trailing comma in array", `{"days":[1,2,3, ]}`, `{"days":[1,2,3 ]}`},
+		{"raw newline in string", "{\"note\":\"line one\nline two\"}", `{"note":"line one\nline two"}`},
+		{"raw tab in string", "{\"note\":\"a\tb\"}", `{"note":"a\tb"}`},
+		{"unclosed object", `{"city":"Paris","units":{"temp":"c"`, `{"city":"Paris","units":{"temp":"c"}}`},
+		{"unterminated string", `{"city":"Par`, `{"city":"Par"}`},
+		{"code fence", "```json\n{\"city\":\"Paris\"}\n```", `{"city":"Paris"}`},
+		{"comma inside string kept", `{"list":"a, }",}`, `{"list":"a, }"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := repairJSON(tt.in)
+			if !ok {
+				t.Fatalf("expected %q to be repairable", tt.in)
+			}
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	if got, ok := repairJSON(`{"city": Paris}`); ok || got != `{"city": Paris}` {
+		t.Errorf("expected unrecoverable input to be returned unchanged, got %q (ok=%v)", got, ok)
+	}
+}
+
+func TestToolArgumentRepair(t *testing.T) {
+	var sent struct {
+		Messages []struct {
+			ToolCalls []struct {
+				Function struct {
+					Arguments string `json:"arguments"`
+				} `json:"function"`
+			} `json:"tool_calls"`
+		} `json:"messages"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&sent)
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","tool_calls":[
+			{"id":"call_2","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Tokyo\",}"}}]},
+			"finish_reason":"tool_calls"}]}`))
+	}))
+	defer server.Close()
+
+	req := &Request{Messages: []Message{
+		{Role: RoleUser, Content: "Weather in Paris, then Tokyo?"},
+		{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "call_1", Type: "function", Function: "get_weather", Arguments: `{"city":"Paris",}`}}},
+		{Role: RoleTool, ToolCallID: "call_1", Content: "sunny"},
+	}}
+
+	// Without the option, the malformed arguments fail validation.
+	plain, err := NewClient(WithProvider(ProviderOpenAI), WithAPIKey("test-key"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	if _, err := plain.Generate(context.Background(), req); err == nil {
+		t.Fatal("expected malformed tool arguments to fail validation without repair")
+	}
+
+	client, err := NewClient(WithProvider(ProviderOpenAI), WithAPIKey("test-key"), WithBaseURL(server.URL), WithToolArgumentRepair(true))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	resp, err := client.Generate(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	if len(sent.Messages) != 3 || len(sent.Messages[1].ToolCalls) != 1 || sent.Messages[1].ToolCalls[0].Function.Arguments != `{"city":"Paris"}` {
+		t.Errorf("expected repaired arguments to be sent, got %+v", sent.Messages)
+	}
+	if req.Messages[1].ToolCalls[0].Arguments != `{"city":"Paris",}` {
+		t.Error("expected the caller's request to be left unmodified")
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].Arguments != `{"city":"Tokyo"}` {
+		t.Errorf("expected repaired arguments in the response, got %+v", resp.ToolCalls)
+	}
+}
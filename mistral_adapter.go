@@ -0,0 +1,141 @@
+package ai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"slices"
+)
+
+// mistralAdapter implements the providerAdapter interface for Mistral, whose
+// chat completions API follows OpenAI's. It reuses the OpenAI adapter and
+// overrides where Mistral differs: tool call IDs must be nine alphanumeric
+// characters, tool results carry the function name, assistant prefill is
+// native, and streamed tool calls may all report index 0.
+type mistralAdapter struct {
+	openaiAdapter
+}
+
+func (a *mistralAdapter) getModel(req *Request) string {
+	if req.Model == "" {
+		return "mistral-small-latest"
+	}
+	return req.Model
+}
+
+func (a *mistralAdapter) buildRequestPayload(ctx context.Context, req *Request) (any, error) {
+	model := a.getModel(req)
+	r := *req
+	r.Model = model
+	// Mistral has no equivalent of these OpenAI parameters.
+	if len(r.LogitBias) > 0 {
+		if err := a.params.unsupported(ProviderMistral, model, "LogitBias"); err != nil {
+			return nil, err
+		}
+		r.LogitBias = nil
+	}
+	if r.TopK != nil {
+		if err := a.params.unsupported(ProviderMistral, model, "TopK"); err != nil {
+			return nil, err
+		}
+		r.TopK = nil
+	}
+	if r.ReasoningEffort != "" {
+		if err := a.params.unsupported(ProviderMistral, model, "ReasoningEffort"); err != nil {
+			return nil, err
+		}
+		r.ReasoningEffort = ""
+	}
+	if r.Logprobs {
+		if err := a.params.unsupported(ProviderMistral, model, "Logprobs"); err != nil {
+			return nil, err
+		}
+		r.Logprobs = false
+	}
+	if r.Thinking != nil {
+		if err := a.params.unsupported(ProviderMistral, model, "Thinking"); err != nil {
+			return nil, err
+		}
+		r.Thinking = nil
+	}
+
+	// Mistral continues a trailing assistant message marked as a prefix, so the
+	// OpenAI adapter's emulation is bypassed.
+	prefill, hasPrefill := r.AssistantPrefill()
+	if hasPrefill {
+		r.Messages = r.Messages[:len(r.Messages)-1]
+	}
+
+	payload, err := a.openaiAdapter.buildRequestPayload(ctx, &r)
+	if err != nil {
+		var contentErr *UnsupportedContentError
+		if errors.As(err, &contentErr) {
+			return nil, NewUnsupportedContentError(ProviderMistral, contentErr.ContentType)
+		}
+		return nil, err
+	}
+	mistralReq := payload.(*OpenAIChatCompletionRequest)
+
+	functions := make(map[string]string) // tool call ID -> function name
+	for i := range mistralReq.Messages {
+		msg := &mistralReq.Messages[i]
+		for j := range msg.ToolCalls {
+			functions[msg.ToolCalls[j].ID] = msg.ToolCalls[j].Function.Name
+			msg.ToolCalls[j].ID = mistralToolCallID(msg.ToolCalls[j].ID)
+		}
+		if msg.ToolCallID != "" {
+			if msg.Name == "" {
+				msg.Name = functions[msg.ToolCallID]
+			}
+			msg.ToolCallID = mistralToolCallID(msg.ToolCallID)
+		}
+	}
+
+	if hasPrefill {
+		mistralReq.Messages = append(mistralReq.Messages, openaiMessage{
+			Role:    string(RoleAssistant),
+			Content: prefill,
+			Prefix:  true,
+		})
+	}
+	return mistralReq, nil
+}
+
+// mistralToolCallIDPattern matches the tool call IDs Mistral accepts.
+var mistralToolCallIDPattern = regexp.MustCompile(`^[a-zA-Z0-9]{9}$`)
+
+// mistralToolCallID returns id if Mistral accepts it, or else a stable
+// nine-character ID derived from it, so calls and results from other
+// providers still match up.
+func mistralToolCallID(id string) string {
+	if mistralToolCallIDPattern.MatchString(id) {
+		return id
+	}
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:])[:9]
+}
+
+func (a *mistralAdapter) parseStreamEvent(event *sseEvent, acc *streamAccumulator) (*StreamChunk, bool, error) {
+	chunk, done, err := a.openaiAdapter.parseStreamEvent(event, acc)
+	if chunk == nil || err != nil {
+		return chunk, done, err
+	}
+	// Mistral streams each tool call whole, with its ID, but may report index 0
+	// for all of them; number them in order of appearance instead.
+	next := len(acc.order)
+	for i := range chunk.ToolCallDeltas {
+		delta := &chunk.ToolCallDeltas[i]
+		if delta.ID == "" {
+			continue
+		}
+		if index := slices.Index(acc.order, delta.ID); index >= 0 {
+			delta.Index = index
+		} else {
+			delta.Index = next
+			next++
+		}
+	}
+	return chunk, done, nil
+}
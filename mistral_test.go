@@ -0,0 +1,138 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestMistralChat(t *testing.T) {
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer test-key" {
+			t.Errorf("expected bearer auth, got %q", got)
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"Bonjour !"},"finish_reason":"stop"}],"usage":{"prompt_tokens":5,"completion_tokens":3,"total_tokens":8}}`)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithProvider(ProviderMistral), WithAPIKey("test-key"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	resp, err := client.Generate(context.Background(), &Request{Messages: []Message{
+		{Role: RoleUser, Content: "Say hello in French"},
+		{Role: RoleAssistant, Content: "Bon"},
+	}})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if resp.Text != "Bonjour !" || resp.Usage == nil || resp.Usage.TotalTokens != 8 {
+		t.Errorf("expected the parsed response, got %+v", resp)
+	}
+	if body["model"] != "mistral-small-latest" {
+		t.Errorf("expected the default model, got %v", body["model"])
+	}
+
+	// A trailing assistant message is sent as a prefix, not with OpenAI's
+	// continuation instruction.
+	msgs := body["messages"].([]any)
+	if len(msgs) != 2 {
+		t.Fatalf("expected user and prefix messages, got %v", msgs)
+	}
+	if last := msgs[1].(map[string]any); last["role"] != "assistant" || last["content"] != "Bon" || last["prefix"] != true {
+		t.Errorf("expected the prefill as an assistant prefix, got %v", last)
+	}
+}
+
+func TestMistralToolCalls(t *testing.T) {
+	var body struct {
+		Messages []map[string]any `json:"messages"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"","tool_calls":[{"id":"D681PevKs","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Paris\"}"}}]},"finish_reason":"tool_calls"}]}`)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithProvider(ProviderMistral), WithAPIKey("test-key"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	resp, err := client.Generate(context.Background(), &Request{Messages: []Message{
+		{Role: RoleUser, Content: "Weather in Lyon, then Paris?"},
+		{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "call_abc123XYZ", Type: "function", Function: "get_weather", Arguments: `{"city":"Lyon"}`}}},
+		{Role: RoleTool, ToolCallID: "call_abc123XYZ", Content: "rain"},
+	}})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].ID != "D681PevKs" || resp.ToolCalls[0].Arguments != `{"city":"Paris"}` {
+		t.Errorf("expected the parsed tool call, got %+v", resp.ToolCalls)
+	}
+	if resp.FinishReason != FinishReasonToolCalls {
+		t.Errorf("expected finish reason tool_calls, got %q", resp.FinishReason)
+	}
+
+	if len(body.Messages) != 3 {
+		t.Fatalf("expected 3 messages, got %v", body.Messages)
+	}
+	callID := body.Messages[1]["tool_calls"].([]any)[0].(map[string]any)["id"]
+	if id, _ := callID.(string); !regexp.MustCompile(`^[a-zA-Z0-9]{9}$`).MatchString(id) {
+		t.Errorf("expected a nine-character alphanumeric tool call ID, got %v", callID)
+	}
+	tool := body.Messages[2]
+	if tool["tool_call_id"] != callID || tool["name"] != "get_weather" {
+		t.Errorf("expected the tool result to match the call and name the function, got %v", tool)
+	}
+}
+
+func TestMistralStreaming(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"role\":\"assistant\",\"content\":\"Checking \"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"tool_calls\":[{\"id\":\"aaaaaaaa1\",\"index\":0,\"function\":{\"name\":\"get_weather\",\"arguments\":\"{\\\"city\\\":\\\"Paris\\\"}\"}}]}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"tool_calls\":[{\"id\":\"bbbbbbbb2\",\"index\":0,\"function\":{\"name\":\"get_time\",\"arguments\":\"{}\"}}]},\"finish_reason\":\"tool_calls\"}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithProvider(ProviderMistral), WithAPIKey("test-key"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	var text string
+	var indexes []int
+	var last *StreamChunk
+	for chunk, err := range StreamSeq(context.Background(), client, &Request{Messages: []Message{{Role: RoleUser, Content: "Weather and time in Paris?"}}}) {
+		if err != nil {
+			t.Fatalf("stream error: %v", err)
+		}
+		text += chunk.TextDelta
+		for _, delta := range chunk.ToolCallDeltas {
+			indexes = append(indexes, delta.Index)
+		}
+		last = chunk
+	}
+	if text != "Checking " {
+		t.Errorf("expected streamed text, got %q", text)
+	}
+	if len(indexes) != 2 || indexes[0] != 0 || indexes[1] != 1 {
+		t.Errorf("expected tool calls numbered 0 and 1, got %v", indexes)
+	}
+	if last == nil || last.FinishReason != FinishReasonToolCalls {
+		t.Fatalf("expected a final chunk with finish reason tool_calls, got %+v", last)
+	}
+	calls := last.Snapshot.ToolCalls
+	if len(calls) != 2 || calls[0].Function != "get_weather" || calls[1].Function != "get_time" || calls[1].Arguments != "{}" {
+		t.Errorf("expected both tool calls in the snapshot, got %+v", calls)
+	}
+}
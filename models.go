@@ -0,0 +1,220 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ModelInfo describes a model offered by a provider, normalized across providers.
+// Fields a provider does not report are left at their zero value.
+type ModelInfo struct {
+	ID               string    // Identifier to pass to WithModel or Request.Model
+	DisplayName      string    // Human-readable name
+	Description      string    // Provider-supplied description
+	OwnedBy          string    // Owning organization (OpenAI)
+	Created          time.Time // Creation or release time
+	ContextWindow    int       // Maximum input tokens (Gemini); see WithModelInfo
+	OutputTokenLimit int       // Maximum output tokens (Gemini)
+}
+
+// ModelLister is implemented by clients that can enumerate the models
+// available to their credentials.
+type ModelLister interface {
+	ListModels(ctx context.Context) ([]ModelInfo, error)
+}
+
+// modelListAdapter is implemented by providers that expose a models endpoint.
+type modelListAdapter interface {
+	// getListModelsPath returns the endpoint for one page of models. An empty
+	// pageToken requests the first page.
+	getListModelsPath(pageToken string) string
+	// parseModelList decodes one page and returns the token of the next page,
+	// or an empty string when there are no more pages.
+	parseModelList(body []byte) (models []ModelInfo, nextPageToken string, err error)
+}
+
+// ListModels returns every model the provider reports, following pagination.
+func (c *genericClient) ListModels(ctx context.Context) ([]ModelInfo, error) {
+	l, ok := c.adapter.(modelListAdapter)
+	if !ok {
+		return nil, fmt.Errorf("model listing not supported by provider %s", c.b.provider)
+	}
+
+	var models []ModelInfo
+	pageToken := ""
+	for {
+		body, err := c.b.doRequestRaw(ctx, "GET", l.getListModelsPath(pageToken), nil)
+		if err != nil {
+			return nil, err
+		}
+		page, next, err := l.parseModelList(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s model list: %w", c.b.provider, err)
+		}
+		models = append(models, page...)
+		if next == "" || next == pageToken {
+			c.limits.record(models)
+			return models, nil
+		}
+		pageToken = next
+	}
+}
+
+// modelLimits records the OutputTokenLimit and ContextWindow of known models,
+// from WithModelInfo and ListModels. The output limit serves as the model's
+// default Request.MaxTokens, the context window as its WithContextWindow.
+type modelLimits struct {
+	mu      sync.RWMutex
+	output  map[string]int
+	context map[string]int
+}
+
+func (l *modelLimits) record(models []ModelInfo) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, m := range models {
+		if m.OutputTokenLimit > 0 {
+			if l.output == nil {
+				l.output = make(map[string]int)
+			}
+			l.output[m.ID] = m.OutputTokenLimit
+		}
+		if m.ContextWindow > 0 {
+			if l.context == nil {
+				l.context = make(map[string]int)
+			}
+			l.context[m.ID] = m.ContextWindow
+		}
+	}
+}
+
+// outputLimit returns the model's output token limit, or 0 if it is unknown.
+func (l *modelLimits) outputLimit(model string) int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.output[model]
+}
+
+// contextWindow returns the model's context window, or 0 if it is unknown.
+func (l *modelLimits) contextWindow(model string) int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.context[model]
+}
+
+// --- OpenAI ---
+
+type openaiModelList struct {
+	Data []struct {
+		ID      string `json:"id"`
+		Created int64  `json:"created"`
+		OwnedBy string `json:"owned_by"`
+	} `json:"data"`
+}
+
+// getListModelsPath returns the models endpoint; OpenAI does not paginate it.
+func (a *openaiAdapter) getListModelsPath(pageToken string) string {
+	return "/models"
+}
+
+func (a *openaiAdapter) parseModelList(body []byte) ([]ModelInfo, string, error) {
+	var resp openaiModelList
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, "", err
+	}
+	models := make([]ModelInfo, 0, len(resp.Data))
+	for _, m := range resp.Data {
+		info := ModelInfo{ID: m.ID, OwnedBy: m.OwnedBy}
+		if m.Created > 0 {
+			info.Created = time.Unix(m.Created, 0).UTC()
+		}
+		models = append(models, info)
+	}
+	return models, "", nil
+}
+
+// --- Gemini ---
+
+type geminiModelList struct {
+	Models []struct {
+		Name             string `json:"name"`
+		DisplayName      string `json:"displayName"`
+		Description      string `json:"description"`
+		InputTokenLimit  int    `json:"inputTokenLimit"`
+		OutputTokenLimit int    `json:"outputTokenLimit"`
+	} `json:"models"`
+	NextPageToken string `json:"nextPageToken"`
+}
+
+func (a *geminiAdapter) getListModelsPath(pageToken string) string {
+	path := "/models?pageSize=1000"
+	if pageToken != "" {
+		path += "&pageToken=" + url.QueryEscape(pageToken)
+	}
+	return path
+}
+
+func (a *geminiAdapter) parseModelList(body []byte) ([]ModelInfo, string, error) {
+	var resp geminiModelList
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, "", err
+	}
+	models := make([]ModelInfo, 0, len(resp.Models))
+	for _, m := range resp.Models {
+		models = append(models, ModelInfo{
+			// Gemini names models as "models/<id>"; strip the prefix so the ID
+			// can be used directly with WithModel.
+			ID:               strings.TrimPrefix(m.Name, "models/"),
+			DisplayName:      m.DisplayName,
+			Description:      m.Description,
+			ContextWindow:    m.InputTokenLimit,
+			OutputTokenLimit: m.OutputTokenLimit,
+		})
+	}
+	return models, resp.NextPageToken, nil
+}
+
+// --- Anthropic ---
+
+type anthropicModelList struct {
+	Data []struct {
+		ID          string    `json:"id"`
+		DisplayName string    `json:"display_name"`
+		CreatedAt   time.Time `json:"created_at"`
+	} `json:"data"`
+	HasMore bool   `json:"has_more"`
+	LastID  string `json:"last_id"`
+}
+
+func (a *anthropicAdapter) getListModelsPath(pageToken string) string {
+	path := "/models?limit=1000"
+	if pageToken != "" {
+		path += "&after_id=" + url.QueryEscape(pageToken)
+	}
+	return path
+}
+
+func (a *anthropicAdapter) parseModelList(body []byte) ([]ModelInfo, string, error) {
+	var resp anthropicModelList
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, "", err
+	}
+	models := make([]ModelInfo, 0, len(resp.Data))
+	for _, m := range resp.Data {
+		models = append(models, ModelInfo{
+			ID:          m.ID,
+			DisplayName: m.DisplayName,
+			Created:     m.CreatedAt,
+		})
+	}
+	next := ""
+	if resp.HasMore {
+		next = resp.LastID
+	}
+	return models, next, nil
+}
@@ -0,0 +1,117 @@
+package ai
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestListModels(t *testing.T) {
+	tests := []struct {
+		provider Provider
+		path     string
+		pages    map[string]string // page token query value -> response body
+		tokenKey string
+		want     []ModelInfo
+	}{
+		{
+			provider: ProviderOpenAI,
+			path:     "/v1/models",
+			pages: map[string]string{
+				"": `{"object":"list","data":[
+					{"id":"gpt-4o","object":"model","created":1715367049,"owned_by":"system"},
+					{"id":"gpt-4o-mini","object":"model","created":1721172741,"owned_by":"system"}]}`,
+			},
+			want: []ModelInfo{
+				{ID: "gpt-4o", OwnedBy: "system", Created: time.Unix(1715367049, 0).UTC()},
+				{ID: "gpt-4o-mini", OwnedBy: "system", Created: time.Unix(1721172741, 0).UTC()},
+			},
+		},
+		{
+			provider: ProviderGemini,
+			path:     "/v1beta/models",
+			tokenKey: "pageToken",
+			pages: map[string]string{
+				"": `{"models":[{"name":"models/gemini-2.5-flash","displayName":"Gemini 2.5 Flash",
+					"description":"Fast model","inputTokenLimit":1048576,"outputTokenLimit":65536}],
+					"nextPageToken":"p2"}`,
+				"p2": `{"models":[{"name":"models/gemini-2.5-pro","displayName":"Gemini 2.5 Pro"}]}`,
+			},
+			want: []ModelInfo{
+				{ID: "gemini-2.5-flash", DisplayName: "Gemini 2.5 Flash", Description: "Fast model", ContextWindow: 1048576, OutputTokenLimit: 65536},
+				{ID: "gemini-2.5-pro", DisplayName: "Gemini 2.5 Pro"},
+			},
+		},
+		{
+			provider: ProviderAnthropic,
+			path:     "/v1/models",
+			tokenKey: "after_id",
+			pages: map[string]string{
+				"": `{"data":[{"type":"model","id":"claude-sonnet-4-5","display_name":"Claude Sonnet 4.5",
+					"created_at":"2025-09-29T00:00:00Z"}],"has_more":true,"first_id":"claude-sonnet-4-5","last_id":"claude-sonnet-4-5"}`,
+				"claude-sonnet-4-5": `{"data":[{"type":"model","id":"claude-haiku-4-5","display_name":"Claude Haiku 4.5",
+					"created_at":"2025-10-15T00:00:00Z"}],"has_more":false,"last_id":"claude-haiku-4-5"}`,
+			},
+			want: []ModelInfo{
+				{ID: "claude-sonnet-4-5", DisplayName: "Claude Sonnet 4.5", Created: time.Date(2025, 9, 29, 0, 0, 0, 0, time.UTC)},
+				{ID: "claude-haiku-4-5", DisplayName: "Claude Haiku 4.5", Created: time.Date(2025, 10, 15, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.provider), func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != "GET" || r.URL.Path != tt.path {
+					t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+				}
+				token := ""
+				if tt.tokenKey != "" {
+					token = r.URL.Query().Get(tt.tokenKey)
+				}
+				body, ok := tt.pages[token]
+				if !ok {
+					t.Errorf("unexpected page token %q", token)
+					body = `{}`
+				}
+				w.Write([]byte(body))
+			}))
+			defer server.Close()
+
+			client, err := NewClient(WithProvider(tt.provider), WithAPIKey("test-key"), WithBaseURL(server.URL))
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+			lister, ok := client.(ModelLister)
+			if !ok {
+				t.Fatalf("%s client does not implement ModelLister", tt.provider)
+			}
+
+			got, err := lister.ListModels(context.Background())
+			if err != nil {
+				t.Fatalf("ListModels failed: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %d models, got %d: %+v", len(tt.want), len(got), got)
+			}
+			for i := range tt.want {
+				if !got[i].Created.Equal(tt.want[i].Created) {
+					t.Errorf("model %d: expected created %v, got %v", i, tt.want[i].Created, got[i].Created)
+				}
+				got[i].Created, tt.want[i].Created = time.Time{}, time.Time{}
+				if got[i] != tt.want[i] {
+					t.Errorf("model %d: expected %+v, got %+v", i, tt.want[i], got[i])
+				}
+			}
+
+			// Listed output limits become the default MaxTokens.
+			for _, m := range tt.want {
+				if limit := client.(*genericClient).limits.outputLimit(m.ID); limit != m.OutputTokenLimit {
+					t.Errorf("expected output limit %d recorded for %s, got %d", m.OutputTokenLimit, m.ID, limit)
+				}
+			}
+		})
+	}
+}
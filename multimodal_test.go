@@ -1,13 +1,17 @@
 package ai
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 // TestMultimodalImageURL tests image input via URL for all providers
@@ -376,3 +380,270 @@ func TestHelperFunctions(t *testing.T) {
 		}
 	})
 }
+
+// TestDataURIPrefixStripped tests that data URI prefixes are removed from base64
+// documents, audio and video before they reach providers that expect raw base64
+func TestDataURIPrefixStripped(t *testing.T) {
+	const payload = "JVBERi0xLjQKJcfsj6IKNSAwIG9iago="
+	tests := []struct {
+		name    string
+		adapter providerAdapter
+		part    ContentPart
+	}{
+		{"gemini document", &geminiAdapter{}, NewDocumentPartFromBase64("data:application/pdf;base64,"+payload, "application/pdf")},
+		{"gemini audio", &geminiAdapter{}, NewAudioPartFromBase64("data:audio/wav;base64,"+payload, "wav")},
+		{"gemini video", &geminiAdapter{}, NewVideoPartFromBase64("data:video/mp4;base64,"+payload, "mp4")},
+		{"gemini image", &geminiAdapter{}, NewImagePartFromBase64("data:image/png;base64,"+payload, "png")},
+		{"anthropic document", &anthropicAdapter{}, NewDocumentPartFromBase64("data:application/pdf;base64,"+payload, "application/pdf")},
+		{"anthropic image", &anthropicAdapter{}, NewImagePartFromBase64("data:image/png;base64,"+payload, "png")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &Request{Messages: []Message{NewMultimodalMessage(RoleUser, []ContentPart{NewTextPart("Summarize"), tt.part})}}
+			body, err := tt.adapter.buildRequestPayload(context.Background(), req)
+			if err != nil {
+				t.Fatalf("buildRequestPayload failed: %v", err)
+			}
+			data, err := json.Marshal(body)
+			if err != nil {
+				t.Fatalf("Failed to marshal payload: %v", err)
+			}
+			if strings.Contains(string(data), "data:") {
+				t.Errorf("Expected data URI prefix to be stripped, got: %s", data)
+			}
+			if !strings.Contains(string(data), `"`+payload+`"`) {
+				t.Errorf("Expected clean base64 %q in payload, got: %s", payload, data)
+			}
+		})
+	}
+
+	// OpenAI has no document input, so the part is rejected rather than forwarded.
+	req := &Request{Messages: []Message{NewMultimodalMessage(RoleUser, []ContentPart{
+		NewDocumentPartFromBase64("data:application/pdf;base64,"+payload, "application/pdf"),
+	})}}
+	if _, err := (&openaiAdapter{}).buildRequestPayload(context.Background(), req); err == nil {
+		t.Error("Expected OpenAI to reject document input")
+	}
+}
+
+// TestImagePartFromReader tests that reader-backed images are encoded when the request is sent
+func TestImagePartFromReader(t *testing.T) {
+	raw := []byte("\x89PNG\r\n\x1a\nfake image bytes")
+	want := "data:image/png;base64," + base64.StdEncoding.EncodeToString(raw)
+
+	var gotURL string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []struct {
+				Content []struct {
+					ImageURL struct {
+						URL string `json:"url"`
+					} `json:"image_url"`
+				} `json:"content"`
+			} `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Failed to parse request: %v", err)
+		}
+		if len(body.Messages) == 1 && len(body.Messages[0].Content) == 2 {
+			gotURL = body.Messages[0].Content[1].ImageURL.URL
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"A picture"}}]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithProvider(ProviderOpenAI), WithAPIKey("test-key"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	part := NewImagePartFromReader(bytes.NewReader(raw), "png")
+	req := &Request{Messages: []Message{NewMultimodalMessage(RoleUser, []ContentPart{NewTextPart("Describe"), part})}}
+	if _, err := client.Generate(context.Background(), req); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if gotURL != want {
+		t.Errorf("Expected image %q, got %q", want, gotURL)
+	}
+	if req.Messages[0].ContentParts[1].ImageSource.Type != ImageSourceTypeReader {
+		t.Error("Expected the caller's request to be left unmodified")
+	}
+
+	// A nil reader fails validation; an empty one fails when read.
+	req = &Request{Messages: []Message{NewMultimodalMessage(RoleUser, []ContentPart{NewImagePartFromReader(nil, "png")})}}
+	if _, err := client.Generate(context.Background(), req); err == nil || !strings.Contains(err.Error(), "image reader cannot be nil") {
+		t.Errorf("Expected nil reader validation error, got: %v", err)
+	}
+	req = &Request{Messages: []Message{NewMultimodalMessage(RoleUser, []ContentPart{NewImagePartFromReader(bytes.NewReader(nil), "png")})}}
+	if _, err := client.Generate(context.Background(), req); err == nil || !strings.Contains(err.Error(), "no data") {
+		t.Errorf("Expected empty reader error, got: %v", err)
+	}
+}
+
+// TestMissingContentSource verifies that every adapter rejects a media part
+// without a source instead of emitting an empty part.
+func TestMissingContentSource(t *testing.T) {
+	adapters := map[string]providerAdapter{
+		"openai":    &openaiAdapter{},
+		"anthropic": &anthropicAdapter{},
+		"gemini":    &geminiAdapter{},
+	}
+	sources := map[ContentType]string{
+		ContentTypeImage:    "ImageSource",
+		ContentTypeAudio:    "AudioSource",
+		ContentTypeVideo:    "VideoSource",
+		ContentTypeDocument: "DocumentSource",
+	}
+
+	for name, adapter := range adapters {
+		for contentType, field := range sources {
+			t.Run(name+"/"+string(contentType), func(t *testing.T) {
+				req := &Request{Messages: []Message{{
+					Role:         RoleUser,
+					ContentParts: []ContentPart{{Type: contentType}},
+				}}}
+				_, err := adapter.buildRequestPayload(context.Background(), req)
+				if !errors.Is(err, ErrMissingContentSource) {
+					t.Fatalf("expected ErrMissingContentSource, got %v", err)
+				}
+				if !strings.Contains(err.Error(), field) {
+					t.Errorf("expected error to name %s, got %q", field, err)
+				}
+			})
+		}
+	}
+}
+
+// TestGeminiAudioMIMETypes tests that each audio format is sent to Gemini with
+// its MIME type
+func TestGeminiAudioMIMETypes(t *testing.T) {
+	tests := map[string]string{
+		"mp3":  "audio/mpeg",
+		"wav":  "audio/wav",
+		"aiff": "audio/aiff",
+		"aac":  "audio/aac",
+		"ogg":  "audio/ogg",
+		"flac": "audio/flac",
+		"m4a":  "audio/mp4",
+		"WAV":  "audio/wav",
+	}
+	for format, want := range tests {
+		req := &Request{Messages: []Message{NewMultimodalMessage(RoleUser, []ContentPart{NewAudioPartFromBase64("UklGRg==", format)})}}
+		payload, err := (&geminiAdapter{}).buildRequestPayload(context.Background(), req)
+		if err != nil {
+			t.Fatalf("%s: buildRequestPayload failed: %v", format, err)
+		}
+		inline := payload.(*geminiGenerateContentRequest).Contents[0].Parts[0].InlineData
+		if inline == nil || inline.MimeType != want {
+			t.Errorf("%s: expected MIME type %s, got %+v", format, want, inline)
+		}
+	}
+}
+
+// TestAnthropicImageModeBase64 tests that a request Anthropic rejects for its
+// URL image source succeeds when WithAnthropicImageMode downloads the image
+// and sends it as base64
+func TestAnthropicImageModeBase64(t *testing.T) {
+	imageData := testPNG(t, 8, 8)
+	imageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(imageData)
+	}))
+	defer imageServer.Close()
+
+	var source anthropicImageSource
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []struct {
+				Content []struct {
+					Source *anthropicImageSource `json:"source"`
+				} `json:"content"`
+			} `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		source = *body.Messages[0].Content[0].Source
+		if source.Type == "url" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"type":"error","error":{"type":"invalid_request_error","message":"url image source is not supported"}}`))
+			return
+		}
+		w.Write([]byte(`{"content":[{"type":"text","text":"ok"}],"stop_reason":"end_turn"}`))
+	}))
+	defer server.Close()
+
+	req := &Request{Messages: []Message{{Role: RoleUser, ContentParts: []ContentPart{
+		NewImagePartFromURL(imageServer.URL + "/photo"),
+	}}}}
+	for _, mode := range []AnthropicImageMode{AnthropicImageModeURL, AnthropicImageModeBase64} {
+		client, err := NewClient(WithProvider(ProviderAnthropic), WithAPIKey("test-key"), WithBaseURL(server.URL),
+			WithAnthropicImageMode(mode))
+		if err != nil {
+			t.Fatalf("failed to create client: %v", err)
+		}
+		_, err = client.Generate(context.Background(), req)
+		if mode == AnthropicImageModeURL {
+			if err == nil {
+				t.Fatal("expected the URL image source to be rejected")
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Generate failed in base64 mode: %v", err)
+		}
+		if source.MediaType != "image/png" || source.Data != base64.StdEncoding.EncodeToString(imageData) {
+			t.Errorf("expected the downloaded PNG as a base64 source, got %+v", source)
+		}
+	}
+
+	if _, err := NewClient(WithProvider(ProviderAnthropic), WithAPIKey("test-key"), WithAnthropicImageMode("inline")); err == nil {
+		t.Error("expected an unknown image mode to be rejected")
+	}
+}
+
+// TestAnthropicDownloadBudget tests that a slow image download in base64 mode
+// is cut off by its share of the request deadline with a download timeout error
+func TestAnthropicDownloadBudget(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer slow.Close()
+	defer close(release)
+
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{"content":[{"type":"text","text":"ok"}],"stop_reason":"end_turn"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithProvider(ProviderAnthropic), WithAPIKey("test-key"), WithBaseURL(server.URL),
+		WithAnthropicImageMode(AnthropicImageModeBase64), WithDownloadTimeout(time.Minute), WithDownloadBudget(0.2))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	_, err = client.Generate(ctx, &Request{Messages: []Message{{
+		Role:         RoleUser,
+		ContentParts: []ContentPart{NewImagePartFromURL(slow.URL + "/slow.png")},
+	}}})
+	elapsed := time.Since(start)
+
+	var timeoutErr *TimeoutError
+	if !errors.As(err, &timeoutErr) || timeoutErr.Kind != TimeoutKindDownload {
+		t.Fatalf("expected a download timeout error, got %v", err)
+	}
+	if elapsed > time.Second || ctx.Err() != nil {
+		t.Errorf("expected the download cut off well before the deadline, took %v", elapsed)
+	}
+	if calls != 0 {
+		t.Errorf("expected no generation call after the failed download, got %d", calls)
+	}
+}
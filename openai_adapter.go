@@ -8,8 +8,14 @@ import (
 	"strings"
 )
 
+// prefillInstruction is appended after a trailing assistant message by the
+// adapters that emulate prefill (OpenAI and Gemini).
+const prefillInstruction = "Continue the previous assistant message exactly where it ends. Do not repeat any of it."
+
 // openaiAdapter implements the providerAdapter interface for OpenAI.
-type openaiAdapter struct{}
+type openaiAdapter struct {
+	params paramPolicy
+}
 
 func (a *openaiAdapter) getModel(req *Request) string {
 	if req.Model == "" {
@@ -23,14 +29,85 @@ func (a *openaiAdapter) getEndpoint(model string) string {
 }
 
 func (a *openaiAdapter) buildRequestPayload(ctx context.Context, req *Request) (any, error) {
+	req = req.hoistSystemMessage()
 	openaiReq := &OpenAIChatCompletionRequest{
 		Model:    a.getModel(req),
 		Messages: make([]openaiMessage, len(req.Messages)),
+		Stop:     req.StopSequences,
+	}
+
+	// Reasoning models reject sampling parameters.
+	reasoning := isOpenAIReasoningModel(openaiReq.Model)
+	if req.Temperature != nil {
+		if reasoning {
+			if err := a.params.unsupported(ProviderOpenAI, openaiReq.Model, "Temperature"); err != nil {
+				return nil, err
+			}
+		} else {
+			openaiReq.Temperature = req.Temperature
+		}
+	}
+	if req.TopP != nil {
+		if reasoning {
+			if err := a.params.unsupported(ProviderOpenAI, openaiReq.Model, "TopP"); err != nil {
+				return nil, err
+			}
+		} else {
+			openaiReq.TopP = req.TopP
+		}
+	}
+	if req.TopK != nil {
+		if err := a.params.unsupported(ProviderOpenAI, openaiReq.Model, "TopK"); err != nil {
+			return nil, err
+		}
+	}
+	if len(req.LogitBias) > 0 {
+		if reasoning {
+			if err := a.params.unsupported(ProviderOpenAI, openaiReq.Model, "LogitBias"); err != nil {
+				return nil, err
+			}
+		} else {
+			openaiReq.LogitBias = req.LogitBias
+		}
+	}
+
+	if req.ReasoningEffort != "" {
+		if reasoning {
+			openaiReq.ReasoningEffort = req.ReasoningEffort
+		} else if err := a.params.unsupported(ProviderOpenAI, openaiReq.Model, "ReasoningEffort"); err != nil {
+			return nil, err
+		}
+	}
+
+	// Reasoning models count hidden reasoning against the limit and reject max_tokens.
+	if req.MaxTokens > 0 {
+		if reasoning {
+			openaiReq.MaxCompletionTokens = req.MaxTokens
+		} else {
+			openaiReq.MaxTokens = req.MaxTokens
+		}
+	}
+
+	if req.Logprobs {
+		if reasoning {
+			if err := a.params.unsupported(ProviderOpenAI, openaiReq.Model, "Logprobs"); err != nil {
+				return nil, err
+			}
+		} else {
+			openaiReq.Logprobs = true
+		}
+	}
+
+	if req.Thinking != nil {
+		if err := a.params.unsupported(ProviderOpenAI, openaiReq.Model, "Thinking"); err != nil {
+			return nil, err
+		}
 	}
 
 	for i, msg := range req.Messages {
 		openaiMsg := openaiMessage{
 			Role:       string(msg.Role),
+			Name:       msg.Name,
 			ToolCallID: msg.ToolCallID,
 		}
 
@@ -39,40 +116,40 @@ func (a *openaiAdapter) buildRequestPayload(ctx context.Context, req *Request) (
 			// Convert content parts to OpenAI format
 			parts := make([]openaiContentPart, 0, len(msg.ContentParts))
 			for _, part := range msg.ContentParts {
+				if err := part.checkSource(); err != nil {
+					return nil, err
+				}
 				switch part.Type {
 				case ContentTypeText:
+					if part.Text == "" {
+						continue // e.g. beside tool calls; the content is then null
+					}
 					parts = append(parts, openaiContentPart{
 						Type: "text",
 						Text: part.Text,
 					})
 				case ContentTypeImage:
-					if part.ImageSource != nil {
-						var url string
-						switch part.ImageSource.Type {
-						case ImageSourceTypeURL:
-							url = part.ImageSource.URL
-						case ImageSourceTypeBase64:
-							// Format as data URI
-							url = formatBase64AsDataURI(part.ImageSource.Data, part.ImageSource.Format)
-						}
-						parts = append(parts, openaiContentPart{
-							Type: "image_url",
-							ImageURL: &openaiImageURL{
-								URL: url,
-							},
-						})
+					var url string
+					switch part.ImageSource.Type {
+					case ImageSourceTypeURL:
+						url = part.ImageSource.URL
+					case ImageSourceTypeBase64:
+						// Format as data URI
+						url = formatBase64AsDataURI(part.ImageSource.Data, part.ImageSource.Format)
 					}
-				case ContentTypeAudio:
-					return nil, fmt.Errorf("OpenAI provider does not support audio input (content type: audio). Supported providers: Gemini")
-				case ContentTypeVideo:
-					return nil, fmt.Errorf("OpenAI provider does not support video input (content type: video). Supported providers: Gemini")
-				case ContentTypeDocument:
-					return nil, fmt.Errorf("OpenAI provider does not support document/PDF input (content type: document). Supported providers: Gemini, Anthropic")
+					parts = append(parts, openaiContentPart{
+						Type: "image_url",
+						ImageURL: &openaiImageURL{
+							URL: url,
+						},
+					})
 				default:
-					return nil, fmt.Errorf("OpenAI provider does not support content type: %s", part.Type)
+					return nil, NewUnsupportedContentError(ProviderOpenAI, part.Type)
 				}
 			}
-			openaiMsg.Content = parts
+			if len(parts) > 0 {
+				openaiMsg.Content = parts
+			}
 		} else if msg.Content != "" {
 			// Backward compatibility: simple text content
 			openaiMsg.Content = msg.Content
@@ -107,6 +184,17 @@ func (a *openaiAdapter) buildRequestPayload(ctx context.Context, req *Request) (
 				},
 			}
 		}
+		// OpenAI rejects parallel_tool_calls in requests without tools.
+		openaiReq.ParallelToolCalls = req.ParallelToolCalls
+	}
+
+	// OpenAI has no native assistant prefill; emulate it by asking the model to
+	// continue the trailing assistant message instead of starting a new reply.
+	if _, ok := req.AssistantPrefill(); ok {
+		openaiReq.Messages = append(openaiReq.Messages, openaiMessage{
+			Role:    string(RoleSystem),
+			Content: prefillInstruction,
+		})
 	}
 
 	if req.SystemPrompt != "" {
@@ -120,7 +208,7 @@ func (a *openaiAdapter) buildRequestPayload(ctx context.Context, req *Request) (
 
 func (a *openaiAdapter) parseResponse(providerResp []byte) (*Response, error) {
 	var openaiResp openaiChatCompletionResponse
-	if err := json.Unmarshal(providerResp, &openaiResp); err != nil {
+	if err := decodeFirstJSON(providerResp, &openaiResp); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal openai response: %w", err)
 	}
 
@@ -129,7 +217,13 @@ func (a *openaiAdapter) parseResponse(providerResp []byte) (*Response, error) {
 	}
 
 	choice := openaiResp.Choices[0]
-	universalResp := &Response{}
+	universalResp := &Response{FinishReason: openaiFinishReason(choice.FinishReason)}
+	if u := openaiResp.Usage; u != nil {
+		universalResp.Usage = &Usage{InputTokens: u.PromptTokens, OutputTokens: u.CompletionTokens, TotalTokens: u.TotalTokens}
+		if d := u.CompletionTokensDetails; d != nil {
+			universalResp.Usage.ReasoningTokens = d.ReasoningTokens
+		}
+	}
 
 	// Handle Content field which can be either string (text-only) or []openaiContentPart (multimodal)
 	switch content := choice.Message.Content.(type) {
@@ -148,6 +242,13 @@ func (a *openaiAdapter) parseResponse(providerResp []byte) (*Response, error) {
 		}
 	}
 
+	universalResp.Reasoning = choice.Message.ReasoningContent
+	universalResp.Citations = openaiCitations(choice.Message.Annotations)
+	universalResp.Logprobs = choice.Logprobs.tokens()
+	if choice.Message.Refusal != "" {
+		universalResp.Warnings = append(universalResp.Warnings, "model refused: "+choice.Message.Refusal)
+	}
+
 	if len(choice.Message.ToolCalls) > 0 {
 		universalResp.ToolCalls = make([]ToolCall, len(choice.Message.ToolCalls))
 		for i, tc := range choice.Message.ToolCalls {
@@ -170,6 +271,11 @@ func (a *openaiAdapter) enableStreaming(payload any) {
 }
 
 func (a *openaiAdapter) parseStreamEvent(event *sseEvent, acc *streamAccumulator) (*StreamChunk, bool, error) {
+	// Some OpenAI-compatible servers name events ("event: message") and send
+	// data-less events such as pings; only the data matters here.
+	if len(event.Data) == 0 {
+		return nil, false, nil
+	}
 	if string(event.Data) == "[DONE]" {
 		return &StreamChunk{Done: true}, true, nil
 	}
@@ -186,47 +292,83 @@ func (a *openaiAdapter) parseStreamEvent(event *sseEvent, acc *streamAccumulator
 	choice := chunkResp.Choices[0]
 	chunk := &StreamChunk{}
 
-	// Handle content which can be array of parts or raw string
+	// Content is usually a plain string delta, but some providers send an
+	// array of parts; null and unrecognized content carry no text.
 	if len(choice.Delta.Content) > 0 {
+		var s string
 		var parts []openaiContentPart
-		if err := json.Unmarshal(choice.Delta.Content, &parts); err == nil {
+		if err := json.Unmarshal(choice.Delta.Content, &s); err == nil {
+			chunk.TextDelta = s
+		} else if err := json.Unmarshal(choice.Delta.Content, &parts); err == nil {
 			for _, part := range parts {
 				if part.Type == "text" {
 					chunk.TextDelta += part.Text
 				}
 			}
-		} else {
-			// Try as plain string
-			var s string
-			if errStr := json.Unmarshal(choice.Delta.Content, &s); errStr == nil {
-				chunk.TextDelta += s
-			} else {
-				// Ignore unrecognized content
-			}
 		}
 	}
 
+	chunk.ReasoningDelta = choice.Delta.ReasoningContent
+
 	for _, tc := range choice.Delta.ToolCalls {
+		// OpenAI only sends the ID on the first fragment of each tool call;
+		// later fragments are identified by their index alone.
+		id := tc.ID
+		if id != "" {
+			acc.toolIndex[tc.Index] = id
+		} else {
+			id = acc.toolIndex[tc.Index]
+		}
 		chunk.ToolCallDeltas = append(chunk.ToolCallDeltas, ToolCallDelta{
-			ID:             tc.ID,
+			Index:          tc.Index,
+			ID:             id,
 			Type:           tc.Type,
 			Function:       tc.Function.Name,
 			ArgumentsDelta: tc.Function.Arguments,
 		})
 	}
 
+	chunk.Citations = openaiCitations(choice.Delta.Annotations)
+	chunk.Logprobs = choice.Logprobs.tokens()
+
 	if choice.FinishReason != "" {
 		chunk.Done = true
+		chunk.FinishReason = openaiFinishReason(choice.FinishReason)
 		return chunk, true, nil
 	}
 
-	if chunk.TextDelta == "" && len(chunk.ToolCallDeltas) == 0 && !chunk.Done {
+	if chunk.TextDelta == "" && chunk.ReasoningDelta == "" && len(chunk.ToolCallDeltas) == 0 && len(chunk.Citations) == 0 && len(chunk.Logprobs) == 0 && !chunk.Done {
 		return nil, false, nil
 	}
 
 	return chunk, false, nil
 }
 
+// openaiCitations converts url_citation annotations; other annotation types are ignored.
+func openaiCitations(annotations []openaiAnnotation) []Citation {
+	var citations []Citation
+	for _, ann := range annotations {
+		if ann.Type != "url_citation" || ann.URLCitation == nil {
+			continue
+		}
+		citations = append(citations, Citation{
+			URL:        ann.URLCitation.URL,
+			Title:      ann.URLCitation.Title,
+			StartIndex: ann.URLCitation.StartIndex,
+			EndIndex:   ann.URLCitation.EndIndex,
+		})
+	}
+	return citations
+}
+
+// openaiFinishReason normalizes an OpenAI finish_reason.
+func openaiFinishReason(reason string) FinishReason {
+	if reason == "function_call" {
+		return FinishReasonToolCalls
+	}
+	return FinishReason(strings.ToLower(reason))
+}
+
 func (a *openaiAdapter) getStreamEndpoint(model string) string {
 	return a.getEndpoint(model)
 }
@@ -245,13 +387,63 @@ type OpenAIChatCompletionRequest struct {
 	Messages []openaiMessage `json:"messages"`
 	Tools    []openaiTool    `json:"tools,omitempty"`
 	Stream   bool            `json:"stream,omitempty"`
+	Stop     openaiStop      `json:"stop,omitempty"`
+
+	Temperature         *float64       `json:"temperature,omitempty"`
+	TopP                *float64       `json:"top_p,omitempty"`
+	LogitBias           map[string]int `json:"logit_bias,omitempty"`
+	MaxTokens           int            `json:"max_tokens,omitempty"`
+	MaxCompletionTokens int            `json:"max_completion_tokens,omitempty"`
+
+	ReasoningEffort   string `json:"reasoning_effort,omitempty"`
+	Logprobs          bool   `json:"logprobs,omitempty"`
+	ParallelToolCalls *bool  `json:"parallel_tool_calls,omitempty"`
+}
+
+// openaiStop holds the stop sequences, which OpenAI accepts as a single string
+// or an array of strings. null means no stop sequences.
+type openaiStop []string
+
+func (s *openaiStop) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*s = nil
+		return nil
+	}
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*s = openaiStop{single}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(s))
 }
 
 type openaiMessage struct {
 	Role       string           `json:"role"`
-	Content    any              `json:"content,omitempty"` // string or []openaiContentPart
+	Name       string           `json:"name,omitempty"`
+	Content    any              `json:"content"` // string or []openaiContentPart; null on tool-call-only assistant messages, as OpenAI sends them
 	ToolCalls  []openaiToolCall `json:"tool_calls,omitempty"`
 	ToolCallID string           `json:"tool_call_id,omitempty"`
+	// Refusal is set on responses when the model declines to answer.
+	Refusal string `json:"refusal,omitempty"`
+	// Annotations carry URL citations on responses when web search is used.
+	Annotations []openaiAnnotation `json:"annotations,omitempty"`
+	// ReasoningContent is the chain of thought returned by OpenAI-compatible
+	// reasoning models such as DeepSeek's; OpenAI itself never sets it.
+	ReasoningContent string `json:"reasoning_content,omitempty"`
+	// Prefix marks a trailing assistant message for Mistral to continue.
+	Prefix bool `json:"prefix,omitempty"`
+}
+
+type openaiAnnotation struct {
+	Type        string             `json:"type"` // "url_citation"
+	URLCitation *openaiURLCitation `json:"url_citation,omitempty"`
+}
+
+type openaiURLCitation struct {
+	URL        string `json:"url"`
+	Title      string `json:"title,omitempty"`
+	StartIndex int    `json:"start_index"`
+	EndIndex   int    `json:"end_index"`
 }
 
 type openaiContentPart struct {
@@ -297,15 +489,40 @@ type openaiChatCompletionResponse struct {
 }
 
 type openaiChoice struct {
-	Index        int           `json:"index"`
-	Message      openaiMessage `json:"message"`
-	FinishReason string        `json:"finish_reason,omitempty"`
+	Index        int             `json:"index"`
+	Message      openaiMessage   `json:"message"`
+	FinishReason string          `json:"finish_reason,omitempty"`
+	Logprobs     *openaiLogprobs `json:"logprobs,omitempty"`
+}
+
+// openaiLogprobs holds per-token log probabilities, on both responses and stream chunks.
+type openaiLogprobs struct {
+	Content []struct {
+		Token   string  `json:"token"`
+		Logprob float64 `json:"logprob"`
+	} `json:"content"`
+}
+
+// tokens converts l to the universal form; it is nil-safe.
+func (l *openaiLogprobs) tokens() []TokenLogprob {
+	if l == nil {
+		return nil
+	}
+	var out []TokenLogprob
+	for _, c := range l.Content {
+		out = append(out, TokenLogprob{Token: c.Token, Logprob: c.Logprob})
+	}
+	return out
 }
 
 type openaiUsage struct {
 	PromptTokens     int `json:"prompt_tokens"`
 	CompletionTokens int `json:"completion_tokens"`
 	TotalTokens      int `json:"total_tokens"`
+
+	CompletionTokensDetails *struct {
+		ReasoningTokens int `json:"reasoning_tokens"`
+	} `json:"completion_tokens_details,omitempty"`
 }
 
 // Streaming response types
@@ -317,14 +534,18 @@ type openaiStreamChoice struct {
 	Index        int               `json:"index"`
 	Delta        openaiStreamDelta `json:"delta"`
 	FinishReason string            `json:"finish_reason"`
+	Logprobs     *openaiLogprobs   `json:"logprobs,omitempty"`
 }
 
 type openaiStreamDelta struct {
-	Content   json.RawMessage       `json:"content"`
-	ToolCalls []openaiToolCallDelta `json:"tool_calls"`
+	Content          json.RawMessage       `json:"content"`
+	ReasoningContent string                `json:"reasoning_content,omitempty"`
+	ToolCalls        []openaiToolCallDelta `json:"tool_calls"`
+	Annotations      []openaiAnnotation    `json:"annotations,omitempty"`
 }
 
 type openaiToolCallDelta struct {
+	Index    int                     `json:"index"`
 	ID       string                  `json:"id,omitempty"`
 	Type     string                  `json:"type,omitempty"`
 	Function openaiFunctionCallDelta `json:"function"`
@@ -1,8 +1,6 @@
 package ai
 
 import (
-	"crypto/rand"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -22,7 +20,9 @@ func NewOpenAIFormatConverter() *OpenAIFormatConverter {
 // DecodeRequest decodes the request body into the OpenAI request struct.
 func (c *OpenAIFormatConverter) DecodeRequest(r *http.Request) (any, error) {
 	var req OpenAIChatCompletionRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	dec := json.NewDecoder(r.Body)
+	dec.UseNumber() // Keep numbers in tool inputs and schemas exact
+	if err := dec.Decode(&req); err != nil {
 		return nil, fmt.Errorf("failed to decode OpenAI request: %w", err)
 	}
 	return &req, nil
@@ -39,8 +39,9 @@ func (c *OpenAIFormatConverter) IsStreaming(providerReq any) bool {
 // NewStreamHandler creates a handler for formatting streaming events.
 func (c *OpenAIFormatConverter) NewStreamHandler(id string, model string) StreamEventHandler {
 	return &OpenAIStreamHandler{
-		ID:    id,
-		Model: model,
+		ID:        id,
+		Model:     model,
+		ToolIndex: make(map[string]int),
 	}
 }
 
@@ -67,8 +68,30 @@ func (c *OpenAIFormatConverter) ConvertRequestFromFormat(providerReq any) (*Requ
 // ConvertResponseToFormat converts a Universal Response to OpenAI format.
 // Implements FormatConverter interface.
 func (c *OpenAIFormatConverter) ConvertResponseToFormat(universalResp *Response, originalModel string) (any, error) {
-	// Token counts are set to 0 as we don't have that info from universal response
-	return c.ConvertResponseToOpenAI(universalResp, originalModel, 0, 0)
+	// Token counts are 0 when the provider reported no usage.
+	var promptTokens, completionTokens int
+	if universalResp != nil && universalResp.Usage != nil {
+		promptTokens, completionTokens = universalResp.Usage.InputTokens, universalResp.Usage.OutputTokens
+	}
+	return c.ConvertResponseToOpenAI(universalResp, originalModel, promptTokens, completionTokens)
+}
+
+// ConvertErrorToFormat converts an error to OpenAI's error format.
+func (c *OpenAIFormatConverter) ConvertErrorToFormat(statusCode int, message string) any {
+	resp := &OpenAIErrorResponse{Error: OpenAIError{Message: message, Type: "invalid_request_error"}}
+	var code string
+	switch {
+	case statusCode == http.StatusUnauthorized:
+		code = "invalid_api_key"
+	case statusCode == http.StatusTooManyRequests:
+		resp.Error.Type, code = "rate_limit_error", "rate_limit_exceeded"
+	case statusCode >= http.StatusInternalServerError:
+		resp.Error.Type = "server_error"
+	}
+	if code != "" {
+		resp.Error.Code = &code
+	}
+	return resp
 }
 
 // ConvertRequestToUniversal converts an OpenAI chat completion request to Universal Request format.
@@ -78,14 +101,23 @@ func (c *OpenAIFormatConverter) ConvertRequestToUniversal(openaiReq *OpenAIChatC
 	}
 
 	universalReq := &Request{
-		Model:    openaiReq.Model,
-		Messages: make([]Message, 0, len(openaiReq.Messages)),
+		Model:             openaiReq.Model,
+		Messages:          make([]Message, 0, len(openaiReq.Messages)),
+		StopSequences:     openaiReq.Stop,
+		Temperature:       openaiReq.Temperature,
+		TopP:              openaiReq.TopP,
+		MaxTokens:         openaiReq.MaxTokens,
+		ParallelToolCalls: openaiReq.ParallelToolCalls,
+	}
+	if openaiReq.MaxCompletionTokens > 0 {
+		universalReq.MaxTokens = openaiReq.MaxCompletionTokens
 	}
 
 	// Convert messages
 	for i, msg := range openaiReq.Messages {
 		universalMsg := Message{
 			Role:       Role(msg.Role),
+			Name:       msg.Name,
 			ToolCallID: msg.ToolCallID,
 		}
 
@@ -207,6 +239,11 @@ func (c *OpenAIFormatConverter) ConvertResponseToOpenAI(universalResp *Response,
 		},
 	}
 
+	// Normalized finish reasons use OpenAI's names.
+	if universalResp.FinishReason != "" {
+		openaiResp.Choices[0].FinishReason = string(universalResp.FinishReason)
+	}
+
 	// Convert tool calls if present
 	if len(universalResp.ToolCalls) > 0 {
 		openaiResp.Choices[0].Message.ToolCalls = make([]openaiToolCall, len(universalResp.ToolCalls))
@@ -231,12 +268,17 @@ func (c *OpenAIFormatConverter) ConvertResponseToOpenAI(universalResp *Response,
 type OpenAIStreamHandler struct {
 	ID    string
 	Model string
+	// ToolIndex assigns each tool call ID its OpenAI stream index.
+	ToolIndex map[string]int
 }
 
 func (h *OpenAIStreamHandler) OnStart(w http.ResponseWriter, flusher http.Flusher) {}
 
 func (h *OpenAIStreamHandler) OnChunk(w http.ResponseWriter, flusher http.Flusher, chunk *StreamChunk) error {
-	payload := buildOpenAIStreamChunk(h.ID, h.Model, chunk)
+	if h.ToolIndex == nil {
+		h.ToolIndex = make(map[string]int)
+	}
+	payload := buildOpenAIStreamChunk(h.ID, h.Model, chunk, h.ToolIndex)
 	data, err := json.Marshal(payload)
 	if err != nil {
 		return err
@@ -263,7 +305,7 @@ func (h *OpenAIStreamHandler) OnError(w http.ResponseWriter, flusher http.Flushe
 	flusher.Flush()
 }
 
-func buildOpenAIStreamChunk(id, model string, chunk *StreamChunk) *openAIStreamChunk {
+func buildOpenAIStreamChunk(id, model string, chunk *StreamChunk, toolIndex map[string]int) *openAIStreamChunk {
 	choice := openAIStreamChoice{
 		Index: 0,
 		Delta: openAIStreamDelta{},
@@ -272,20 +314,35 @@ func buildOpenAIStreamChunk(id, model string, chunk *StreamChunk) *openAIStreamC
 		choice.Delta.Content = chunk.TextDelta
 	}
 	for _, tc := range chunk.ToolCallDeltas {
-		choice.Delta.ToolCalls = append(choice.Delta.ToolCalls, openAIToolCallDelta{
-			ID:   tc.ID,
-			Type: tc.Type,
+		delta := openAIToolCallDelta{
 			Function: openAIFunctionCallDelta{
-				Name:      tc.Function,
 				Arguments: tc.ArgumentsDelta,
 			},
-		})
+		}
+		// Like OpenAI, only the first fragment of a tool call carries its ID, type and name;
+		// later fragments are joined by index.
+		idx, seen := toolIndex[tc.ID]
+		if !seen {
+			idx = len(toolIndex)
+			toolIndex[tc.ID] = idx
+			delta.ID = tc.ID
+			delta.Type = tc.Type
+			delta.Function.Name = tc.Function
+		}
+		delta.Index = idx
+		choice.Delta.ToolCalls = append(choice.Delta.ToolCalls, delta)
 	}
 
 	if chunk.Done {
-		if len(choice.Delta.ToolCalls) > 0 {
+		// Providers like Gemini may finish in a later chunk than the one carrying
+		// the tool calls, so consider every tool call seen on the stream.
+		// Normalized finish reasons use OpenAI's names.
+		switch {
+		case len(toolIndex) > 0:
 			choice.FinishReason = "tool_calls"
-		} else {
+		case chunk.FinishReason != "":
+			choice.FinishReason = string(chunk.FinishReason)
+		default:
 			choice.FinishReason = "stop"
 		}
 	}
@@ -299,6 +356,19 @@ func buildOpenAIStreamChunk(id, model string, chunk *StreamChunk) *openAIStreamC
 	}
 }
 
+// OpenAIErrorResponse is the body of an OpenAI API error.
+type OpenAIErrorResponse struct {
+	Error OpenAIError `json:"error"`
+}
+
+// OpenAIError describes an OpenAI API error. Param and Code are null when unset.
+type OpenAIError struct {
+	Message string  `json:"message"`
+	Type    string  `json:"type"`
+	Param   *string `json:"param"`
+	Code    *string `json:"code"`
+}
+
 // Minimal OpenAI streaming chunk structs
 type openAIStreamChunk struct {
 	ID      string               `json:"id"`
@@ -321,6 +391,7 @@ type openAIStreamDelta struct {
 }
 
 type openAIToolCallDelta struct {
+	Index    int                     `json:"index"`
 	ID       string                  `json:"id,omitempty"`
 	Type     string                  `json:"type,omitempty"`
 	Function openAIFunctionCallDelta `json:"function"`
@@ -342,17 +413,3 @@ func getCurrentTimestamp() int64 {
 	// Return current Unix timestamp
 	return time.Now().Unix()
 }
-
-func generateRandomID(length int) string {
-	// Generate a cryptographically secure random ID
-	b := make([]byte, (length+1)/2) // Each byte becomes 2 hex chars
-	if _, err := rand.Read(b); err != nil {
-		// Fallback to timestamp-based ID if random generation fails
-		return fmt.Sprintf("%d", time.Now().UnixNano())
-	}
-	id := hex.EncodeToString(b)
-	if len(id) > length {
-		return id[:length]
-	}
-	return id
-}
@@ -275,6 +275,60 @@ func TestConvertResponseToOpenAI(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "text and tool call response",
+			universalResp: &Response{
+				Text: "Let me check the weather for you.",
+				ToolCalls: []ToolCall{
+					{
+						ID:        "call_789",
+						Type:      "function",
+						Function:  "get_weather",
+						Arguments: `{"location":"Paris"}`,
+					},
+				},
+			},
+			model:          "gpt-4",
+			promptTokens:   15,
+			completionToks: 20,
+			wantErr:        false,
+			checkResult: func(t *testing.T, resp *openaiChatCompletionResponse) {
+				data, err := json.Marshal(resp)
+				if err != nil {
+					t.Fatalf("Failed to marshal response: %v", err)
+				}
+				var wire struct {
+					Choices []struct {
+						Message struct {
+							Content   string `json:"content"`
+							ToolCalls []struct {
+								ID       string `json:"id"`
+								Function struct {
+									Name string `json:"name"`
+								} `json:"function"`
+							} `json:"tool_calls"`
+						} `json:"message"`
+						FinishReason string `json:"finish_reason"`
+					} `json:"choices"`
+				}
+				if err := json.Unmarshal(data, &wire); err != nil {
+					t.Fatalf("Failed to unmarshal response: %v", err)
+				}
+				if len(wire.Choices) != 1 {
+					t.Fatalf("Expected 1 choice, got %d", len(wire.Choices))
+				}
+				choice := wire.Choices[0]
+				if choice.Message.Content != "Let me check the weather for you." {
+					t.Errorf("Expected content to be serialized alongside tool calls, got %q", choice.Message.Content)
+				}
+				if len(choice.Message.ToolCalls) != 1 || choice.Message.ToolCalls[0].ID != "call_789" || choice.Message.ToolCalls[0].Function.Name != "get_weather" {
+					t.Errorf("Expected tool call call_789 to be serialized, got %+v", choice.Message.ToolCalls)
+				}
+				if choice.FinishReason != "tool_calls" {
+					t.Errorf("Expected finish reason 'tool_calls', got '%s'", choice.FinishReason)
+				}
+			},
+		},
 		{
 			name:          "nil response",
 			universalResp: nil,
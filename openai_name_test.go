@@ -0,0 +1,55 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestOpenAIMessageName tests that a message name is read by the converter
+// and sent on by the OpenAI adapter
+func TestOpenAIMessageName(t *testing.T) {
+	var body struct {
+		Messages []map[string]any `json:"messages"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`)
+	}))
+	defer server.Close()
+
+	req, err := NewOpenAIFormatConverter().ConvertRequestToUniversal(&OpenAIChatCompletionRequest{
+		Model: "gpt-4o",
+		Messages: []openaiMessage{
+			{Role: "user", Name: "alice", Content: "Let's plan the trip."},
+			{Role: "assistant", Name: "planner", Content: "Where to?"},
+			{Role: "user", Content: "Paris."},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ConvertRequestToUniversal failed: %v", err)
+	}
+	if req.Messages[0].Name != "alice" || req.Messages[1].Name != "planner" {
+		t.Fatalf("expected names to be converted, got %+v", req.Messages)
+	}
+
+	client, err := NewClient(WithProvider(ProviderOpenAI), WithAPIKey("test-key"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	if _, err := client.Generate(context.Background(), req); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if len(body.Messages) != 3 {
+		t.Fatalf("expected 3 messages, got %v", body.Messages)
+	}
+	if body.Messages[0]["name"] != "alice" || body.Messages[1]["name"] != "planner" {
+		t.Errorf("expected names in the request body, got %v", body.Messages)
+	}
+	if _, ok := body.Messages[2]["name"]; ok {
+		t.Errorf("expected no name for an unnamed message, got %v", body.Messages[2])
+	}
+}
@@ -0,0 +1,61 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestOpenAIReasoningContent tests that DeepSeek-style reasoning_content is
+// returned as Response.Reasoning
+func TestOpenAIReasoningContent(t *testing.T) {
+	resp, err := (&openaiAdapter{}).parseResponse([]byte(`{"choices":[{"message":{"role":"assistant",
+		"reasoning_content":"9.11 has a smaller tenths digit.","content":"9.9 is larger."},"finish_reason":"stop"}]}`))
+	if err != nil {
+		t.Fatalf("parseResponse failed: %v", err)
+	}
+	if resp.Reasoning != "9.11 has a smaller tenths digit." || resp.Text != "9.9 is larger." {
+		t.Errorf("expected reasoning and text, got %q and %q", resp.Reasoning, resp.Text)
+	}
+
+	resp, err = (&openaiAdapter{}).parseResponse([]byte(`{"choices":[{"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}]}`))
+	if err != nil {
+		t.Fatalf("parseResponse failed: %v", err)
+	}
+	if resp.Reasoning != "" {
+		t.Errorf("expected no reasoning without reasoning_content, got %q", resp.Reasoning)
+	}
+}
+
+func TestOpenAIStreamingReasoningContent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"role\":\"assistant\",\"reasoning_content\":\"Compare \"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"reasoning_content\":\"the digits.\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"9.9\"},\"finish_reason\":\"stop\"}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithProvider(ProviderOpenAI), WithAPIKey("test-key"), WithBaseURL(server.URL), WithModel("deepseek-reasoner"))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	var reasoning string
+	var last *StreamChunk
+	for chunk, err := range StreamSeq(context.Background(), client, &Request{Messages: []Message{{Role: RoleUser, Content: "9.11 or 9.9?"}}}) {
+		if err != nil {
+			t.Fatalf("stream error: %v", err)
+		}
+		reasoning += chunk.ReasoningDelta
+		last = chunk
+	}
+	if reasoning != "Compare the digits." {
+		t.Errorf("expected reasoning deltas, got %q", reasoning)
+	}
+	if last == nil || last.Snapshot.Reasoning != "Compare the digits." || last.Snapshot.Text != "9.9" {
+		t.Errorf("expected reasoning and text in the final snapshot, got %+v", last)
+	}
+}
@@ -0,0 +1,78 @@
+package ai
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ParameterCompatibility selects how unsupported request parameters are handled.
+type ParameterCompatibility string
+
+const (
+	// ParameterCompatibilityDrop omits unsupported parameters from the request.
+	ParameterCompatibilityDrop ParameterCompatibility = "drop"
+	// ParameterCompatibilityStrict fails the request with an *UnsupportedParameterError.
+	ParameterCompatibilityStrict ParameterCompatibility = "strict"
+)
+
+// ErrUnsupportedParameter is matched (via errors.Is) by *UnsupportedParameterError.
+var ErrUnsupportedParameter = errors.New("unsupported parameter")
+
+// UnsupportedParameterError is returned under ParameterCompatibilityStrict when
+// a request sets a parameter the target provider or model does not accept.
+type UnsupportedParameterError struct {
+	Provider  Provider
+	Model     string
+	Parameter string // Request field name, e.g. "Temperature"
+}
+
+func (e *UnsupportedParameterError) Error() string {
+	return fmt.Sprintf("%s: %s is not supported by %s model %q", ErrUnsupportedParameter, e.Parameter, e.Provider, e.Model)
+}
+
+func (e *UnsupportedParameterError) Unwrap() error {
+	return ErrUnsupportedParameter
+}
+
+// paramPolicy applies the configured ParameterCompatibility in adapter payload builders.
+// The zero value drops unsupported parameters silently.
+type paramPolicy struct {
+	strict bool
+	onDrop func(provider Provider, model, param string)
+}
+
+func newParamPolicy(cfg *Config) paramPolicy {
+	return paramPolicy{
+		strict: cfg.paramCompatibility == ParameterCompatibilityStrict,
+		onDrop: cfg.onDroppedParameter,
+	}
+}
+
+// unsupported reports that param is set but unsupported. It returns an error in
+// strict mode; otherwise it notifies the drop handler and the caller omits param.
+func (p paramPolicy) unsupported(provider Provider, model, param string) error {
+	if p.strict {
+		return &UnsupportedParameterError{Provider: provider, Model: model, Parameter: param}
+	}
+	if p.onDrop != nil {
+		p.onDrop(provider, model, param)
+	}
+	return nil
+}
+
+// isOpenAIReasoningModel reports whether model is an OpenAI reasoning model
+// (o-series or GPT-5), which rejects sampling parameters like temperature.
+// A family matches alone or followed by a version or variant suffix, such as
+// "o4-mini" or "gpt-5.1"; the "-chat" variants of GPT-5 are not reasoning models.
+func isOpenAIReasoningModel(model string) bool {
+	if strings.Contains(model, "-chat") {
+		return false
+	}
+	for _, family := range []string{"o1", "o3", "o4", "gpt-5"} {
+		if rest, ok := strings.CutPrefix(model, family); ok && (rest == "" || rest[0] == '-' || rest[0] == '.') {
+			return true
+		}
+	}
+	return false
+}
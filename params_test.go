@@ -0,0 +1,220 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParameterCompatibility(t *testing.T) {
+	temp := 0.2
+	topK := 40
+	tests := []struct {
+		name    string
+		adapter func(p paramPolicy) providerAdapter
+		req     *Request
+		param   string // unsupported parameter, or "" if all are supported
+		key     string // JSON key that must be absent when dropped
+	}{
+		{"openai reasoning temperature", func(p paramPolicy) providerAdapter { return &openaiAdapter{params: p} },
+			&Request{Model: "o3-mini", Temperature: &temp}, "Temperature", `"temperature"`},
+		{"openai reasoning top_p", func(p paramPolicy) providerAdapter { return &openaiAdapter{params: p} },
+			&Request{Model: "o3-mini", TopP: &temp}, "TopP", `"top_p"`},
+		{"openai reasoning logit bias", func(p paramPolicy) providerAdapter { return &openaiAdapter{params: p} },
+			&Request{Model: "gpt-5-mini", LogitBias: map[string]int{"50256": -100}}, "LogitBias", `"logit_bias"`},
+		{"anthropic logit bias", func(p paramPolicy) providerAdapter { return &anthropicAdapter{params: p} },
+			&Request{LogitBias: map[string]int{"1": 5}}, "LogitBias", `"logit_bias"`},
+		{"gemini logit bias", func(p paramPolicy) providerAdapter { return &geminiAdapter{params: p} },
+			&Request{LogitBias: map[string]int{"1": 5}}, "LogitBias", `"logit_bias"`},
+		{"openai chat model reasoning effort", func(p paramPolicy) providerAdapter { return &openaiAdapter{params: p} },
+			&Request{Model: "gpt-4o", ReasoningEffort: "low"}, "ReasoningEffort", `"reasoning_effort"`},
+		{"anthropic reasoning effort", func(p paramPolicy) providerAdapter { return &anthropicAdapter{params: p} },
+			&Request{ReasoningEffort: "high"}, "ReasoningEffort", `"reasoning_effort"`},
+		{"gemini logprobs", func(p paramPolicy) providerAdapter { return &geminiAdapter{params: p} },
+			&Request{Logprobs: true}, "Logprobs", `"logprobs"`},
+		{"openai top_k", func(p paramPolicy) providerAdapter { return &openaiAdapter{params: p} },
+			&Request{Model: "gpt-4o", TopK: &topK}, "TopK", `"top_k"`},
+		{"mistral top_k", func(p paramPolicy) providerAdapter { return &mistralAdapter{openaiAdapter{params: p}} },
+			&Request{TopK: &topK}, "TopK", `"top_k"`},
+		{"anthropic thinking top_k", func(p paramPolicy) providerAdapter { return &anthropicAdapter{params: p} },
+			&Request{Thinking: &ThinkingConfig{BudgetTokens: 1024}, TopK: &topK}, "TopK", `"top_k"`},
+		{"openai chat model", func(p paramPolicy) providerAdapter { return &openaiAdapter{params: p} },
+			&Request{Model: "gpt-4o", Temperature: &temp, TopP: &temp, LogitBias: map[string]int{"1": 5}}, "", ""},
+		{"openai gpt-5 chat model", func(p paramPolicy) providerAdapter { return &openaiAdapter{params: p} },
+			&Request{Model: "gpt-5-chat-latest", Temperature: &temp, TopP: &temp, LogitBias: map[string]int{"1": 5}}, "", ""},
+	}
+
+	for _, tt := range tests {
+		tt.req.Messages = []Message{{Role: RoleUser, Content: "hi"}}
+		t.Run(tt.name+"/strict", func(t *testing.T) {
+			_, err := tt.adapter(paramPolicy{strict: true}).buildRequestPayload(context.Background(), tt.req)
+			if tt.param == "" {
+				if err != nil {
+					t.Fatalf("expected supported parameters to pass, got %v", err)
+				}
+				return
+			}
+			var paramErr *UnsupportedParameterError
+			if !errors.As(err, &paramErr) || !errors.Is(err, ErrUnsupportedParameter) {
+				t.Fatalf("expected UnsupportedParameterError, got %v", err)
+			}
+			if paramErr.Parameter != tt.param {
+				t.Errorf("expected parameter %q, got %q", tt.param, paramErr.Parameter)
+			}
+		})
+		t.Run(tt.name+"/drop", func(t *testing.T) {
+			var dropped []string
+			policy := paramPolicy{onDrop: func(provider Provider, model, param string) { dropped = append(dropped, param) }}
+			payload, err := tt.adapter(policy).buildRequestPayload(context.Background(), tt.req)
+			if err != nil {
+				t.Fatalf("expected drop mode to succeed, got %v", err)
+			}
+			body, _ := json.Marshal(payload)
+			if tt.param == "" {
+				if len(dropped) != 0 {
+					t.Errorf("expected nothing dropped, got %v", dropped)
+				}
+				return
+			}
+			if len(dropped) != 1 || dropped[0] != tt.param {
+				t.Errorf("expected %q to be reported as dropped, got %v", tt.param, dropped)
+			}
+			if strings.Contains(string(body), tt.key) {
+				t.Errorf("expected %s to be omitted, got %s", tt.key, body)
+			}
+		})
+	}
+}
+
+func TestTemperatureSerialized(t *testing.T) {
+	temp := 0.7
+	tests := []struct {
+		name    string
+		adapter providerAdapter
+		model   string
+	}{
+		{"openai", &openaiAdapter{}, "gpt-4o"},
+		{"openai gpt-5 chat", &openaiAdapter{}, "gpt-5-chat-latest"},
+		{"anthropic", &anthropicAdapter{}, ""},
+		{"gemini", &geminiAdapter{}, ""},
+	}
+
+	for _, tt := range tests {
+		req := &Request{Model: tt.model, Messages: []Message{{Role: RoleUser, Content: "hi"}}, Temperature: &temp}
+		payload, err := tt.adapter.buildRequestPayload(context.Background(), req)
+		if err != nil {
+			t.Fatalf("%s: buildRequestPayload failed: %v", tt.name, err)
+		}
+		body, _ := json.Marshal(payload)
+		if !strings.Contains(string(body), `"temperature":0.7`) {
+			t.Errorf("%s: expected temperature in payload, got %s", tt.name, body)
+		}
+	}
+}
+
+func TestTopKSerialized(t *testing.T) {
+	topK := 40
+	tests := []struct {
+		name    string
+		adapter providerAdapter
+		want    string
+	}{
+		{"anthropic", &anthropicAdapter{}, `"top_k":40`},
+		{"gemini", &geminiAdapter{}, `"topK":40`},
+	}
+
+	for _, tt := range tests {
+		req := &Request{Messages: []Message{{Role: RoleUser, Content: "hi"}}, TopK: &topK}
+		payload, err := tt.adapter.buildRequestPayload(context.Background(), req)
+		if err != nil {
+			t.Fatalf("%s: buildRequestPayload failed: %v", tt.name, err)
+		}
+		body, _ := json.Marshal(payload)
+		if !strings.Contains(string(body), tt.want) {
+			t.Errorf("%s: expected %s in payload, got %s", tt.name, tt.want, body)
+		}
+	}
+
+	negative := -1
+	req := &Request{Messages: []Message{{Role: RoleUser, Content: "hi"}}, TopK: &negative}
+	if err := req.Validate(); err == nil || !strings.Contains(err.Error(), "top_k") {
+		t.Errorf("expected a negative top_k to be rejected, got %v", err)
+	}
+}
+
+func TestIsOpenAIReasoningModel(t *testing.T) {
+	for model, want := range map[string]bool{
+		"o1":                 true,
+		"o3-mini":            true,
+		"o4-mini-2025-04-16": true,
+		"gpt-5":              true,
+		"gpt-5-mini":         true,
+		"gpt-5.1":            true,
+		"gpt-5-chat-latest":  false,
+		"gpt-5.1-chat":       false,
+		"gpt-4o":             false,
+		"o1x":                false,
+		"gpt-50":             false,
+	} {
+		if got := isOpenAIReasoningModel(model); got != want {
+			t.Errorf("isOpenAIReasoningModel(%q) = %v, want %v", model, got, want)
+		}
+	}
+}
+
+func TestOpenAIReasoningEffort(t *testing.T) {
+	temp := 0.7
+	req := &Request{
+		Model:           "o3",
+		Messages:        []Message{{Role: RoleUser, Content: "hi"}},
+		Temperature:     &temp,
+		ReasoningEffort: "high",
+	}
+	payload, err := (&openaiAdapter{}).buildRequestPayload(context.Background(), req)
+	if err != nil {
+		t.Fatalf("buildRequestPayload failed: %v", err)
+	}
+	body, _ := json.Marshal(payload)
+	if !strings.Contains(string(body), `"reasoning_effort":"high"`) {
+		t.Errorf("expected reasoning_effort in payload, got %s", body)
+	}
+	if strings.Contains(string(body), `"temperature"`) {
+		t.Errorf("expected temperature to be omitted for a reasoning model, got %s", body)
+	}
+
+	req.ReasoningEffort = "extreme"
+	if err := req.Validate(); err == nil {
+		t.Error("expected an unknown reasoning effort to fail validation")
+	}
+}
+
+func TestOpenAIParallelToolCalls(t *testing.T) {
+	parallel := false
+	req := &Request{
+		Model:             "gpt-4o",
+		Messages:          []Message{{Role: RoleUser, Content: "hi"}},
+		Tools:             []Tool{{Type: "function", Function: FunctionDefinition{Name: "get_weather", Parameters: json.RawMessage(`{"type":"object"}`)}}},
+		ParallelToolCalls: &parallel,
+	}
+	payload, err := (&openaiAdapter{}).buildRequestPayload(context.Background(), req)
+	if err != nil {
+		t.Fatalf("buildRequestPayload failed: %v", err)
+	}
+	body, _ := json.Marshal(payload)
+	if !strings.Contains(string(body), `"parallel_tool_calls":false`) {
+		t.Errorf("expected parallel_tool_calls in payload, got %s", body)
+	}
+
+	// OpenAI rejects the field without tools, so it is dropped.
+	req.Tools = nil
+	payload, err = (&openaiAdapter{}).buildRequestPayload(context.Background(), req)
+	if err != nil {
+		t.Fatalf("buildRequestPayload failed: %v", err)
+	}
+	body, _ = json.Marshal(payload)
+	if strings.Contains(string(body), `"parallel_tool_calls"`) {
+		t.Errorf("expected parallel_tool_calls to be omitted without tools, got %s", body)
+	}
+}
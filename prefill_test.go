@@ -0,0 +1,96 @@
+package ai
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAnthropicAdapter_AssistantPrefill(t *testing.T) {
+	req := &Request{
+		Messages: []Message{
+			{Role: RoleUser, Content: "List three colors as JSON."},
+			{Role: RoleAssistant, Content: "{\"colors\": [ \n"},
+		},
+	}
+
+	payload, err := (&anthropicAdapter{}).buildRequestPayload(context.Background(), req)
+	if err != nil {
+		t.Fatalf("buildRequestPayload returned error: %v", err)
+	}
+	msgs := payload.(*anthropicMessagesRequest).Messages
+	if len(msgs) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(msgs))
+	}
+	last := msgs[len(msgs)-1]
+	if last.Role != "assistant" {
+		t.Fatalf("expected last message role assistant, got %q", last.Role)
+	}
+	if len(last.Content) != 1 || last.Content[0].Type != "text" {
+		t.Fatalf("expected a single text block, got %+v", last.Content)
+	}
+	if last.Content[0].Text != `{"colors": [` {
+		t.Fatalf("expected prefill with trailing whitespace trimmed, got %q", last.Content[0].Text)
+	}
+}
+
+func TestOpenAIAdapter_AssistantPrefillEmulation(t *testing.T) {
+	req := &Request{
+		Messages: []Message{
+			{Role: RoleUser, Content: "List three colors as JSON."},
+			{Role: RoleAssistant, Content: `{"colors": [`},
+		},
+	}
+
+	payload, err := (&openaiAdapter{}).buildRequestPayload(context.Background(), req)
+	if err != nil {
+		t.Fatalf("buildRequestPayload returned error: %v", err)
+	}
+	msgs := payload.(*OpenAIChatCompletionRequest).Messages
+	if len(msgs) != 3 {
+		t.Fatalf("expected 3 messages, got %d", len(msgs))
+	}
+	if msgs[1].Role != "assistant" || msgs[1].Content != `{"colors": [` {
+		t.Fatalf("expected prefill to be kept as assistant message, got %+v", msgs[1])
+	}
+	if msgs[2].Role != "system" || msgs[2].Content != prefillInstruction {
+		t.Fatalf("expected continuation instruction, got %+v", msgs[2])
+	}
+
+	// Without a trailing assistant message, nothing is appended.
+	req.Messages = req.Messages[:1]
+	payload, _ = (&openaiAdapter{}).buildRequestPayload(context.Background(), req)
+	if n := len(payload.(*OpenAIChatCompletionRequest).Messages); n != 1 {
+		t.Fatalf("expected 1 message without prefill, got %d", n)
+	}
+}
+
+func TestGeminiAdapter_AssistantPrefillEmulation(t *testing.T) {
+	req := &Request{
+		Messages: []Message{
+			{Role: RoleUser, Content: "List three colors as JSON."},
+			{Role: RoleAssistant, Content: `{"colors": [`},
+		},
+	}
+
+	payload, err := (&geminiAdapter{}).buildRequestPayload(context.Background(), req)
+	if err != nil {
+		t.Fatalf("buildRequestPayload returned error: %v", err)
+	}
+	contents := payload.(*geminiGenerateContentRequest).Contents
+	if len(contents) != 3 {
+		t.Fatalf("expected 3 contents, got %d", len(contents))
+	}
+	if prefill := contents[1]; prefill.Role != "model" || *prefill.Parts[0].Text != `{"colors": [` {
+		t.Fatalf("expected prefill to be kept as a model turn, got %+v", prefill)
+	}
+	if last := contents[2]; last.Role != "user" || len(last.Parts) != 1 || *last.Parts[0].Text != prefillInstruction {
+		t.Fatalf("expected continuation instruction, got %+v", last)
+	}
+
+	// Without a trailing assistant message, nothing is appended.
+	req.Messages = req.Messages[:1]
+	payload, _ = (&geminiAdapter{}).buildRequestPayload(context.Background(), req)
+	if n := len(payload.(*geminiGenerateContentRequest).Contents); n != 1 {
+		t.Fatalf("expected 1 content without prefill, got %d", n)
+	}
+}
@@ -1,10 +1,16 @@
 package ai
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"strings"
+	"sync/atomic"
+	"time"
 )
 
 // providerAdapter defines the interface for provider-specific logic,
@@ -39,6 +45,16 @@ type streamingAdapter interface {
 	newStreamDecoder(r io.Reader) streamDecoder
 }
 
+// Every built-in provider streams; a new adapter that does not fails its
+// Stream calls with a *StreamingUnsupportedError.
+var (
+	_ streamingAdapter = (*openaiAdapter)(nil)
+	_ streamingAdapter = (*geminiAdapter)(nil)
+	_ streamingAdapter = (*anthropicAdapter)(nil)
+	_ streamingAdapter = (*mistralAdapter)(nil)
+	_ StreamingClient  = (*genericClient)(nil)
+)
+
 // streamDecoder abstracts different streaming formats (SSE, JSON array, etc.)
 type streamDecoder interface {
 	Next() (*sseEvent, error)
@@ -49,21 +65,244 @@ type streamDecoder interface {
 type genericClient struct {
 	b       *baseClient
 	adapter providerAdapter
+	// flight coalesces identical concurrent requests when enabled via WithRequestCoalescing.
+	flight *flightGroup
+	// strict enables the extra request checks of WithStrictValidation.
+	strict bool
+	// autoContinue is the maximum number of continuation calls; see WithAutoContinue.
+	autoContinue int
+	// repairToolArgs enables WithToolArgumentRepair.
+	repairToolArgs bool
+	// rawResponse enables WithRawResponse.
+	rawResponse bool
+	// imageMaxDimension bounds the size of images sent; see WithImageMaxDimension.
+	imageMaxDimension int
+	// defaults fill unset request fields; see WithDefaults. Its Model comes
+	// from WithModel when that is set.
+	defaults Defaults
+	// limits supplies MaxTokens for known models when no default sets it, and
+	// their context window when WithContextWindow does not.
+	limits *modelLimits
+	// streamIdleTimeout bounds the wait for each stream event; see WithStreamIdleTimeout.
+	streamIdleTimeout time.Duration
+	// tokenizer and contextWindow drive the local pre-check of WithContextWindow.
+	tokenizer     Tokenizer
+	contextWindow int
+	// middleware post-processes responses; see WithResponseMiddleware.
+	middleware responseMiddleware
+}
+
+// newGenericClient wires a provider's base client and adapter together,
+// applying the client-level options from cfg.
+func newGenericClient(cfg *Config, b *baseClient, adapter providerAdapter) *genericClient {
+	if cfg.transport != nil {
+		b.httpClient.Transport = cfg.transport
+	}
+	b.applyConfig(cfg)
+	if cfg.recorder != nil {
+		b.httpClient.Transport = newRecordingTransport(b.httpClient.Transport, cfg.recorder)
+	}
+	c := &genericClient{
+		b:       b,
+		adapter: adapter,
+		strict:  cfg.strictValidation,
+
+		autoContinue:   cfg.autoContinueRounds,
+		repairToolArgs: cfg.repairToolArguments,
+		rawResponse:    cfg.rawResponse,
+
+		imageMaxDimension: cfg.imageMaxDimension,
+
+		tokenizer:     cfg.tokenizer,
+		contextWindow: cfg.contextWindow,
+	}
+	c.defaults = cfg.defaults
+	c.streamIdleTimeout = cfg.streamIdleTimeout
+	c.middleware = cfg.responseMiddleware
+	c.limits = &modelLimits{}
+	c.limits.record(cfg.modelInfo)
+	if cfg.model != "" {
+		c.defaults.Model = cfg.model
+	}
+	if cfg.defaultSystemPrompt != "" {
+		c.defaults.SystemPrompt = cfg.defaultSystemPrompt
+	}
+	if cfg.coalesceRequests {
+		c.flight = newFlightGroup()
+	}
+	return c
+}
+
+// validate runs the request checks, including the strict ones when enabled.
+func (c *genericClient) validate(req *Request) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+	if c.strict {
+		return req.ValidateToolResults()
+	}
+	return nil
+}
+
+// checkWindow runs the context window pre-check on a request with the
+// client's defaults applied. Without WithContextWindow, the model's known
+// ContextWindow is used once a tokenizer is set.
+func (c *genericClient) checkWindow(req *Request) error {
+	window := c.contextWindow
+	if window == 0 && c.tokenizer != nil {
+		window = c.limits.contextWindow(c.adapter.getModel(req))
+	}
+	return checkContextWindow(c.tokenizer, window, req)
+}
+
+// withDefaults returns req, or a shallow copy of it with the client's defaults
+// filling the fields req leaves unset. MaxTokens falls back to the model's
+// output limit when known. A leading RoleSystem message counts as a system
+// prompt. The caller's request is never modified.
+func (c *genericClient) withDefaults(req *Request) *Request {
+	d := c.defaults
+	model := req.Model
+	if model == "" {
+		model = d.Model
+	}
+	if req.MaxTokens == 0 && d.MaxTokens == 0 {
+		d.MaxTokens = c.limits.outputLimit(c.adapter.getModel(&Request{Model: model}))
+	}
+	if len(req.Messages) > 0 && req.Messages[0].Role == RoleSystem {
+		d.SystemPrompt = ""
+	}
+	if (req.Model != "" || d.Model == "") &&
+		(req.SystemPrompt != "" || d.SystemPrompt == "") &&
+		(req.Temperature != nil || d.Temperature == nil) &&
+		(req.MaxTokens != 0 || d.MaxTokens == 0) {
+		return req
+	}
+	r := *req
+	if r.Model == "" {
+		r.Model = d.Model
+	}
+	if r.SystemPrompt == "" {
+		r.SystemPrompt = d.SystemPrompt
+	}
+	if r.Temperature == nil {
+		r.Temperature = d.Temperature
+	}
+	if r.MaxTokens == 0 {
+		r.MaxTokens = d.MaxTokens
+	}
+	return &r
 }
 
 // Generate implements the core logic for the Client interface.
 func (c *genericClient) Generate(ctx context.Context, req *Request) (*Response, error) {
 	// 0. Validate the request before processing
-	if err := req.Validate(); err != nil {
+	if c.repairToolArgs {
+		req = repairRequestToolArguments(req)
+	}
+	if err := c.validate(req); err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+	req = c.withDefaults(req)
+	if err := c.checkWindow(req); err != nil {
 		return nil, fmt.Errorf("invalid request: %w", err)
 	}
+	req, err := resolveImageReaders(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	if req, err = resizeImages(req, c.imageMaxDimension); err != nil {
+		return nil, fmt.Errorf("failed to resize image: %w", err)
+	}
+
+	if c.flight == nil {
+		return c.generate(ctx, req)
+	}
+	key, err := HashRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	return c.flight.do(ctx, key, func(ctx context.Context) (*Response, error) {
+		return c.generate(ctx, req)
+	})
+}
+
+// autoContinuePrompt is the user turn sent to resume a length-truncated reply.
+const autoContinuePrompt = "Continue exactly where you stopped. Do not repeat anything you already wrote."
+
+// Close closes the client's idle connections. It is safe to call more than
+// once, and the client remains usable: later calls open new connections.
+func (c *genericClient) Close() error {
+	c.b.httpClient.CloseIdleConnections()
+	return nil
+}
+
+// generate performs the provider call for an already validated request,
+// continuing length-truncated replies when WithAutoContinue is enabled.
+func (c *genericClient) generate(ctx context.Context, req *Request) (*Response, error) {
+	resp, err := c.generateOnce(ctx, req)
+	if err != nil {
+		return nil, err
+	}
 
+	result := resp
+	var next *Request
+	for round := 0; round < c.autoContinue; round++ {
+		if resp.FinishReason != FinishReasonLength || len(resp.ToolCalls) > 0 || resp.Text == "" {
+			break
+		}
+		// Clone before appending so the caller's Messages backing array is never written.
+		if next == nil {
+			next = req.Clone()
+		}
+		next.Messages = append(next.Messages,
+			Message{Role: RoleAssistant, Content: resp.Text},
+			Message{Role: RoleUser, Content: autoContinuePrompt},
+		)
+		if resp, err = c.generateOnce(ctx, next); err != nil {
+			return nil, err
+		}
+		result = result.Merge(resp)
+	}
+
+	// Checked after auto-continue so only the final outcome is reported.
+	switch result.FinishReason {
+	case FinishReasonLength:
+		result.Warnings = append(result.Warnings, "response truncated by max_tokens")
+	case FinishReasonContentFilter:
+		result.Warnings = append(result.Warnings, "response stopped by the provider's content filter")
+	}
+	if err := c.middleware.apply(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// responseMiddleware is the chain of WithResponseMiddleware functions.
+type responseMiddleware []func(*Response) error
+
+// apply runs each middleware on resp in order, stopping at the first error.
+func (m responseMiddleware) apply(resp *Response) error {
+	for _, fn := range m {
+		if err := fn(resp); err != nil {
+			return fmt.Errorf("response middleware: %w", err)
+		}
+	}
+	return nil
+}
+
+// generateOnce performs a single provider call.
+func (c *genericClient) generateOnce(ctx context.Context, req *Request) (*Response, error) {
 	// 1. Build the provider-specific request payload using the adapter.
 	payload, err := c.adapter.buildRequestPayload(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build request payload: %w", err)
 	}
 
+	payload, err = mergeProviderExtra(payload, req.ProviderExtra[c.b.provider])
+	if err != nil {
+		return nil, err
+	}
+
 	// 2. Get model and endpoint from the adapter.
 	model := c.adapter.getModel(req)
 	endpoint := c.adapter.getEndpoint(model)
@@ -75,18 +314,93 @@ func (c *genericClient) Generate(ctx context.Context, req *Request) (*Response,
 	}
 
 	// 4. Convert the provider-specific response to the universal response using the adapter.
-	return c.adapter.parseResponse(respBytes)
+	resp, err := c.adapter.parseResponse(respBytes)
+	if err != nil {
+		return nil, err
+	}
+	if c.rawResponse {
+		resp.Raw = respBytes
+	}
+	if c.repairToolArgs {
+		for _, name := range repairToolCallArguments(resp.ToolCalls) {
+			resp.Warnings = append(resp.Warnings, fmt.Sprintf("repaired malformed JSON arguments of tool call %q", name))
+		}
+	}
+	return resp, nil
+}
+
+// mergeProviderExtra returns payload with the fields of the JSON object extra
+// added at the top level, replacing fields of the same name. Without extra
+// fields the payload is returned unchanged.
+func mergeProviderExtra(payload any, extra json.RawMessage) (any, error) {
+	if len(extra) == 0 {
+		return payload, nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(extra, &fields); err != nil {
+		return nil, fmt.Errorf("invalid provider extra fields: %w", err)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request payload: %w", err)
+	}
+	var merged map[string]json.RawMessage
+	if err := json.Unmarshal(data, &merged); err != nil {
+		return nil, fmt.Errorf("failed to merge provider extra fields: %w", err)
+	}
+	maps.Copy(merged, fields)
+	return merged, nil
+}
+
+// decodeFirstJSON decodes the first complete JSON value in data into v and
+// ignores anything after it. Some proxies append extra bytes to the body,
+// which json.Unmarshal would reject. Numbers in untyped fields, such as tool
+// call arguments, are kept as json.Number so they re-marshal exactly.
+func decodeFirstJSON(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// decodeArguments decodes a JSON object of tool call arguments. Unlike
+// json.Unmarshal into a map, it keeps numbers as json.Number, so large
+// integers and decimals are sent on exactly as written.
+func decodeArguments(data string) (map[string]any, error) {
+	dec := json.NewDecoder(strings.NewReader(data))
+	dec.UseNumber()
+	var args map[string]any
+	if err := dec.Decode(&args); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("invalid character after top-level value")
+	}
+	return args, nil
 }
 
 // Stream implements the streaming generation flow when supported by the adapter.
 func (c *genericClient) Stream(ctx context.Context, req *Request) (StreamReader, error) {
-	if err := req.Validate(); err != nil {
+	if c.repairToolArgs {
+		req = repairRequestToolArguments(req)
+	}
+	if err := c.validate(req); err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+	req = c.withDefaults(req)
+	if err := c.checkWindow(req); err != nil {
 		return nil, fmt.Errorf("invalid request: %w", err)
 	}
+	req, err := resolveImageReaders(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	if req, err = resizeImages(req, c.imageMaxDimension); err != nil {
+		return nil, fmt.Errorf("failed to resize image: %w", err)
+	}
 
 	streaming, ok := c.adapter.(streamingAdapter)
 	if !ok {
-		return nil, fmt.Errorf("streaming not supported by provider")
+		return nil, &StreamingUnsupportedError{Provider: c.b.provider}
 	}
 
 	// Build provider payload
@@ -95,6 +409,10 @@ func (c *genericClient) Stream(ctx context.Context, req *Request) (StreamReader,
 		return nil, fmt.Errorf("failed to build request payload: %w", err)
 	}
 	streaming.enableStreaming(payload)
+	payload, err = mergeProviderExtra(payload, req.ProviderExtra[c.b.provider])
+	if err != nil {
+		return nil, err
+	}
 
 	// Determine endpoint
 	model := c.adapter.getModel(req)
@@ -109,11 +427,18 @@ func (c *genericClient) Stream(ctx context.Context, req *Request) (StreamReader,
 	// Let the adapter choose the appropriate decoder for its streaming format
 	decoder := streaming.newStreamDecoder(body)
 	reader := &genericStreamReader{
-		body:    body,
-		decoder: decoder,
-		adapter: streaming,
-		acc:     newStreamAccumulator(),
+		ctx:         ctx,
+		body:        body,
+		decoder:     decoder,
+		adapter:     streaming,
+		acc:         newStreamAccumulator(),
+		middleware:  c.middleware,
+		provider:    c.b.provider,
+		timeout:     c.b.streamTimeout,
+		idleTimeout: c.streamIdleTimeout,
 	}
+	// Closing the body on cancellation unblocks a Recv waiting on a slow provider.
+	reader.stop = context.AfterFunc(ctx, func() { _ = body.Close() })
 	return reader, nil
 }
 
@@ -122,6 +447,8 @@ type streamAccumulator struct {
 	response  Response
 	toolCalls map[string]*toolCallAccumulator
 	order     []string
+	// toolIndex maps a tool call's stream index to its ID for fragments that omit the ID.
+	toolIndex map[int]string
 	// anthropicBlocks tracks block metadata by index for streaming tool/text assembly.
 	anthropicBlocks map[int]*anthropicBlockState
 }
@@ -133,7 +460,7 @@ type toolCallAccumulator struct {
 }
 
 type anthropicBlockState struct {
-	kind     string // "text" or "tool"
+	kind     string // "text", "thinking" or "tool"
 	toolID   string
 	toolName string
 }
@@ -141,6 +468,7 @@ type anthropicBlockState struct {
 func newStreamAccumulator() *streamAccumulator {
 	return &streamAccumulator{
 		toolCalls:       make(map[string]*toolCallAccumulator),
+		toolIndex:       make(map[int]string),
 		anthropicBlocks: make(map[int]*anthropicBlockState),
 	}
 }
@@ -149,18 +477,30 @@ func (a *streamAccumulator) applyChunk(chunk *StreamChunk) {
 	if chunk.TextDelta != "" {
 		a.response.Text += chunk.TextDelta
 	}
+	a.response.Reasoning += chunk.ReasoningDelta
+	if chunk.FinishReason != "" {
+		a.response.FinishReason = chunk.FinishReason
+	}
+	a.response.Logprobs = append(a.response.Logprobs, chunk.Logprobs...)
+	a.response.Citations = appendCitations(a.response.Citations, chunk.Citations...)
 
 	for _, delta := range chunk.ToolCallDeltas {
-		tc := a.toolCalls[delta.ID]
+		id := delta.ID
+		if id == "" {
+			id = a.toolIndex[delta.Index]
+		} else {
+			a.toolIndex[delta.Index] = id
+		}
+		tc := a.toolCalls[id]
 		if tc == nil {
 			tc = &toolCallAccumulator{
 				call: ToolCall{
-					ID:   delta.ID,
+					ID:   id,
 					Type: delta.Type,
 				},
 			}
-			a.toolCalls[delta.ID] = tc
-			a.order = append(a.order, delta.ID)
+			a.toolCalls[id] = tc
+			a.order = append(a.order, id)
 		}
 		if delta.Function != "" {
 			tc.call.Function = delta.Function
@@ -193,28 +533,76 @@ func (a *streamAccumulator) snapshot() *Response {
 	if len(a.response.ToolCalls) > 0 {
 		s.ToolCalls = append([]ToolCall(nil), a.response.ToolCalls...)
 	}
+	if len(a.response.Citations) > 0 {
+		s.Citations = append([]Citation(nil), a.response.Citations...)
+	}
+	if len(a.response.Logprobs) > 0 {
+		s.Logprobs = append([]TokenLogprob(nil), a.response.Logprobs...)
+	}
 	return &s
 }
 
 // genericStreamReader implements StreamReader over SSE events.
 type genericStreamReader struct {
+	ctx     context.Context
+	stop    func() bool // Unregisters the close-on-cancel hook
 	body    io.Closer
 	decoder streamDecoder
 	adapter streamingAdapter
 	acc     *streamAccumulator
-	closed  bool
+	// closed is set by Close, which may be called from another goroutine to
+	// abort a pending Recv.
+	closed atomic.Bool
+	// middleware runs on the final snapshot; see WithResponseMiddleware.
+	middleware responseMiddleware
+
+	// provider and timeout (the stream timeout) describe timeout errors.
+	provider string
+	timeout  time.Duration
+	// idleTimeout, if set, closes the body when no event arrives in time;
+	// idled records that it fired.
+	idleTimeout time.Duration
+	idled       atomic.Bool
 }
 
 func (r *genericStreamReader) Recv() (*StreamChunk, error) {
-	if r.closed {
+	if r.closed.Load() {
 		return nil, io.EOF
 	}
+	if err := r.ctx.Err(); err != nil {
+		_ = r.Close()
+		return nil, r.contextError(err)
+	}
+	var idle *time.Timer
+	if r.idleTimeout > 0 {
+		idle = time.AfterFunc(r.idleTimeout, func() {
+			r.idled.Store(true)
+			_ = r.body.Close()
+		})
+		defer idle.Stop()
+	}
 	for {
 		event, err := r.decoder.Next()
+		if err == nil && idle != nil {
+			idle.Reset(r.idleTimeout)
+		}
 		if err != nil {
+			// A read failing because the body was closed on cancellation
+			// or idleness is reported as that cause.
+			if ctxErr := r.ctx.Err(); ctxErr != nil {
+				_ = r.Close()
+				return nil, r.contextError(ctxErr)
+			}
+			if r.idled.Load() {
+				_ = r.Close()
+				return nil, newTimeoutError(r.provider, TimeoutKindIdle, r.idleTimeout, err)
+			}
 			if err == io.EOF {
 				_ = r.Close()
 			}
+			if errors.Is(err, context.DeadlineExceeded) {
+				return nil, NewTimeoutError(r.provider, r.timeout, err)
+			}
 			return nil, err
 		}
 		chunk, done, err := r.adapter.parseStreamEvent(event, r.acc)
@@ -227,6 +615,9 @@ func (r *genericStreamReader) Recv() (*StreamChunk, error) {
 			chunk.Snapshot = r.acc.snapshot()
 			if chunk.Done {
 				_ = r.Close()
+				if err := r.middleware.apply(chunk.Snapshot); err != nil {
+					return nil, err
+				}
 			}
 			return chunk, nil
 		}
@@ -238,10 +629,21 @@ func (r *genericStreamReader) Recv() (*StreamChunk, error) {
 	}
 }
 
+// contextError reports a passed context deadline as a deadline *TimeoutError,
+// which still matches context.DeadlineExceeded; cancellation is returned as is.
+func (r *genericStreamReader) contextError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return NewTimeoutError(r.provider, 0, err)
+	}
+	return err
+}
+
 func (r *genericStreamReader) Close() error {
-	if r.closed {
+	if r.closed.Swap(true) {
 		return nil
 	}
-	r.closed = true
+	if r.stop != nil {
+		r.stop()
+	}
 	return r.body.Close()
 }
@@ -0,0 +1,713 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseResponseIgnoresTrailingData(t *testing.T) {
+	const trailer = "\n\n<!-- served by proxy -->garbage{"
+	tests := []struct {
+		name    string
+		adapter providerAdapter
+		body    string
+	}{
+		{"openai", &openaiAdapter{}, `{"choices":[{"message":{"role":"assistant","content":"hello"},"finish_reason":"stop"}]}`},
+		{"gemini", &geminiAdapter{}, `{"candidates":[{"content":{"role":"model","parts":[{"text":"hello"}]},"finishReason":"STOP"}]}`},
+		{"anthropic", &anthropicAdapter{}, `{"content":[{"type":"text","text":"hello"}],"stop_reason":"end_turn"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, body := range []string{tt.body + trailer, tt.body + tt.body} {
+				resp, err := tt.adapter.parseResponse([]byte(body))
+				if err != nil {
+					t.Fatalf("parseResponse failed: %v", err)
+				}
+				if resp.Text != "hello" {
+					t.Errorf("expected text %q, got %q", "hello", resp.Text)
+				}
+			}
+		})
+	}
+}
+
+func TestParseResponseRejectsInvalidJSON(t *testing.T) {
+	for _, body := range []string{"", "garbage", `{"choices":[`} {
+		if _, err := (&openaiAdapter{}).parseResponse([]byte(body)); err == nil {
+			t.Errorf("expected error for body %q", body)
+		}
+	}
+}
+
+func TestParseResponseFinishReason(t *testing.T) {
+	tests := []struct {
+		name    string
+		adapter providerAdapter
+		body    string
+		want    FinishReason
+	}{
+		{"openai stop", &openaiAdapter{}, `{"choices":[{"message":{"content":"hi"},"finish_reason":"stop"}]}`, FinishReasonStop},
+		{"openai length", &openaiAdapter{}, `{"choices":[{"message":{"content":"hi"},"finish_reason":"length"}]}`, FinishReasonLength},
+		{"anthropic max_tokens", &anthropicAdapter{}, `{"content":[{"type":"text","text":"hi"}],"stop_reason":"max_tokens"}`, FinishReasonLength},
+		{"anthropic tool_use", &anthropicAdapter{}, `{"content":[{"type":"tool_use","id":"t1","name":"f","input":{}}],"stop_reason":"tool_use"}`, FinishReasonToolCalls},
+		{"gemini max tokens", &geminiAdapter{}, `{"candidates":[{"content":{"parts":[{"text":"hi"}]},"finishReason":"MAX_TOKENS"}]}`, FinishReasonLength},
+		{"gemini safety", &geminiAdapter{}, `{"candidates":[{"content":{"parts":[]},"finishReason":"SAFETY"}]}`, FinishReasonContentFilter},
+		{"gemini function call", &geminiAdapter{}, `{"candidates":[{"content":{"parts":[{"functionCall":{"name":"f","args":{}}}]},"finishReason":"STOP"}]}`, FinishReasonToolCalls},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := tt.adapter.parseResponse([]byte(tt.body))
+			if err != nil {
+				t.Fatalf("parseResponse failed: %v", err)
+			}
+			if resp.FinishReason != tt.want {
+				t.Errorf("expected finish reason %q, got %q", tt.want, resp.FinishReason)
+			}
+		})
+	}
+}
+
+func TestParseResponseUsage(t *testing.T) {
+	tests := []struct {
+		name    string
+		adapter providerAdapter
+		body    string
+	}{
+		{"openai", &openaiAdapter{}, `{"choices":[{"message":{"content":"hi"}}],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`},
+		{"anthropic", &anthropicAdapter{}, `{"content":[{"type":"text","text":"hi"}],"usage":{"input_tokens":10,"output_tokens":5}}`},
+		{"gemini", &geminiAdapter{}, `{"candidates":[{"content":{"parts":[{"text":"hi"}]}}],"usageMetadata":{"promptTokenCount":10,"candidatesTokenCount":5,"totalTokenCount":15}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := tt.adapter.parseResponse([]byte(tt.body))
+			if err != nil {
+				t.Fatalf("parseResponse failed: %v", err)
+			}
+			want := Usage{InputTokens: 10, OutputTokens: 5, TotalTokens: 15}
+			if resp.Usage == nil || *resp.Usage != want {
+				t.Errorf("expected usage %+v, got %+v", want, resp.Usage)
+			}
+		})
+	}
+
+	t.Run("openai reasoning tokens", func(t *testing.T) {
+		body := `{"choices":[{"message":{"content":"hi"}}],"usage":{"prompt_tokens":10,"completion_tokens":50,"total_tokens":60,"completion_tokens_details":{"reasoning_tokens":42}}}`
+		resp, err := (&openaiAdapter{}).parseResponse([]byte(body))
+		if err != nil {
+			t.Fatalf("parseResponse failed: %v", err)
+		}
+		if resp.Usage == nil || resp.Usage.ReasoningTokens != 42 {
+			t.Errorf("expected 42 reasoning tokens, got %+v", resp.Usage)
+		}
+	})
+}
+
+func TestParseResponseCitations(t *testing.T) {
+	tests := []struct {
+		name    string
+		adapter providerAdapter
+		body    string
+		want    Citation
+	}{
+		{"openai", &openaiAdapter{}, `{"choices":[{"message":{"content":"hi","annotations":[{"type":"url_citation","url_citation":{"url":"https://example.com","title":"Example","start_index":0,"end_index":2}}]}}]}`,
+			Citation{URL: "https://example.com", Title: "Example", EndIndex: 2}},
+		{"anthropic", &anthropicAdapter{}, `{"content":[{"type":"text","text":"hi","citations":[{"type":"char_location","document_title":"Doc","cited_text":"hello"}]}]}`,
+			Citation{Title: "Doc", Text: "hello"}},
+		{"gemini", &geminiAdapter{}, `{"candidates":[{"content":{"parts":[{"text":"hi"}]},"groundingMetadata":{"groundingChunks":[{"web":{"uri":"https://example.com","title":"example.com"}}]}}]}`,
+			Citation{URL: "https://example.com", Title: "example.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := tt.adapter.parseResponse([]byte(tt.body))
+			if err != nil {
+				t.Fatalf("parseResponse failed: %v", err)
+			}
+			if len(resp.Citations) != 1 || resp.Citations[0] != tt.want {
+				t.Errorf("expected citation %+v, got %+v", tt.want, resp.Citations)
+			}
+		})
+	}
+}
+
+func TestAutoContinue(t *testing.T) {
+	replies := []string{
+		`{"choices":[{"message":{"role":"assistant","content":"Once upon "},"finish_reason":"length"}]}`,
+		`{"choices":[{"message":{"role":"assistant","content":"a time."},"finish_reason":"stop"}]}`,
+		`{"choices":[{"message":{"role":"assistant","content":"unused"},"finish_reason":"stop"}]}`,
+	}
+	var bodies []openaiChatCompletionRequestProbe
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body openaiChatCompletionRequestProbe
+		json.NewDecoder(r.Body).Decode(&body)
+		bodies = append(bodies, body)
+		w.Write([]byte(replies[len(bodies)-1]))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithProvider(ProviderOpenAI), WithAPIKey("test-key"), WithBaseURL(server.URL), WithAutoContinue(3))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	req := &Request{Messages: []Message{{Role: RoleUser, Content: "Tell me a story"}}}
+	resp, err := client.Generate(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	if resp.Text != "Once upon a time." {
+		t.Errorf("expected combined text, got %q", resp.Text)
+	}
+	if resp.FinishReason != FinishReasonStop {
+		t.Errorf("expected final finish reason stop, got %q", resp.FinishReason)
+	}
+	if len(bodies) != 2 {
+		t.Fatalf("expected continuation to stop after a non-length finish, got %d calls", len(bodies))
+	}
+	msgs := bodies[1].Messages
+	if len(msgs) != 3 || msgs[1].Role != "assistant" || msgs[1].Content != "Once upon " || msgs[2].Role != "user" {
+		t.Errorf("expected partial assistant reply and a continue turn, got %+v", msgs)
+	}
+	if len(req.Messages) != 1 {
+		t.Errorf("expected caller's messages to be unchanged, got %d", len(req.Messages))
+	}
+}
+
+func TestAutoContinueMaxRounds(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"more "},"finish_reason":"length"}]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithProvider(ProviderOpenAI), WithAPIKey("test-key"), WithBaseURL(server.URL), WithAutoContinue(2))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	resp, err := client.Generate(context.Background(), &Request{Messages: []Message{{Role: RoleUser, Content: "go"}}})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if calls != 3 || resp.Text != "more more more " || resp.FinishReason != FinishReasonLength {
+		t.Errorf("expected 1 call plus 2 continuations, got %d calls, text %q, finish %q", calls, resp.Text, resp.FinishReason)
+	}
+}
+
+func TestGenerateDoesNotMutateRequest(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls%2 == 1 {
+			w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"part "},"finish_reason":"length"}]}`))
+			return
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"done"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithProvider(ProviderOpenAI), WithAPIKey("test-key"), WithBaseURL(server.URL),
+		WithAutoContinue(1), WithToolArgumentRepair(true), WithDefaults(Defaults{SystemPrompt: "Be brief."}))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	// Spare capacity would let an append write into the caller's backing array.
+	messages := make([]Message, 0, 8)
+	messages = append(messages,
+		Message{Role: RoleUser, Content: "What's the weather?"},
+		Message{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "call_1", Type: "function", Function: "get_weather", Arguments: `{"city": "Paris",}`}}},
+		Message{Role: RoleTool, ToolCallID: "call_1", Content: "sunny"},
+	)
+	req := &Request{Messages: messages}
+	want := req.Clone()
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.Generate(context.Background(), req); err != nil {
+			t.Fatalf("Generate %d failed: %v", i, err)
+		}
+		if !reflect.DeepEqual(req, want) {
+			t.Fatalf("Generate %d modified the request: got %+v, want %+v", i, req, want)
+		}
+		if spare := messages[:cap(messages)][len(messages)]; !reflect.DeepEqual(spare, Message{}) {
+			t.Fatalf("Generate %d wrote past the end of Messages: %+v", i, spare)
+		}
+	}
+}
+
+// openaiChatCompletionRequestProbe decodes just the messages of an outgoing OpenAI request.
+type openaiChatCompletionRequestProbe struct {
+	Messages []struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	} `json:"messages"`
+}
+
+func TestWithDefaults(t *testing.T) {
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"ok"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	defaultTemp, reqTemp := 0.2, 0.9
+	client, err := NewClient(
+		WithProvider(ProviderOpenAI),
+		WithAPIKey("test-key"),
+		WithBaseURL(server.URL),
+		WithDefaults(Defaults{Model: "gpt-4o-mini", SystemPrompt: "Be brief.", Temperature: &defaultTemp, MaxTokens: 256}),
+	)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	// Unset fields take the defaults.
+	req := &Request{Messages: []Message{{Role: RoleUser, Content: "hi"}}}
+	if _, err := client.Generate(context.Background(), req); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if body["model"] != "gpt-4o-mini" || body["temperature"] != 0.2 || body["max_tokens"] != 256.0 {
+		t.Errorf("expected defaults in payload, got %v", body)
+	}
+	if msgs, _ := body["messages"].([]any); len(msgs) != 2 {
+		t.Errorf("expected the default system prompt to be sent, got %v", body["messages"])
+	}
+	if req.Model != "" || req.SystemPrompt != "" || req.Temperature != nil || req.MaxTokens != 0 {
+		t.Errorf("expected the caller's request to be left unmodified, got %+v", req)
+	}
+
+	// Fields set on the request win.
+	req = &Request{
+		Model:        "gpt-4o",
+		SystemPrompt: "Be thorough.",
+		Temperature:  &reqTemp,
+		MaxTokens:    1024,
+		Messages:     []Message{{Role: RoleUser, Content: "hi"}},
+	}
+	if _, err := client.Generate(context.Background(), req); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if body["model"] != "gpt-4o" || body["temperature"] != 0.9 || body["max_tokens"] != 1024.0 {
+		t.Errorf("expected request values to override defaults, got %v", body)
+	}
+	if msgs, _ := body["messages"].([]any); len(msgs) == 0 || msgs[0].(map[string]any)["content"] != "Be thorough." {
+		t.Errorf("expected the request system prompt, got %v", body["messages"])
+	}
+}
+
+func TestDefaultSystemPrompt(t *testing.T) {
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"content":[{"type":"text","text":"ok"}],"stop_reason":"end_turn"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithProvider(ProviderAnthropic), WithAPIKey("test-key"), WithBaseURL(server.URL),
+		WithDefaults(Defaults{SystemPrompt: "Be brief."}), WithDefaultSystemPrompt("You are a pirate."))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	tests := []struct {
+		name string
+		req  *Request
+		want string
+	}{
+		{"no system prompt", &Request{Messages: []Message{{Role: RoleUser, Content: "hi"}}}, "You are a pirate."},
+		{"request system prompt", &Request{SystemPrompt: "You are a poet.", Messages: []Message{{Role: RoleUser, Content: "hi"}}}, "You are a poet."},
+		{"system message", &Request{Messages: []Message{{Role: RoleSystem, Content: "You are a chef."}, {Role: RoleUser, Content: "hi"}}}, "You are a chef."},
+	}
+	for _, tt := range tests {
+		if _, err := client.Generate(context.Background(), tt.req); err != nil {
+			t.Fatalf("%s: Generate failed: %v", tt.name, err)
+		}
+		if body["system"] != tt.want {
+			t.Errorf("%s: expected system prompt %q, got %v", tt.name, tt.want, body["system"])
+		}
+		if msgs, _ := body["messages"].([]any); len(msgs) != 1 {
+			t.Errorf("%s: expected only the user message, got %v", tt.name, body["messages"])
+		}
+	}
+}
+
+func TestMaxTokensDefault(t *testing.T) {
+	tests := []struct {
+		provider   Provider
+		model      string
+		response   string
+		maxTokens  func(body map[string]any) any
+		unknownMax any // sent when the model's output limit is unknown
+	}{
+		{ProviderOpenAI, "gpt-4o", `{"choices":[{"message":{"content":"ok"}}]}`,
+			func(body map[string]any) any { return body["max_tokens"] }, nil},
+		{ProviderAnthropic, "claude-sonnet-4-5", `{"content":[{"type":"text","text":"ok"}]}`,
+			func(body map[string]any) any { return body["max_tokens"] }, 4096.0},
+		{ProviderGemini, "gemini-2.5-pro", `{"candidates":[{"content":{"parts":[{"text":"ok"}]}}]}`,
+			func(body map[string]any) any {
+				config, _ := body["generationConfig"].(map[string]any)
+				return config["maxOutputTokens"]
+			}, 8192.0},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.provider), func(t *testing.T) {
+			var body map[string]any
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body = nil
+				_ = json.NewDecoder(r.Body).Decode(&body)
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			generate := func(t *testing.T, req *Request, opts ...Option) any {
+				t.Helper()
+				opts = append([]Option{WithProvider(tt.provider), WithAPIKey("test-key"), WithBaseURL(server.URL)}, opts...)
+				client, err := NewClient(opts...)
+				if err != nil {
+					t.Fatalf("NewClient failed: %v", err)
+				}
+				if _, err := client.Generate(context.Background(), req); err != nil {
+					t.Fatalf("Generate failed: %v", err)
+				}
+				return tt.maxTokens(body)
+			}
+			newReq := func(maxTokens int) *Request {
+				return &Request{Model: tt.model, MaxTokens: maxTokens, Messages: []Message{{Role: RoleUser, Content: "hi"}}}
+			}
+			info := WithModelInfo(ModelInfo{ID: tt.model, OutputTokenLimit: 65536})
+
+			if got := generate(t, newReq(0)); got != tt.unknownMax {
+				t.Errorf("unknown model: expected %v, got %v", tt.unknownMax, got)
+			}
+			if got := generate(t, newReq(0), info); got != 65536.0 {
+				t.Errorf("known model: expected its output limit, got %v", got)
+			}
+			if got := generate(t, newReq(100), info); got != 100.0 {
+				t.Errorf("expected Request.MaxTokens to override the model limit, got %v", got)
+			}
+		})
+	}
+}
+
+func TestSystemRoleMessage(t *testing.T) {
+	req := &Request{Messages: []Message{
+		{Role: RoleSystem, Content: "Answer in French."},
+		{Role: RoleUser, Content: "Hello"},
+	}}
+	ctx := context.Background()
+
+	t.Run("openai", func(t *testing.T) {
+		payload, err := (&openaiAdapter{}).buildRequestPayload(ctx, req)
+		if err != nil {
+			t.Fatalf("buildRequestPayload failed: %v", err)
+		}
+		msgs := payload.(*OpenAIChatCompletionRequest).Messages
+		if len(msgs) != 2 || msgs[0].Role != "system" || msgs[0].Content != "Answer in French." || msgs[1].Role != "user" {
+			t.Errorf("expected one leading system message, got %+v", msgs)
+		}
+	})
+
+	t.Run("anthropic", func(t *testing.T) {
+		payload, err := (&anthropicAdapter{}).buildRequestPayload(ctx, req)
+		if err != nil {
+			t.Fatalf("buildRequestPayload failed: %v", err)
+		}
+		anthropicReq := payload.(*anthropicMessagesRequest)
+		if anthropicReq.System != "Answer in French." {
+			t.Errorf("expected the system message as system, got %q", anthropicReq.System)
+		}
+		if len(anthropicReq.Messages) != 1 || anthropicReq.Messages[0].Role != "user" {
+			t.Errorf("expected only the user message, got %+v", anthropicReq.Messages)
+		}
+	})
+
+	t.Run("gemini", func(t *testing.T) {
+		payload, err := (&geminiAdapter{}).buildRequestPayload(ctx, req)
+		if err != nil {
+			t.Fatalf("buildRequestPayload failed: %v", err)
+		}
+		geminiReq := payload.(*geminiGenerateContentRequest)
+		if si := geminiReq.SystemInstruction; si == nil || len(si.Parts) != 1 || si.Parts[0].Text == nil || *si.Parts[0].Text != "Answer in French." {
+			t.Errorf("expected the system message as systemInstruction, got %+v", si)
+		}
+		if len(geminiReq.Contents) != 1 || geminiReq.Contents[0].Role != "user" {
+			t.Errorf("expected only the user message, got %+v", geminiReq.Contents)
+		}
+	})
+
+	t.Run("explicit system prompt wins", func(t *testing.T) {
+		withPrompt := *req
+		withPrompt.SystemPrompt = "Be brief."
+		payload, err := (&anthropicAdapter{}).buildRequestPayload(ctx, &withPrompt)
+		if err != nil {
+			t.Fatalf("buildRequestPayload failed: %v", err)
+		}
+		if system := payload.(*anthropicMessagesRequest).System; system != "Be brief." {
+			t.Errorf("expected SystemPrompt to be used, got %q", system)
+		}
+	})
+}
+
+func TestResponseMiddleware(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"hello"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	upper := func(resp *Response) error {
+		resp.Text = strings.ToUpper(resp.Text)
+		return nil
+	}
+	disclaimer := func(resp *Response) error {
+		resp.Text += " (AI generated)"
+		return nil
+	}
+	req := &Request{Messages: []Message{{Role: RoleUser, Content: "hi"}}}
+
+	client, err := NewClient(WithProvider(ProviderOpenAI), WithAPIKey("test-key"), WithBaseURL(server.URL),
+		WithResponseMiddleware(upper), WithResponseMiddleware(disclaimer))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	resp, err := client.Generate(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if resp.Text != "HELLO (AI generated)" {
+		t.Errorf("expected the middlewares applied in order, got %q", resp.Text)
+	}
+
+	failing, err := NewClient(WithProvider(ProviderOpenAI), WithAPIKey("test-key"), WithBaseURL(server.URL),
+		WithResponseMiddleware(func(*Response) error { return errors.New("blocked") }))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	if _, err := failing.Generate(context.Background(), req); err == nil || !strings.Contains(err.Error(), "blocked") {
+		t.Errorf("expected the middleware error, got %v", err)
+	}
+}
+
+func TestRawResponse(t *testing.T) {
+	// Fields this package does not parse must survive in Raw.
+	const body = `{"id":"chatcmpl-1","system_fingerprint":"fp_1","choices":[{"message":{"role":"assistant","content":"hello"},"finish_reason":"stop"}]}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+	req := &Request{Messages: []Message{{Role: RoleUser, Content: "hi"}}}
+
+	client, err := NewClient(WithProvider(ProviderOpenAI), WithAPIKey("test-key"), WithBaseURL(server.URL), WithRawResponse(true))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	resp, err := client.Generate(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if string(resp.Raw) != body {
+		t.Errorf("expected the raw body %s, got %s", body, resp.Raw)
+	}
+
+	client, err = NewClient(WithProvider(ProviderOpenAI), WithAPIKey("test-key"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	if resp, err = client.Generate(context.Background(), req); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if resp.Raw != nil {
+		t.Errorf("expected no raw body by default, got %s", resp.Raw)
+	}
+}
+
+func TestAnthropicMessageMerging(t *testing.T) {
+	var body struct {
+		Messages []anthropicMessage `json:"messages"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"content":[{"type":"text","text":"ok"}],"stop_reason":"end_turn"}`))
+	}))
+	defer server.Close()
+
+	req := &Request{Messages: []Message{
+		{Role: RoleUser, Content: "Here is the report."},
+		{Role: RoleUser, Content: "Summarize it."},
+	}}
+	for _, merge := range []bool{false, true} {
+		client, err := NewClient(WithProvider(ProviderAnthropic), WithAPIKey("test-key"), WithBaseURL(server.URL), WithMessageMerging(merge))
+		if err != nil {
+			t.Fatalf("failed to create client: %v", err)
+		}
+		if _, err := client.Generate(context.Background(), req); err != nil {
+			t.Fatalf("Generate failed: %v", err)
+		}
+		if !merge {
+			if len(body.Messages) != 2 {
+				t.Errorf("expected 2 messages without merging, got %d", len(body.Messages))
+			}
+			continue
+		}
+		if len(body.Messages) != 1 || body.Messages[0].Role != "user" {
+			t.Fatalf("expected a single merged user message, got %+v", body.Messages)
+		}
+		blocks := body.Messages[0].Content
+		if len(blocks) != 2 || blocks[0].Text != "Here is the report." || blocks[1].Text != "Summarize it." {
+			t.Errorf("expected both text blocks in order, got %+v", blocks)
+		}
+	}
+}
+
+func TestProviderExtra(t *testing.T) {
+	var body map[string]json.RawMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithProvider(ProviderOpenAI), WithAPIKey("test-key"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	const prediction = `{"type":"content","content":"func main() {}"}`
+	_, err = client.Generate(context.Background(), &Request{
+		Messages: []Message{{Role: RoleUser, Content: "Rename main."}},
+		ProviderExtra: map[string]json.RawMessage{
+			"openai": json.RawMessage(`{"prediction":` + prediction + `,"model":"gpt-4o-mini"}`),
+			"gemini": json.RawMessage(`{"responseLogprobs":true}`),
+		},
+	})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if string(body["prediction"]) != prediction {
+		t.Errorf("expected the prediction field verbatim, got %s", body["prediction"])
+	}
+	if string(body["model"]) != `"gpt-4o-mini"` {
+		t.Errorf("expected extra fields to override the library's, got model %s", body["model"])
+	}
+	if _, ok := body["responseLogprobs"]; ok {
+		t.Error("expected another provider's extra fields to be left out")
+	}
+	if _, ok := body["messages"]; !ok {
+		t.Error("expected the library's fields to be kept")
+	}
+}
+
+// TestToolArgumentNumbers tests that large integers and decimals in tool call
+// arguments reach the provider, and come back from it, exactly as written
+func TestToolArgumentNumbers(t *testing.T) {
+	const args = `{"id":12345678901234567890,"price":19.99,"ratio":0.30000000000000004}`
+	req := &Request{Messages: []Message{
+		{Role: RoleUser, Content: "Look up the order"},
+		{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "call_1", Type: "function", Function: "lookup", Arguments: args}}},
+		{Role: RoleTool, ToolCallID: "call_1", Content: `{"total":98765432109876543210}`},
+	}}
+
+	for _, adapter := range []providerAdapter{&anthropicAdapter{}, &geminiAdapter{}} {
+		payload, err := adapter.buildRequestPayload(context.Background(), req)
+		if err != nil {
+			t.Fatalf("%T: buildRequestPayload failed: %v", adapter, err)
+		}
+		body, _ := json.Marshal(payload)
+		for _, want := range []string{`"id":12345678901234567890`, `"price":19.99`, `"ratio":0.30000000000000004`} {
+			if !strings.Contains(string(body), want) {
+				t.Errorf("%T: expected %s in payload, got %s", adapter, want, body)
+			}
+		}
+	}
+	payload, _ := (&geminiAdapter{}).buildRequestPayload(context.Background(), req)
+	if body, _ := json.Marshal(payload); !strings.Contains(string(body), `"total":98765432109876543210`) {
+		t.Errorf("expected the tool result number unchanged, got %s", body)
+	}
+
+	resp, err := (&geminiAdapter{}).parseResponse([]byte(`{"candidates":[{"content":{"role":"model","parts":[{"functionCall":{"name":"lookup","args":` + args + `}}]},"finishReason":"STOP"}]}`))
+	if err != nil {
+		t.Fatalf("parseResponse failed: %v", err)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].Arguments != args {
+		t.Errorf("expected arguments %s, got %+v", args, resp.ToolCalls)
+	}
+
+	if _, err := decodeArguments(`{"a":1} {"b":2}`); err == nil {
+		t.Error("expected trailing data after the arguments to be rejected")
+	}
+}
+
+// TestToolCallOnlyAssistantMessage tests that each provider gets its own valid
+// form of an assistant turn that only calls a tool without arguments
+func TestToolCallOnlyAssistantMessage(t *testing.T) {
+	req := &Request{Messages: []Message{
+		{Role: RoleUser, Content: "What time is it?"},
+		{Role: RoleAssistant, ContentParts: []ContentPart{NewTextPart("")}, ToolCalls: []ToolCall{{ID: "call_1", Type: "function", Function: "get_time", Arguments: "{}"}}},
+		{Role: RoleTool, ToolCallID: "call_1", Content: "14:00"},
+	}}
+	assistantTurn := func(t *testing.T, adapter providerAdapter, key string) map[string]any {
+		t.Helper()
+		payload, err := adapter.buildRequestPayload(context.Background(), req)
+		if err != nil {
+			t.Fatalf("buildRequestPayload failed: %v", err)
+		}
+		data, _ := json.Marshal(payload)
+		var body map[string][]map[string]any
+		json.Unmarshal(data, &body)
+		for _, msg := range body[key] {
+			if msg["role"] == "assistant" || msg["role"] == "model" {
+				return msg
+			}
+		}
+		t.Fatalf("no assistant turn in %s", data)
+		return nil
+	}
+
+	t.Run("openai", func(t *testing.T) {
+		msg := assistantTurn(t, &openaiAdapter{}, "messages")
+		content, ok := msg["content"]
+		if !ok || content != nil {
+			t.Errorf("expected content to be null, got %v (present: %v)", content, ok)
+		}
+		if calls, _ := msg["tool_calls"].([]any); len(calls) != 1 {
+			t.Errorf("expected the tool call, got %v", msg)
+		}
+	})
+
+	t.Run("anthropic", func(t *testing.T) {
+		msg := assistantTurn(t, &anthropicAdapter{}, "messages")
+		blocks, _ := msg["content"].([]any)
+		if len(blocks) != 1 {
+			t.Fatalf("expected only the tool_use block, got %v", msg["content"])
+		}
+		block := blocks[0].(map[string]any)
+		if input, ok := block["input"].(map[string]any); block["type"] != "tool_use" || !ok || len(input) != 0 {
+			t.Errorf("expected a tool_use block with an empty input object, got %v", block)
+		}
+	})
+
+	t.Run("gemini", func(t *testing.T) {
+		msg := assistantTurn(t, &geminiAdapter{}, "contents")
+		parts, _ := msg["parts"].([]any)
+		if len(parts) != 1 {
+			t.Fatalf("expected only the functionCall part, got %v", msg["parts"])
+		}
+		if _, ok := parts[0].(map[string]any)["functionCall"]; !ok {
+			t.Errorf("expected a functionCall part, got %v", parts[0])
+		}
+	})
+}
@@ -0,0 +1,223 @@
+package ai
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// recordedExchange is one provider call as written by WithRecorder, one JSON
+// object per line.
+type recordedExchange struct {
+	// Hash identifies the request by method, path, query and body; see exchangeHash.
+	Hash        string          `json:"hash"`
+	Method      string          `json:"method"`
+	Path        string          `json:"path"`
+	Request     json.RawMessage `json:"request,omitempty"`
+	Status      int             `json:"status"`
+	ContentType string          `json:"content_type,omitempty"`
+	// Response is the raw body: JSON for unary calls, the event stream for streams.
+	Response string `json:"response"`
+}
+
+// exchangeHash returns the key a request is recorded and replayed under.
+// Headers, including the API key, and the host are left out, so a recording
+// replays against any base URL with the same path.
+func exchangeHash(method, path, query string, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s?%s\n", method, path, query)
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// readRequestBody returns the body of req and a copy of req that can still be
+// sent with it.
+func readRequestBody(req *http.Request) (*http.Request, []byte, error) {
+	if req.Body == nil {
+		return req, nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+	clone := req.Clone(req.Context())
+	clone.Body = io.NopCloser(bytes.NewReader(body))
+	return clone, body, nil
+}
+
+// recordingTransport writes every exchange through next to w; see WithRecorder.
+type recordingTransport struct {
+	next http.RoundTripper
+	mu   sync.Mutex
+	w    io.Writer
+}
+
+func newRecordingTransport(next http.RoundTripper, w io.Writer) *recordingTransport {
+	return &recordingTransport{next: next, w: w}
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req, body, err := readRequestBody(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body for recording: %w", err)
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	exchange := &recordedExchange{
+		Hash:        exchangeHash(req.Method, req.URL.Path, req.URL.RawQuery, body),
+		Method:      req.Method,
+		Path:        req.URL.Path,
+		Status:      resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+	}
+	if json.Valid(body) {
+		exchange.Request = body
+	}
+	// The body is recorded as it is read, so streams still arrive incrementally.
+	resp.Body = &recordingBody{ReadCloser: resp.Body, transport: t, exchange: exchange}
+	return resp, nil
+}
+
+// CloseIdleConnections lets Client.Close reach the wrapped transport.
+func (t *recordingTransport) CloseIdleConnections() {
+	if closer, ok := t.next.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+func (t *recordingTransport) write(exchange *recordedExchange) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return json.NewEncoder(t.w).Encode(exchange)
+}
+
+// recordingBody copies what is read from a response body and writes the
+// exchange once the body is fully read or closed. A stream may be closed from
+// another goroutine while it is being read, so buf is guarded by mu.
+type recordingBody struct {
+	io.ReadCloser
+	transport *recordingTransport
+	exchange  *recordedExchange
+	once      sync.Once
+
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.mu.Lock()
+	b.buf.Write(p[:n])
+	b.mu.Unlock()
+	if err == io.EOF {
+		if writeErr := b.flush(); writeErr != nil {
+			return n, fmt.Errorf("failed to record response: %w", writeErr)
+		}
+	}
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	b.flush()
+	return b.ReadCloser.Close()
+}
+
+func (b *recordingBody) flush() error {
+	var err error
+	b.once.Do(func() {
+		b.mu.Lock()
+		b.exchange.Response = b.buf.String()
+		b.mu.Unlock()
+		err = b.transport.write(b.exchange)
+	})
+	return err
+}
+
+// replayTransport answers requests from recorded exchanges; see NewReplayClient.
+type replayTransport struct {
+	mu        sync.Mutex
+	exchanges map[string][]*recordedExchange
+}
+
+// next returns the recorded exchange for hash. A request recorded more than
+// once is answered in recorded order, and the last answer then repeats.
+func (t *replayTransport) next(hash string) (*recordedExchange, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	queue := t.exchanges[hash]
+	if len(queue) == 0 {
+		return nil, false
+	}
+	if len(queue) > 1 {
+		t.exchanges[hash] = queue[1:]
+	}
+	return queue[0], true
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	_, body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	exchange, ok := t.next(exchangeHash(req.Method, req.URL.Path, req.URL.RawQuery, body))
+	if !ok {
+		// A 404 rather than a transport error, so the call fails without retries.
+		message, _ := json.Marshal(fmt.Sprintf("%s: %s %s", ErrNotRecorded, req.Method, req.URL.Path))
+		return replayResponse(req, http.StatusNotFound, "application/json", `{"error":{"message":`+string(message)+`}}`), nil
+	}
+	return replayResponse(req, exchange.Status, exchange.ContentType, exchange.Response), nil
+}
+
+func replayResponse(req *http.Request, status int, contentType, body string) *http.Response {
+	header := make(http.Header)
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// ErrNotRecorded is reported in the error of a replay client call whose
+// request is not in the recording.
+var ErrNotRecorded = errors.New("no recorded response for request")
+
+// NewReplayClient returns a client that answers provider calls from a recording
+// made with WithRecorder instead of the network, for offline regression tests.
+// opts configure the client as for NewClient and should match those of the
+// recording client, since requests are matched by the exact provider request:
+// method, path and body. A request recorded more than once is answered in
+// recorded order. A request that was not recorded fails with an error
+// mentioning ErrNotRecorded.
+func NewReplayClient(r io.Reader, opts ...Option) (Client, error) {
+	transport := &replayTransport{exchanges: make(map[string][]*recordedExchange)}
+	dec := json.NewDecoder(r)
+	for {
+		var exchange recordedExchange
+		if err := dec.Decode(&exchange); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read recording: %w", err)
+		}
+		transport.exchanges[exchange.Hash] = append(transport.exchanges[exchange.Hash], &exchange)
+	}
+	return NewClient(append(opts, func(c *Config) { c.transport = transport })...)
+}
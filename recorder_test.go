@@ -0,0 +1,130 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRecordAndReplay tests that calls recorded against a server are answered
+// from the recording once the server is gone
+func TestRecordAndReplay(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Hel\"}}]}\n\n")
+			fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"lo\"},\"finish_reason\":\"stop\"}]}\n\n")
+			fmt.Fprint(w, "data: [DONE]\n\n")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"Bonjour"},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4}}`)
+	}))
+
+	unaryReq := &Request{Messages: []Message{{Role: RoleUser, Content: "Say hello in French"}}}
+	streamReq := &Request{Messages: []Message{{Role: RoleUser, Content: "Say hello"}}}
+	streamText := func(t *testing.T, client Client) string {
+		t.Helper()
+		var text string
+		for chunk, err := range StreamSeq(context.Background(), client, streamReq) {
+			if err != nil {
+				t.Fatalf("stream error: %v", err)
+			}
+			text += chunk.TextDelta
+		}
+		return text
+	}
+
+	var recording bytes.Buffer
+	client, err := NewClient(WithProvider(ProviderOpenAI), WithAPIKey("secret-key"), WithBaseURL(server.URL), WithRecorder(&recording))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	recorded, err := client.Generate(context.Background(), unaryReq)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if text := streamText(t, client); text != "Hello" {
+		t.Fatalf("expected streamed text Hello, got %q", text)
+	}
+	server.Close()
+
+	if lines := strings.Count(recording.String(), "\n"); lines != 2 {
+		t.Fatalf("expected 2 recorded calls, got %d:\n%s", lines, recording.String())
+	}
+	if strings.Contains(recording.String(), "secret-key") {
+		t.Error("expected the API key to be left out of the recording")
+	}
+
+	replay, err := NewReplayClient(&recording, WithProvider(ProviderOpenAI), WithAPIKey("test-key"))
+	if err != nil {
+		t.Fatalf("NewReplayClient failed: %v", err)
+	}
+	resp, err := replay.Generate(context.Background(), unaryReq)
+	if err != nil {
+		t.Fatalf("replayed Generate failed: %v", err)
+	}
+	if resp.Text != recorded.Text || resp.Usage == nil || resp.Usage.TotalTokens != 4 {
+		t.Errorf("expected the recorded response, got %+v", resp)
+	}
+	if text := streamText(t, replay); text != "Hello" {
+		t.Errorf("expected the recorded stream, got %q", text)
+	}
+	if calls != 2 {
+		t.Errorf("expected replay to make no network calls, got %d calls", calls)
+	}
+
+	_, err = replay.Generate(context.Background(), &Request{Messages: []Message{{Role: RoleUser, Content: "Something else"}}})
+	if err == nil || !strings.Contains(err.Error(), ErrNotRecorded.Error()) {
+		t.Errorf("expected an unrecorded request to fail with ErrNotRecorded, got %v", err)
+	}
+}
+
+// TestRecordClosedMidStream tests that a stream closed from another goroutine
+// while it is being read is recorded up to that point; run it with -race
+func TestRecordClosedMidStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for {
+			fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"more\"}}]}\n\n")
+			w.(http.Flusher).Flush()
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(time.Millisecond):
+			}
+		}
+	}))
+	defer server.Close()
+
+	var recording bytes.Buffer
+	client := &http.Client{Transport: newRecordingTransport(http.DefaultTransport, &recording)}
+	resp, err := client.Get(server.URL + "/v1/chat/completions")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		io.Copy(io.Discard, resp.Body)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	resp.Body.Close()
+	<-done
+
+	if lines := strings.Count(recording.String(), "\n"); lines != 1 {
+		t.Fatalf("expected 1 recorded call, got %d:\n%s", lines, recording.String())
+	}
+	if !strings.Contains(recording.String(), "more") {
+		t.Errorf("expected the stream read so far to be recorded, got %s", recording.String())
+	}
+}
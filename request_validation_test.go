@@ -411,6 +411,37 @@ func TestRequestValidation_WhitespaceOnlyModel(t *testing.T) {
 	}
 }
 
+// TestRequestValidation_NegativeTemperature tests that a negative temperature is rejected
+func TestRequestValidation_NegativeTemperature(t *testing.T) {
+	temp := -0.5
+	req := &Request{
+		Temperature: &temp,
+		Messages: []Message{
+			{Role: RoleUser, Content: "test"},
+		},
+	}
+
+	err := req.Validate()
+	if err == nil || !strings.Contains(err.Error(), "temperature cannot be negative") {
+		t.Errorf("Expected negative temperature error, got: %v", err)
+	}
+}
+
+// TestRequestValidation_ProviderExtraNotObject tests that provider extra fields must be a JSON object
+func TestRequestValidation_ProviderExtraNotObject(t *testing.T) {
+	req := &Request{
+		ProviderExtra: map[string]json.RawMessage{"openai": json.RawMessage(`["prediction"]`)},
+		Messages: []Message{
+			{Role: RoleUser, Content: "test"},
+		},
+	}
+
+	err := req.Validate()
+	if err == nil || !strings.Contains(err.Error(), "provider_extra[openai]: must be a JSON object") {
+		t.Errorf("Expected provider extra error, got: %v", err)
+	}
+}
+
 // TestRequestValidation_ValidRequests tests various valid request configurations
 func TestRequestValidation_ValidRequests(t *testing.T) {
 	testCases := []struct {
@@ -542,3 +573,144 @@ func TestRequestValidation_Integration(t *testing.T) {
 		t.Errorf("Expected error about empty messages, got: %v", err)
 	}
 }
+
+// parallelToolCallMessages returns a conversation with two parallel tool calls and their results.
+func parallelToolCallMessages() []Message {
+	return []Message{
+		{Role: RoleUser, Content: "What's the weather in Paris and Tokyo?"},
+		{Role: RoleAssistant, ToolCalls: []ToolCall{
+			{ID: "call_paris", Type: "function", Function: "get_weather", Arguments: `{"city":"Paris"}`},
+			{ID: "call_tokyo", Type: "function", Function: "get_weather", Arguments: `{"city":"Tokyo"}`},
+		}},
+		{Role: RoleTool, ToolCallID: "call_paris", Content: `{"temp":18}`},
+		{Role: RoleTool, ToolCallID: "call_tokyo", Content: `{"temp":24}`},
+	}
+}
+
+// TestRequestValidation_ToolResults tests the pairing of tool calls and tool results
+func TestRequestValidation_ToolResults(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func([]Message) []Message
+		wantErr string
+	}{
+		{"all calls answered", func(m []Message) []Message { return m }, ""},
+		{"results out of order", func(m []Message) []Message {
+			m[2], m[3] = m[3], m[2]
+			return m
+		}, ""},
+		{"missing result", func(m []Message) []Message { return m[:3] }, `tool call "call_tokyo" (get_weather) has no tool result`},
+		{"unknown result", func(m []Message) []Message {
+			m[3].ToolCallID = "call_london"
+			return m
+		}, `tool result "call_london" does not match any pending tool call`},
+		{"duplicate result", func(m []Message) []Message {
+			m[3].ToolCallID = "call_paris"
+			return m
+		}, `tool result "call_paris" does not match any pending tool call`},
+		{"orphan result", func(m []Message) []Message { return []Message{m[0], m[2]} }, "does not follow an assistant message with tool calls"},
+		{"call without ID", func(m []Message) []Message {
+			m[1].ToolCalls[1].ID = ""
+			return m
+		}, "tool call must have an ID"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &Request{Messages: tt.mutate(parallelToolCallMessages())}
+			err := req.ValidateToolResults()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestOpenAIParallelToolResults tests that parallel tool results are serialized in order
+// with their tool_call_id, and that strict validation rejects unpaired results
+func TestOpenAIParallelToolResults(t *testing.T) {
+	adapter := &openaiAdapter{}
+	payload, err := adapter.buildRequestPayload(context.Background(), &Request{Messages: parallelToolCallMessages()})
+	if err != nil {
+		t.Fatalf("buildRequestPayload failed: %v", err)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("Failed to marshal payload: %v", err)
+	}
+	var sent struct {
+		Messages []struct {
+			Role       string `json:"role"`
+			Content    any    `json:"content"`
+			ToolCallID string `json:"tool_call_id"`
+			ToolCalls  []struct {
+				ID string `json:"id"`
+			} `json:"tool_calls"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(data, &sent); err != nil {
+		t.Fatalf("Failed to unmarshal payload: %v", err)
+	}
+	if len(sent.Messages) != 4 {
+		t.Fatalf("Expected 4 messages, got %d", len(sent.Messages))
+	}
+	if calls := sent.Messages[1].ToolCalls; len(calls) != 2 || calls[0].ID != "call_paris" || calls[1].ID != "call_tokyo" {
+		t.Errorf("Expected assistant tool calls [call_paris call_tokyo], got %+v", calls)
+	}
+	for i, want := range []struct{ id, content string }{{"call_paris", `{"temp":18}`}, {"call_tokyo", `{"temp":24}`}} {
+		msg := sent.Messages[2+i]
+		if msg.Role != "tool" || msg.ToolCallID != want.id || msg.Content != want.content {
+			t.Errorf("Tool result %d: expected %s/%s, got %+v", i, want.id, want.content, msg)
+		}
+	}
+
+	client, err := NewClient(
+		WithProvider(ProviderOpenAI),
+		WithAPIKey("test-key"),
+		WithBaseURL("http://invalid"),
+		WithStrictValidation(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	_, err = client.Generate(context.Background(), &Request{Messages: parallelToolCallMessages()[:3]})
+	if err == nil || !strings.Contains(err.Error(), "has no tool result") {
+		t.Errorf("Expected strict validation error, got: %v", err)
+	}
+}
+
+// TestRequestFromTranscript tests building a multi-turn request from a transcript
+func TestRequestFromTranscript(t *testing.T) {
+	req := RequestFromTranscript("You are a helpful assistant.", []Turn{
+		{Role: RoleUser, Text: "What is the capital of France?"},
+		{Role: RoleAssistant, Text: "Paris."},
+		{Role: RoleUser, Text: "And of Japan?"},
+	})
+
+	if err := req.Validate(); err != nil {
+		t.Fatalf("Expected transcript request to be valid, got: %v", err)
+	}
+	if req.SystemPrompt != "You are a helpful assistant." {
+		t.Errorf("Unexpected system prompt: %q", req.SystemPrompt)
+	}
+	if len(req.Messages) != 3 {
+		t.Fatalf("Expected 3 messages, got %d", len(req.Messages))
+	}
+	if req.Messages[1].Role != RoleAssistant || req.Messages[1].Content != "Paris." {
+		t.Errorf("Unexpected second message: %+v", req.Messages[1])
+	}
+	if req.Messages[2].Role != RoleUser || req.Messages[2].Content != "And of Japan?" {
+		t.Errorf("Unexpected third message: %+v", req.Messages[2])
+	}
+
+	// An empty transcript produces a request that fails validation.
+	if err := RequestFromTranscript("system", nil).Validate(); err == nil {
+		t.Error("Expected empty transcript to fail validation")
+	}
+}
@@ -14,6 +14,8 @@ type sseEvent struct {
 }
 
 // sseDecoder provides minimal SSE parsing suitable for provider streaming APIs.
+// Lines are read with a bufio.Reader rather than a bufio.Scanner, so a single
+// data line, such as one carrying large tool call arguments, has no size limit.
 type sseDecoder struct {
 	r *bufio.Reader
 }
@@ -2,9 +2,33 @@ package ai
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"iter"
 )
 
+// ErrStreamingUnsupported is returned when streaming is requested from a client
+// or provider that does not support it.
+var ErrStreamingUnsupported = errors.New("streaming not supported by this client")
+
+// StreamingUnsupportedError is returned by Stream when the client or its
+// provider adapter cannot stream. It matches ErrStreamingUnsupported via errors.Is.
+type StreamingUnsupportedError struct {
+	Provider string // Empty when the client does not identify its provider
+}
+
+func (e *StreamingUnsupportedError) Error() string {
+	if e.Provider == "" {
+		return ErrStreamingUnsupported.Error()
+	}
+	return fmt.Sprintf("%s: provider %s", ErrStreamingUnsupported, e.Provider)
+}
+
+func (e *StreamingUnsupportedError) Unwrap() error {
+	return ErrStreamingUnsupported
+}
+
 // StreamingClient exposes streaming generation without changing the existing Client API.
 // genericClient implements this interface; users can call Stream via the helper function.
 type StreamingClient interface {
@@ -25,16 +49,28 @@ type StreamReader interface {
 type StreamChunk struct {
 	// TextDelta is the incremental text returned in this chunk.
 	TextDelta string
+	// ReasoningDelta is incremental thinking text; see Response.Reasoning.
+	ReasoningDelta string
 	// ToolCallDeltas contains incremental tool/function call updates.
 	ToolCallDeltas []ToolCallDelta
+	// Citations are sources reported in this chunk, e.g. OpenAI annotations or Gemini grounding.
+	Citations []Citation
+	// Logprobs are the log probabilities of the tokens in this chunk, when requested.
+	Logprobs []TokenLogprob
 	// Snapshot is the accumulated response after applying this chunk.
 	Snapshot *Response
 	// Done indicates the provider signaled completion in this chunk.
 	Done bool
+	// FinishReason is why generation stopped, set on the chunk where the
+	// provider reports it; Snapshot.FinishReason keeps it for later chunks.
+	FinishReason FinishReason
 }
 
 // ToolCallDelta represents incremental tool call data.
+// Index identifies the tool call's position within the response and is used to
+// join fragments that arrive without an ID (as OpenAI does after the first fragment).
 type ToolCallDelta struct {
+	Index            int
 	ID               string
 	Type             string
 	Function         string
@@ -43,11 +79,92 @@ type ToolCallDelta struct {
 	Done             bool
 }
 
+// CanStream reports whether the client supports streaming generation.
+func CanStream(client Client) bool {
+	if gc, ok := client.(*genericClient); ok {
+		_, ok := gc.adapter.(streamingAdapter)
+		return ok
+	}
+	_, ok := client.(StreamingClient)
+	return ok
+}
+
+// AccumulateStream reads r until it ends and returns the accumulated response,
+// including tool calls and citations. It does not close r.
+func AccumulateStream(r StreamReader) (*Response, error) {
+	resp := &Response{}
+	for {
+		chunk, err := r.Recv()
+		if errors.Is(err, io.EOF) {
+			return resp, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if chunk.Snapshot != nil {
+			resp = chunk.Snapshot
+		}
+		if chunk.Done {
+			return resp, nil
+		}
+	}
+}
+
 // Stream invokes streaming generation when supported by the client.
-// It returns an error if the provided client does not implement streaming.
+// It returns a *StreamingUnsupportedError if the client cannot stream.
 func Stream(ctx context.Context, client Client, req *Request) (StreamReader, error) {
 	if sc, ok := client.(StreamingClient); ok {
 		return sc.Stream(ctx, req)
 	}
-	return nil, fmt.Errorf("streaming not supported by this client")
+	return nil, &StreamingUnsupportedError{}
+}
+
+// StreamSeq streams req from client as an iterator, for use with range:
+//
+//	for chunk, err := range ai.StreamSeq(ctx, client, req) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Print(chunk.TextDelta)
+//	}
+//
+// The stream ends without an error at io.EOF. A failure to start the stream or
+// to read from it is yielded once as a final (nil, err) pair. The underlying
+// reader is closed when the loop ends, including when it breaks early.
+func StreamSeq(ctx context.Context, client Client, req *Request) iter.Seq2[*StreamChunk, error] {
+	return func(yield func(*StreamChunk, error) bool) {
+		r, err := Stream(ctx, client, req)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		defer r.Close()
+		for {
+			chunk, err := r.Recv()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(chunk, nil) {
+				return
+			}
+		}
+	}
+}
+
+// GenerateViaStream generates a response for req by streaming it from client
+// and accumulating the chunks, for callers that want a streamed request (for
+// example, to measure time to first byte) but a complete *Response. It returns
+// a *StreamingUnsupportedError if the client cannot stream. Streamed responses
+// may lack fields only unary calls report, such as Usage.
+func GenerateViaStream(ctx context.Context, client Client, req *Request) (*Response, error) {
+	r, err := Stream(ctx, client, req)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return AccumulateStream(r)
 }
@@ -0,0 +1,41 @@
+package ai
+
+import (
+	"context"
+	"net/http"
+)
+
+// StreamClient streams from any OpenAI-compatible chat completions endpoint,
+// such as the ai-gateway's, yielding universal StreamChunks. It lets a Go
+// program consume the gateway without parsing SSE itself.
+type StreamClient struct {
+	c *genericClient
+}
+
+// NewStreamClient returns a StreamClient for the OpenAI-compatible API at
+// baseURL, which includes any version segment (for the ai-gateway, for example,
+// "http://localhost:8080/openai/v1"). The provider and base URL are fixed;
+// other options, including the required WithAPIKey, apply as for NewClient.
+func NewStreamClient(baseURL string, opts ...Option) (*StreamClient, error) {
+	cfg := newConfig(opts)
+	cfg.provider = ProviderOpenAI
+	cfg.baseURL = baseURL
+	if err := validateConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	headers := make(http.Header)
+	headers.Set("Authorization", "Bearer "+cfg.apiKey)
+	b := newBaseClient(string(ProviderOpenAI), baseURL, "", cfg.timeout, headers, 3)
+	return &StreamClient{c: newGenericClient(cfg, b, &openaiAdapter{params: newParamPolicy(cfg)})}, nil
+}
+
+// Close closes the client's idle connections; see CloseClient.
+func (s *StreamClient) Close() error {
+	return s.c.Close()
+}
+
+// Stream sends req to the endpoint and returns a reader over its chunks.
+func (s *StreamClient) Stream(ctx context.Context, req *Request) (StreamReader, error) {
+	return s.c.Stream(ctx, req)
+}
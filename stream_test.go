@@ -2,10 +2,14 @@ package ai
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 )
@@ -208,3 +212,878 @@ func TestGeminiStreaming(t *testing.T) {
 		t.Fatalf("unexpected tool call: %+v", finalSnap.ToolCalls[0])
 	}
 }
+
+func TestOpenAIStreamingToolCallFragments(t *testing.T) {
+	events := []string{
+		`{"choices":[{"index":0,"delta":{"role":"assistant","content":null,"tool_calls":[{"index":0,"id":"call_abc","type":"function","function":{"name":"get_weather","arguments":""}}]}}]}`,
+		`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"loc"}}]}}]}`,
+		`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"ation\":"}}]}}]}`,
+		`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Paris\"}"}}]}}]}`,
+		`{"choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher, _ := w.(http.Flusher)
+		for _, e := range events {
+			fmt.Fprintf(w, "data: %s\n\n", e)
+			flusher.Flush()
+		}
+		fmt.Fprintf(w, "data: [DONE]\n\n")
+		flusher.Flush()
+	}))
+	defer server.Close()
+
+	client, err := NewClient(
+		WithProvider(ProviderOpenAI),
+		WithAPIKey("test-key"),
+		WithBaseURL(server.URL),
+		WithTimeout(30*time.Second),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	reader, err := Stream(context.Background(), client, &Request{
+		Messages: []Message{{Role: RoleUser, Content: "weather in Paris?"}},
+	})
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	defer reader.Close()
+
+	var finalSnap *Response
+	for {
+		chunk, err := reader.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv error: %v", err)
+		}
+		for _, d := range chunk.ToolCallDeltas {
+			if d.ID != "call_abc" {
+				t.Fatalf("expected fragment to be attributed to call_abc, got %q", d.ID)
+			}
+		}
+		finalSnap = chunk.Snapshot
+	}
+
+	if finalSnap == nil || len(finalSnap.ToolCalls) != 1 {
+		t.Fatalf("expected 1 tool call in snapshot, got %+v", finalSnap)
+	}
+	tc := finalSnap.ToolCalls[0]
+	if tc.ID != "call_abc" || tc.Function != "get_weather" || tc.Arguments != `{"location":"Paris"}` {
+		t.Fatalf("unexpected tool call: %+v", tc)
+	}
+}
+
+func TestStreamAccumulatorJoinsFragmentsByIndex(t *testing.T) {
+	acc := newStreamAccumulator()
+	acc.applyChunk(&StreamChunk{ToolCallDeltas: []ToolCallDelta{
+		{Index: 0, ID: "call_a", Type: "function", Function: "first"},
+		{Index: 1, ID: "call_b", Type: "function", Function: "second"},
+	}})
+	acc.applyChunk(&StreamChunk{ToolCallDeltas: []ToolCallDelta{{Index: 1, ArgumentsDelta: `{"b":`}}})
+	acc.applyChunk(&StreamChunk{ToolCallDeltas: []ToolCallDelta{{Index: 0, ArgumentsDelta: `{"a":1}`}}})
+	acc.applyChunk(&StreamChunk{ToolCallDeltas: []ToolCallDelta{{Index: 1, ArgumentsDelta: `2}`}}})
+
+	snap := acc.snapshot()
+	if len(snap.ToolCalls) != 2 {
+		t.Fatalf("expected 2 tool calls, got %+v", snap.ToolCalls)
+	}
+	if snap.ToolCalls[0].ID != "call_a" || snap.ToolCalls[0].Arguments != `{"a":1}` {
+		t.Fatalf("unexpected first tool call: %+v", snap.ToolCalls[0])
+	}
+	if snap.ToolCalls[1].ID != "call_b" || snap.ToolCalls[1].Arguments != `{"b":2}` {
+		t.Fatalf("unexpected second tool call: %+v", snap.ToolCalls[1])
+	}
+}
+
+// generateOnlyClient implements Client without streaming support.
+type generateOnlyClient struct{}
+
+func (generateOnlyClient) Generate(ctx context.Context, req *Request) (*Response, error) {
+	return &Response{}, nil
+}
+
+func TestCanStream(t *testing.T) {
+	client, err := NewClient(
+		WithProvider(ProviderOpenAI),
+		WithAPIKey("test-key"),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	if !CanStream(client) {
+		t.Fatal("expected OpenAI client to support streaming")
+	}
+
+	var plain generateOnlyClient
+	if CanStream(plain) {
+		t.Fatal("expected Generate-only client not to support streaming")
+	}
+	req := &Request{Messages: []Message{{Role: RoleUser, Content: "hi"}}}
+	var unsupported *StreamingUnsupportedError
+	if _, err := Stream(context.Background(), plain, req); !errors.Is(err, ErrStreamingUnsupported) || !errors.As(err, &unsupported) {
+		t.Fatalf("expected StreamingUnsupportedError, got %v", err)
+	}
+}
+
+// nonStreamingAdapter wraps a providerAdapter, hiding its streaming support.
+type nonStreamingAdapter struct {
+	providerAdapter
+}
+
+func TestStreamUnsupportedAdapter(t *testing.T) {
+	cfg := &Config{provider: ProviderOpenAI, maxResponseSize: maxResponseSize}
+	b := newBaseClient("stub", "http://example.invalid", "v1", time.Second, nil, 1)
+	client := newGenericClient(cfg, b, nonStreamingAdapter{&openaiAdapter{}})
+
+	if CanStream(client) {
+		t.Fatal("expected client with a non-streaming adapter not to support streaming")
+	}
+	req := &Request{Messages: []Message{{Role: RoleUser, Content: "hi"}}}
+	_, err := Stream(context.Background(), client, req)
+	var unsupported *StreamingUnsupportedError
+	if !errors.As(err, &unsupported) || !errors.Is(err, ErrStreamingUnsupported) {
+		t.Fatalf("expected StreamingUnsupportedError, got %v", err)
+	}
+	if unsupported.Provider != "stub" {
+		t.Errorf("expected provider %q in error, got %q", "stub", unsupported.Provider)
+	}
+}
+
+func TestStreamRecvContextCancel(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher, _ := w.(http.Flusher)
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Hello\"}}]}\n\n")
+		flusher.Flush()
+		// Stall until the test finishes, like a provider that stopped sending.
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client, err := NewClient(WithProvider(ProviderOpenAI), WithAPIKey("test-key"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reader, err := Stream(ctx, client, &Request{Messages: []Message{{Role: RoleUser, Content: "hi"}}})
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	defer reader.Close()
+
+	if chunk, err := reader.Recv(); err != nil || chunk.TextDelta != "Hello" {
+		t.Fatalf("expected first chunk, got %+v, %v", chunk, err)
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := reader.Recv()
+		errCh <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Recv did not return promptly after cancellation")
+	}
+
+	if _, err := reader.Recv(); err != io.EOF {
+		t.Errorf("expected io.EOF after the reader closed, got %v", err)
+	}
+}
+
+// TestGeminiStreamThroughOpenAIFormat streams from a mock Gemini server through the
+// same converter path the gateway uses and checks the OpenAI-format SSE output.
+func TestGeminiStreamThroughOpenAIFormat(t *testing.T) {
+	gemini := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1beta/models/gemini-2.5-flash:streamGenerateContent" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		flusher, _ := w.(http.Flusher)
+		// Pretty-printed array elements, as the real API sends them, split across writes.
+		for _, part := range []string{
+			"[{\n  \"candidates\": [{\"content\": {\"role\": \"model\", \"parts\": [{\"text\": \"Hel\"}]}}]\n}\n",
+			",\r\n{\n  \"candidates\": [{\"content\": {\"parts\": [{\"text\": \"lo {\\\"x\\\"}\"}]}}]\n}\n",
+			",\r\n{\n  \"candidates\": [{\"content\": {\"parts\": [{\"functionCall\": {\"name\": \"lookup\", \"args\": {\"q\": \"go\"}}}]}}]\n}\n",
+			",\r\n{\n  \"candidates\": [{\"content\": {\"parts\": [{\"text\": \"\"}]}, \"finishReason\": \"STOP\"}],\n  \"usageMetadata\": {\"totalTokenCount\": 9}\n}\n]",
+		} {
+			fmt.Fprint(w, part)
+			flusher.Flush()
+		}
+	}))
+	defer gemini.Close()
+
+	client, err := NewClient(WithProvider(ProviderGemini), WithAPIKey("test-key"), WithBaseURL(gemini.URL))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	// Mirror the gateway: decode an OpenAI request, stream from Gemini, re-encode as OpenAI SSE.
+	converter := NewOpenAIFormatConverter()
+	httpReq := httptest.NewRequest(http.MethodPost, "/v1/chat/completions",
+		strings.NewReader(`{"model":"gemini-2.5-flash","stream":true,"messages":[{"role":"user","content":"hi"}]}`))
+	providerReq, err := converter.DecodeRequest(httpReq)
+	if err != nil {
+		t.Fatalf("DecodeRequest failed: %v", err)
+	}
+	if !converter.IsStreaming(providerReq) {
+		t.Fatal("expected a streaming request")
+	}
+	universalReq, err := converter.ConvertRequestFromFormat(providerReq)
+	if err != nil {
+		t.Fatalf("ConvertRequestFromFormat failed: %v", err)
+	}
+	reader, err := Stream(context.Background(), client, universalReq)
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	defer reader.Close()
+
+	rec := httptest.NewRecorder()
+	handler := converter.NewStreamHandler("chatcmpl-test", "gemini-2.5-flash")
+	handler.OnStart(rec, rec)
+	for {
+		chunk, err := reader.Recv()
+		if errors.Is(err, io.EOF) {
+			handler.OnEnd(rec, rec)
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv error: %v", err)
+		}
+		if err := handler.OnChunk(rec, rec, chunk); err != nil {
+			t.Fatalf("OnChunk failed: %v", err)
+		}
+		if chunk.Done {
+			break
+		}
+	}
+
+	var text, toolName, toolArgs, finish string
+	var sawDone bool
+	for _, line := range strings.Split(rec.Body.String(), "\n") {
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			continue
+		}
+		if data == "[DONE]" {
+			sawDone = true
+			continue
+		}
+		var chunk openAIStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			t.Fatalf("invalid SSE chunk %q: %v", data, err)
+		}
+		if chunk.Object != "chat.completion.chunk" || len(chunk.Choices) != 1 {
+			t.Fatalf("unexpected chunk shape: %s", data)
+		}
+		choice := chunk.Choices[0]
+		text += choice.Delta.Content
+		for _, tc := range choice.Delta.ToolCalls {
+			toolName += tc.Function.Name
+			toolArgs += tc.Function.Arguments
+		}
+		if choice.FinishReason != "" {
+			finish = choice.FinishReason
+		}
+	}
+
+	if text != `Hello {"x"}` {
+		t.Errorf("unexpected streamed text: %q", text)
+	}
+	if toolName != "lookup" || toolArgs != `{"q":"go"}` {
+		t.Errorf("unexpected tool call: name=%q args=%q", toolName, toolArgs)
+	}
+	if finish != "tool_calls" {
+		t.Errorf("expected finish_reason tool_calls, got %q", finish)
+	}
+	if !sawDone {
+		t.Error("expected terminating [DONE] event")
+	}
+}
+
+func TestStreamCitationsAccumulate(t *testing.T) {
+	tests := []struct {
+		name        string
+		provider    Provider
+		contentType string
+		body        string
+		want        []Citation
+	}{
+		{
+			name:        "openai annotations",
+			provider:    ProviderOpenAI,
+			contentType: "text/event-stream",
+			body: `data: {"choices":[{"delta":{"content":"Go 1.24 is out."}}]}
+
+data: {"choices":[{"delta":{"annotations":[{"type":"url_citation","url_citation":{"url":"https://go.dev/doc/go1.24","title":"Go 1.24 Release Notes","start_index":0,"end_index":15}}]}}]}
+
+data: {"choices":[{"delta":{"annotations":[{"type":"url_citation","url_citation":{"url":"https://go.dev/doc/go1.24","title":"Go 1.24 Release Notes","start_index":0,"end_index":15}},{"type":"url_citation","url_citation":{"url":"https://go.dev/blog","title":"The Go Blog","start_index":0,"end_index":15}}]},"finish_reason":"stop"}]}
+
+data: [DONE]
+
+`,
+			want: []Citation{
+				{URL: "https://go.dev/doc/go1.24", Title: "Go 1.24 Release Notes", StartIndex: 0, EndIndex: 15},
+				{URL: "https://go.dev/blog", Title: "The Go Blog", StartIndex: 0, EndIndex: 15},
+			},
+		},
+		{
+			name:        "gemini grounding",
+			provider:    ProviderGemini,
+			contentType: "application/json",
+			body: `[{"candidates":[{"content":{"parts":[{"text":"Go 1.24 is out."}]}}]},
+{"candidates":[{"content":{"parts":[{"text":""}]},"finishReason":"STOP","groundingMetadata":{
+  "groundingChunks":[{"web":{"uri":"https://go.dev/doc/go1.24","title":"go.dev"}},{"web":{"uri":"https://go.dev/blog","title":"go.dev"}}],
+  "groundingSupports":[{"segment":{"endIndex":15,"text":"Go 1.24 is out."},"groundingChunkIndices":[0,1]}]}}]}]`,
+			want: []Citation{
+				{URL: "https://go.dev/doc/go1.24", Title: "go.dev", Text: "Go 1.24 is out.", EndIndex: 15},
+				{URL: "https://go.dev/blog", Title: "go.dev", Text: "Go 1.24 is out.", EndIndex: 15},
+			},
+		},
+		{
+			name:        "anthropic citations_delta",
+			provider:    ProviderAnthropic,
+			contentType: "text/event-stream",
+			body: `event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"citations_delta","citation":{"type":"web_search_result_location","url":"https://go.dev/doc/go1.24","title":"Go 1.24 Release Notes","cited_text":"Go 1.24 was released"}}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Go 1.24 is out."}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`,
+			want: []Citation{
+				{URL: "https://go.dev/doc/go1.24", Title: "Go 1.24 Release Notes", Text: "Go 1.24 was released"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			client, err := NewClient(WithProvider(tt.provider), WithAPIKey("test-key"), WithBaseURL(server.URL))
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+			reader, err := Stream(context.Background(), client, &Request{Messages: []Message{{Role: RoleUser, Content: "What's new in Go?"}}})
+			if err != nil {
+				t.Fatalf("Stream failed: %v", err)
+			}
+			defer reader.Close()
+
+			resp, err := AccumulateStream(reader)
+			if err != nil {
+				t.Fatalf("AccumulateStream failed: %v", err)
+			}
+			if resp.Text != "Go 1.24 is out." {
+				t.Errorf("unexpected text: %q", resp.Text)
+			}
+			if !slices.Equal(resp.Citations, tt.want) {
+				t.Errorf("unexpected citations:\n got %+v\nwant %+v", resp.Citations, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/openai/v1/chat/completions" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer gateway-key" {
+			t.Errorf("unexpected Authorization header: %q", got)
+		}
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["stream"] != true || body["model"] != "fast" {
+			t.Errorf("expected a streaming request for model alias, got %v", body)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Hello\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\" gateway\"},\"finish_reason\":\"stop\"}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	client, err := NewStreamClient(server.URL+"/openai/v1", WithAPIKey("gateway-key"))
+	if err != nil {
+		t.Fatalf("NewStreamClient failed: %v", err)
+	}
+	reader, err := client.Stream(context.Background(), &Request{
+		Model:    "fast",
+		Messages: []Message{{Role: RoleUser, Content: "hi"}},
+	})
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	resp, err := AccumulateStream(reader)
+	if err != nil {
+		t.Fatalf("AccumulateStream failed: %v", err)
+	}
+	if resp.Text != "Hello gateway" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	if _, err := NewStreamClient(server.URL); err == nil {
+		t.Error("expected an error without an API key")
+	}
+}
+
+func TestOpenAIStreamingLogprobs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["logprobs"] != true {
+			t.Errorf("expected logprobs to be requested, got %v", body)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Hello\"},\"logprobs\":{\"content\":[{\"token\":\"Hello\",\"logprob\":-0.1}]}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\" world\"},\"logprobs\":{\"content\":[{\"token\":\" world\",\"logprob\":-1.5}]}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithProvider(ProviderOpenAI), WithAPIKey("test-key"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	reader, err := Stream(context.Background(), client, &Request{
+		Model:    "gpt-4o",
+		Messages: []Message{{Role: RoleUser, Content: "hi"}},
+		Logprobs: true,
+	})
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	defer reader.Close()
+
+	var chunks int
+	var last *Response
+	for {
+		chunk, err := reader.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv error: %v", err)
+		}
+		if len(chunk.Logprobs) > 0 {
+			chunks++
+		}
+		last = chunk.Snapshot
+	}
+
+	want := []TokenLogprob{{Token: "Hello", Logprob: -0.1}, {Token: " world", Logprob: -1.5}}
+	if chunks != 2 {
+		t.Errorf("expected logprobs on 2 chunks, got %d", chunks)
+	}
+	if last == nil || !slices.Equal(last.Logprobs, want) {
+		t.Errorf("expected accumulated logprobs %v, got %+v", want, last)
+	}
+}
+
+func TestStreamSeq(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, word := range []string{"one", " two", " three"} {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", word)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithProvider(ProviderOpenAI), WithAPIKey("test-key"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	req := &Request{Messages: []Message{{Role: RoleUser, Content: "count"}}}
+
+	var got []string
+	var last *StreamChunk
+	for chunk, err := range StreamSeq(context.Background(), client, req) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if chunk.TextDelta != "" {
+			got = append(got, chunk.TextDelta)
+		}
+		last = chunk
+	}
+	if want := []string{"one", " two", " three"}; !slices.Equal(got, want) {
+		t.Errorf("expected chunks %q, got %q", want, got)
+	}
+	if last == nil || !last.Done || last.Snapshot.Text != "one two three" {
+		t.Errorf("expected a final done chunk with the full text, got %+v", last)
+	}
+
+	// Breaking early stops the iteration.
+	var n int
+	for range StreamSeq(context.Background(), client, req) {
+		n++
+		break
+	}
+	if n != 1 {
+		t.Errorf("expected one chunk before break, got %d", n)
+	}
+
+	// A stream that cannot start yields its error once.
+	var errs []error
+	for chunk, err := range StreamSeq(context.Background(), generateOnlyClient{}, req) {
+		if chunk != nil {
+			t.Errorf("expected no chunk with the error, got %+v", chunk)
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], ErrStreamingUnsupported) {
+		t.Errorf("expected a single ErrStreamingUnsupported, got %v", errs)
+	}
+}
+
+func TestGenerateViaStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["stream"] != true {
+			fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"Checking the weather.","tool_calls":[{"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Paris\"}"}}]},"finish_reason":"tool_calls"}]}`)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"role\":\"assistant\",\"content\":\"Checking \"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"the weather.\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"tool_calls\":[{\"index\":0,\"id\":\"call_1\",\"type\":\"function\",\"function\":{\"name\":\"get_weather\",\"arguments\":\"{\\\"city\\\":\"}}]}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"tool_calls\":[{\"index\":0,\"function\":{\"arguments\":\"\\\"Paris\\\"}\"}}]},\"finish_reason\":\"tool_calls\"}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithProvider(ProviderOpenAI), WithAPIKey("test-key"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	req := &Request{Messages: []Message{{Role: RoleUser, Content: "Weather in Paris?"}}}
+	want, err := client.Generate(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if len(want.ToolCalls) != 1 {
+		t.Fatalf("expected Generate to return the tool call, got %+v", want)
+	}
+	got, err := GenerateViaStream(context.Background(), client, req)
+	if err != nil {
+		t.Fatalf("GenerateViaStream failed: %v", err)
+	}
+	if got.Text != want.Text || got.FinishReason != want.FinishReason || !slices.Equal(got.ToolCalls, want.ToolCalls) {
+		t.Errorf("expected the streamed response to match Generate:\n got %+v\nwant %+v", got, want)
+	}
+
+	if _, err := GenerateViaStream(context.Background(), generateOnlyClient{}, req); !errors.Is(err, ErrStreamingUnsupported) {
+		t.Errorf("expected ErrStreamingUnsupported, got %v", err)
+	}
+}
+
+func TestStreamResponseMiddleware(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":\"hello\"}}]}\n\n")
+		fmt.Fprintf(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithProvider(ProviderOpenAI), WithAPIKey("test-key"), WithBaseURL(server.URL),
+		WithResponseMiddleware(func(resp *Response) error {
+			resp.Text = strings.ToUpper(resp.Text)
+			return nil
+		}))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	var last *StreamChunk
+	for chunk, err := range StreamSeq(context.Background(), client, &Request{Messages: []Message{{Role: RoleUser, Content: "hi"}}}) {
+		if err != nil {
+			t.Fatalf("stream error: %v", err)
+		}
+		last = chunk
+	}
+	if last == nil || !last.Done || last.Snapshot.Text != "HELLO" {
+		t.Fatalf("expected the middleware applied to the final snapshot, got %+v", last)
+	}
+}
+
+func TestStreamLargeEvent(t *testing.T) {
+	// Larger than bufio.MaxScanTokenSize (64KB), which would fail a bufio.Scanner.
+	bigArg := strings.Repeat("x", 200*1024)
+	args, _ := json.Marshal(fmt.Sprintf(`{"blob":%q}`, bigArg))
+
+	t.Run("sse", func(t *testing.T) {
+		stream := fmt.Sprintf("data: {\"choices\":[{\"delta\":{\"tool_calls\":[{\"index\":0,\"id\":\"call_1\",\"type\":\"function\",\"function\":{\"name\":\"save\",\"arguments\":%s}}]}}]}\n\ndata: [DONE]\n\n", args)
+		event, err := newSSEDecoder(strings.NewReader(stream)).Next()
+		if err != nil {
+			t.Fatalf("Next failed: %v", err)
+		}
+		chunk, _, err := (&openaiAdapter{}).parseStreamEvent(event, newStreamAccumulator())
+		if err != nil {
+			t.Fatalf("parseStreamEvent failed: %v", err)
+		}
+		if len(chunk.ToolCallDeltas) != 1 || !strings.Contains(chunk.ToolCallDeltas[0].ArgumentsDelta, bigArg) {
+			t.Errorf("expected the full tool call arguments, got %d deltas", len(chunk.ToolCallDeltas))
+		}
+	})
+
+	t.Run("json array", func(t *testing.T) {
+		stream := fmt.Sprintf(`[{"candidates":[{"content":{"parts":[{"text":%q}]}}]}]`, bigArg)
+		event, err := newJSONArrayDecoder(strings.NewReader(stream)).Next()
+		if err != nil {
+			t.Fatalf("Next failed: %v", err)
+		}
+		if len(event.Data) < len(bigArg) {
+			t.Errorf("expected the whole object, got %d bytes", len(event.Data))
+		}
+	})
+}
+
+// TestGeminiStreamBlockedPrompt tests that a stream whose prompt was blocked
+// fails with a ContentFilterError instead of ending empty
+func TestGeminiStreamBlockedPrompt(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `[{"promptFeedback":{"blockReason":"PROHIBITED_CONTENT"}}]`)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithProvider(ProviderGemini), WithAPIKey("test-key"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	var streamErr error
+	for _, err := range StreamSeq(context.Background(), client, &Request{Messages: []Message{{Role: RoleUser, Content: "hi"}}}) {
+		if err != nil {
+			streamErr = err
+			break
+		}
+	}
+	var filterErr *ContentFilterError
+	if !errors.As(streamErr, &filterErr) || filterErr.Reason != "PROHIBITED_CONTENT" {
+		t.Errorf("expected a PROHIBITED_CONTENT ContentFilterError, got %v", streamErr)
+	}
+}
+
+// TestOpenAIStreamingNamedEvents tests streams from OpenAI-compatible servers
+// that send "event:" lines and data-less events
+func TestOpenAIStreamingNamedEvents(t *testing.T) {
+	const stream = "event: message\ndata: {\"choices\":[{\"delta\":{\"content\":\"Hel\"}}]}\n\n" +
+		"event: ping\n\n" +
+		"event: message\ndata: {\"choices\":[{\"delta\":{\"content\":\"lo\"},\"finish_reason\":\"stop\"}]}\n\n" +
+		"event: message\ndata: [DONE]\n\n"
+
+	event, err := newSSEDecoder(strings.NewReader(stream)).Next()
+	if err != nil {
+		t.Fatalf("Next failed: %v", err)
+	}
+	if event.Event != "message" || !strings.HasPrefix(string(event.Data), "{") {
+		t.Errorf("expected a message event with JSON data, got %q %q", event.Event, event.Data)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, stream)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithProvider(ProviderOpenAI), WithAPIKey("test-key"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	var text string
+	var last *StreamChunk
+	for chunk, err := range StreamSeq(context.Background(), client, &Request{Messages: []Message{{Role: RoleUser, Content: "hi"}}}) {
+		if err != nil {
+			t.Fatalf("stream error: %v", err)
+		}
+		text += chunk.TextDelta
+		last = chunk
+	}
+	if text != "Hello" || last == nil || !last.Done {
+		t.Errorf("expected text Hello and a done chunk, got %q, %+v", text, last)
+	}
+}
+
+// TestStreamTimeout tests that streams are bounded by WithStreamTimeout rather
+// than the unary WithTimeout
+func TestStreamTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		for _, word := range []string{"one ", "two ", "three"} {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", word)
+			flusher.Flush()
+			select {
+			case <-time.After(150 * time.Millisecond):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	stream := func(streamTimeout time.Duration) (string, error) {
+		client, err := NewClient(WithProvider(ProviderOpenAI), WithAPIKey("test-key"), WithBaseURL(server.URL),
+			WithTimeout(100*time.Millisecond), WithStreamTimeout(streamTimeout))
+		if err != nil {
+			t.Fatalf("failed to create client: %v", err)
+		}
+		var text string
+		for chunk, err := range StreamSeq(context.Background(), client, &Request{Messages: []Message{{Role: RoleUser, Content: "count"}}}) {
+			if err != nil {
+				return text, err
+			}
+			text += chunk.TextDelta
+		}
+		return text, nil
+	}
+
+	text, err := stream(5 * time.Second)
+	if err != nil || text != "one two three" {
+		t.Errorf("expected the stream to outlive the unary timeout, got %q, %v", text, err)
+	}
+
+	_, err = stream(200 * time.Millisecond)
+	var timeoutErr *TimeoutError
+	if !errors.As(err, &timeoutErr) || timeoutErr.Duration != 200*time.Millisecond {
+		t.Errorf("expected a TimeoutError after the 200ms stream timeout, got %v", err)
+	}
+}
+
+// TestStreamFinishReasonThroughOpenAIFormat tests that a length-truncated
+// stream from each provider ends with finish_reason "length" in the
+// OpenAI-format output the gateway emits
+func TestStreamFinishReasonThroughOpenAIFormat(t *testing.T) {
+	tests := []struct {
+		provider    Provider
+		contentType string
+		body        string
+	}{
+		{ProviderOpenAI, "text/event-stream",
+			"data: {\"choices\":[{\"delta\":{\"content\":\"Once upon\"}}]}\n\n" +
+				"data: {\"choices\":[{\"delta\":{},\"finish_reason\":\"length\"}]}\n\n" +
+				"data: [DONE]\n\n"},
+		{ProviderAnthropic, "text/event-stream",
+			"event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}\n\n" +
+				"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"Once upon\"}}\n\n" +
+				"event: message_delta\ndata: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"max_tokens\"}}\n\n" +
+				"event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n"},
+		{ProviderGemini, "application/json",
+			`[{"candidates":[{"content":{"parts":[{"text":"Once upon"}]},"finishReason":"MAX_TOKENS"}]}]`},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.provider), func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			client, err := NewClient(WithProvider(tt.provider), WithAPIKey("test-key"), WithBaseURL(server.URL))
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+			rec := httptest.NewRecorder()
+			handler := NewOpenAIFormatConverter().NewStreamHandler("chatcmpl-test", "test-model")
+			handler.OnStart(rec, rec)
+			var last *StreamChunk
+			for chunk, err := range StreamSeq(context.Background(), client, &Request{Messages: []Message{{Role: RoleUser, Content: "Tell me a story."}}}) {
+				if err != nil {
+					t.Fatalf("stream error: %v", err)
+				}
+				if err := handler.OnChunk(rec, rec, chunk); err != nil {
+					t.Fatalf("OnChunk failed: %v", err)
+				}
+				last = chunk
+			}
+			handler.OnEnd(rec, rec)
+
+			if last == nil || !last.Done || last.FinishReason != FinishReasonLength || last.Snapshot.FinishReason != FinishReasonLength {
+				t.Fatalf("expected a done chunk with finish reason length, got %+v", last)
+			}
+			var finish string
+			for _, line := range strings.Split(rec.Body.String(), "\n") {
+				data, ok := strings.CutPrefix(line, "data: ")
+				if !ok || data == "[DONE]" {
+					continue
+				}
+				var chunk openAIStreamChunk
+				if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+					t.Fatalf("invalid SSE chunk %q: %v", data, err)
+				}
+				if fr := chunk.Choices[0].FinishReason; fr != "" {
+					finish = fr
+				}
+			}
+			if finish != "length" {
+				t.Errorf("expected finish_reason length, got %q", finish)
+			}
+		})
+	}
+}
+
+// TestOpenAIStreamingContentShapes tests that string and array-of-parts
+// content deltas accumulate to the same text
+func TestOpenAIStreamingContentShapes(t *testing.T) {
+	shapes := map[string][]string{
+		"string": {`null`, `"Hel"`, `"lo"`},
+		"parts":  {`null`, `[{"type":"text","text":"Hel"}]`, `[{"type":"text","text":"lo"}]`},
+	}
+	for name, contents := range shapes {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"role\":\"assistant\",\"content\":%s}}]}\n\n", contents[0])
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%s}}]}\n\n", contents[1])
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%s},\"finish_reason\":\"stop\"}]}\n\n", contents[2])
+			fmt.Fprint(w, "data: [DONE]\n\n")
+		}))
+
+		client, err := NewClient(WithProvider(ProviderOpenAI), WithAPIKey("test-key"), WithBaseURL(server.URL))
+		if err != nil {
+			t.Fatalf("failed to create client: %v", err)
+		}
+		var text string
+		var last *StreamChunk
+		for chunk, err := range StreamSeq(context.Background(), client, &Request{Messages: []Message{{Role: RoleUser, Content: "hi"}}}) {
+			if err != nil {
+				t.Fatalf("%s: stream error: %v", name, err)
+			}
+			text += chunk.TextDelta
+			last = chunk
+		}
+		server.Close()
+		if text != "Hello" || last == nil || last.Snapshot.Text != "Hello" {
+			t.Errorf("%s: expected accumulated text Hello, got %q, %+v", name, text, last)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package ai
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrContextTooLong is matched (via errors.Is) by *ContextTooLongError.
+var ErrContextTooLong = errors.New("request exceeds model context window")
+
+// ContextTooLongError is returned before any network call when the estimated
+// token count of a request exceeds the context window set with WithContextWindow
+// or known for the model (see WithTokenizer).
+type ContextTooLongError struct {
+	Tokens        int // Estimated tokens in the request
+	ContextWindow int // Context window checked against
+}
+
+func (e *ContextTooLongError) Error() string {
+	return fmt.Sprintf("%s: estimated %d tokens, limit %d", ErrContextTooLong, e.Tokens, e.ContextWindow)
+}
+
+func (e *ContextTooLongError) Unwrap() error {
+	return ErrContextTooLong
+}
+
+// Tokenizer counts the tokens in a piece of text. Implementations may be
+// approximate; they are only used for local pre-checks.
+type Tokenizer interface {
+	Count(text string) int
+}
+
+// WhitespaceTokenizer is a trivial Tokenizer that counts whitespace-separated words.
+// It underestimates real tokenizers and is meant as a cheap default.
+type WhitespaceTokenizer struct{}
+
+// Count returns the number of whitespace-separated words in text.
+func (WhitespaceTokenizer) Count(text string) int {
+	return len(strings.Fields(text))
+}
+
+// EstimateTokens returns t's token count for the text of a request: the system
+// prompt, message content and text parts, and tool call names and arguments.
+// Media parts and tool definitions are not counted.
+func EstimateTokens(t Tokenizer, req *Request) int {
+	n := t.Count(req.SystemPrompt)
+	for _, msg := range req.Messages {
+		n += t.Count(msg.Content)
+		for _, part := range msg.ContentParts {
+			if part.Type == ContentTypeText {
+				n += t.Count(part.Text)
+			}
+		}
+		for _, tc := range msg.ToolCalls {
+			n += t.Count(tc.Function) + t.Count(tc.Arguments)
+		}
+	}
+	return n
+}
+
+// checkContextWindow returns a *ContextTooLongError if req is estimated to exceed contextWindow.
+// A non-positive contextWindow disables the check.
+func checkContextWindow(t Tokenizer, contextWindow int, req *Request) error {
+	if contextWindow <= 0 {
+		return nil
+	}
+	if t == nil {
+		t = WhitespaceTokenizer{}
+	}
+	if n := EstimateTokens(t, req); n > contextWindow {
+		return &ContextTooLongError{Tokens: n, ContextWindow: contextWindow}
+	}
+	return nil
+}
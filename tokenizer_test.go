@@ -0,0 +1,135 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// charTokenizer counts one token per byte, making limits easy to hit in tests.
+type charTokenizer struct{}
+
+func (charTokenizer) Count(text string) int { return len(text) }
+
+func TestContextWindowPreCheck(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(
+		WithProvider(ProviderOpenAI),
+		WithAPIKey("test-key"),
+		WithBaseURL(server.URL),
+		WithTokenizer(charTokenizer{}),
+		WithContextWindow(10),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	_, err = client.Generate(context.Background(), &Request{
+		SystemPrompt: "be brief",
+		Messages:     []Message{{Role: RoleUser, Content: "hello"}},
+	})
+	var tooLong *ContextTooLongError
+	if !errors.As(err, &tooLong) || !errors.Is(err, ErrContextTooLong) {
+		t.Fatalf("expected *ContextTooLongError, got %T: %v", err, err)
+	}
+	if tooLong.Tokens != 13 || tooLong.ContextWindow != 10 {
+		t.Errorf("expected 13 tokens over a window of 10, got %d over %d", tooLong.Tokens, tooLong.ContextWindow)
+	}
+	if calls != 0 {
+		t.Errorf("expected no request to reach the provider, got %d", calls)
+	}
+
+	if _, err := client.Generate(context.Background(), &Request{
+		Messages: []Message{{Role: RoleUser, Content: "hi"}},
+	}); err != nil {
+		t.Fatalf("expected request within the window to succeed, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 provider request, got %d", calls)
+	}
+}
+
+func TestEstimateTokensWhitespace(t *testing.T) {
+	req := &Request{
+		SystemPrompt: "You are helpful.",
+		Messages: []Message{
+			{Role: RoleUser, ContentParts: []ContentPart{{Type: ContentTypeText, Text: "what is  the weather"}}},
+			{Role: RoleAssistant, ToolCalls: []ToolCall{{Function: "get_weather", Arguments: `{"city": "Paris"}`}}},
+			{Role: RoleTool, ToolCallID: "1", Content: "sunny"},
+		},
+	}
+	if got := EstimateTokens(WhitespaceTokenizer{}, req); got != 3+4+1+2+1 {
+		t.Errorf("expected 11 tokens, got %d", got)
+	}
+	// The whitespace tokenizer is the default when only a window is configured.
+	if err := checkContextWindow(nil, 10, req); !errors.Is(err, ErrContextTooLong) {
+		t.Errorf("expected ErrContextTooLong, got %v", err)
+	}
+	if err := checkContextWindow(nil, 0, req); err != nil {
+		t.Errorf("expected a zero window to disable the check, got %v", err)
+	}
+}
+
+// TestContextWindowFromModelInfo tests that a tokenizer enables the check against
+// a registered model's window, and that the default system prompt is counted.
+func TestContextWindowFromModelInfo(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`))
+	}))
+	defer server.Close()
+
+	newClient := func(opts ...Option) Client {
+		opts = append([]Option{
+			WithProvider(ProviderOpenAI),
+			WithAPIKey("test-key"),
+			WithBaseURL(server.URL),
+			WithModel("small-model"),
+			WithModelInfo(ModelInfo{ID: "small-model", ContextWindow: 10}),
+		}, opts...)
+		client, err := NewClient(opts...)
+		if err != nil {
+			t.Fatalf("failed to create client: %v", err)
+		}
+		return client
+	}
+	req := &Request{Messages: []Message{{Role: RoleUser, Content: "hi"}}}
+
+	// Without a tokenizer the listed window does not enable the check.
+	if _, err := newClient(WithDefaultSystemPrompt("be very brief")).Generate(context.Background(), req); err != nil {
+		t.Fatalf("expected no pre-check without a tokenizer, got %v", err)
+	}
+
+	client := newClient(WithTokenizer(charTokenizer{}), WithDefaultSystemPrompt("be very brief"))
+	_, err := client.Generate(context.Background(), req)
+	var tooLong *ContextTooLongError
+	if !errors.As(err, &tooLong) {
+		t.Fatalf("expected *ContextTooLongError, got %T: %v", err, err)
+	}
+	if tooLong.Tokens != 15 || tooLong.ContextWindow != 10 {
+		t.Errorf("expected 15 tokens over a window of 10, got %d over %d", tooLong.Tokens, tooLong.ContextWindow)
+	}
+	if _, err := Stream(context.Background(), client, req); !errors.Is(err, ErrContextTooLong) {
+		t.Errorf("expected Stream to fail the pre-check, got %v", err)
+	}
+
+	// Another model's window does not apply, and WithContextWindow overrides it.
+	if _, err := client.Generate(context.Background(), &Request{Model: "other-model", Messages: req.Messages}); err != nil {
+		t.Errorf("expected an unknown model to skip the pre-check, got %v", err)
+	}
+	if _, err := newClient(WithTokenizer(charTokenizer{}), WithContextWindow(100)).Generate(context.Background(), req); err != nil {
+		t.Errorf("expected WithContextWindow to override the listed window, got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 provider requests, got %d", calls)
+	}
+}
@@ -201,8 +201,8 @@ func (c *ToolServerClient) ExecuteTool(ctx context.Context, toolCall ToolCall) (
 	if err := c.ensureConnected(ctx); err != nil {
 		return "", err
 	}
-	var args map[string]any
-	if err := json.Unmarshal([]byte(toolCall.Arguments), &args); err != nil {
+	args, err := decodeArguments(toolCall.Arguments)
+	if err != nil {
 		return "", fmt.Errorf("failed to unmarshal tool arguments: %w", err)
 	}
 	params := &mcp.CallToolParams{Name: toolCall.Function, Arguments: args}